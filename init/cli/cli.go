@@ -27,20 +27,41 @@ func App() *cli.App {
 	app := cli.NewApp()
 	app.Name = "go-init"
 	app.Usage = "A simple init.sh-style service launcher CLI."
+	app.Flags = append(app.Flags, lockTimeoutFlag, serviceRootFlag, staticConfigFlag, customConfigFlag, pidDirFlag,
+		logDirFlag)
 
-	app.Subcommands = []cli.Command{startCliCommand, statusCliCommand, stopCliCommand}
+	app.Subcommands = []cli.Command{
+		startCliCommand,
+		statusCliCommand,
+		stopCliCommand,
+		restartCliCommand,
+		tryRestartCliCommand,
+		reloadCliCommand,
+		forceReloadCliCommand,
+		runCliCommand,
+		logsCliCommand,
+		metricsCliCommand,
+		execCliCommand,
+		explainCliCommand,
+		logWriterCliCommand,
+		shimCliCommand,
+	}
 	return app
 }
 
 func executeWithLoggers(action func(cli.Context, launchlib.ServiceLoggers) error, flags FileFlags) func(cli.Context) error {
 	return func(ctx cli.Context) (rErr error) {
+		paths, err := getServicePaths(ctx)
+		if err != nil {
+			return logErrorAndReturnWithExitCode(ctx, err, 4)
+		}
 		// Fall back to default stdout if error opening log file
-		if err := os.MkdirAll(logDir, 0755); err != nil {
+		if err := os.MkdirAll(paths.logDir, 0755); err != nil {
 			return logErrorAndReturnWithExitCode(
-				ctx, errors.Wrapf(err, "Error trying to make log directory '%s'", logDir), 4)
+				ctx, errors.Wrapf(err, "Error trying to make log directory '%s'", paths.logDir), 4)
 		}
 
-		loggers := NewFileLoggers(flags, outputFileMode)
+		loggers := newServiceFileLoggers(flags, outputFileMode, paths)
 
 		outputFile, err := loggers.PrimaryLogger()
 		if err != nil {
@@ -0,0 +1,76 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"github.com/palantir/go-java-launcher/launchlib"
+	"github.com/palantir/pkg/cli"
+	"github.com/palantir/pkg/cli/flag"
+	"github.com/pkg/errors"
+)
+
+const dryRunFlagName = "dry-run"
+
+var (
+	dryRunFlag = flag.BoolFlag{
+		Name:  dryRunFlagName,
+		Usage: "Prints the commands that would be run rather than starting anything",
+	}
+	dryRunFormatFlag = flag.StringFlag{
+		Name:  formatFlagName,
+		Value: launchlib.DryRunFormatJSON,
+		Usage: "Prints the commands of a dry run as 'json' or as 'shell' commands",
+	}
+)
+
+// executeUnlessDryRun runs the given action unless --dry-run is given, in which case it prints the commands of the
+// service instead. A dry run takes no lock and writes to no log, so that it can be run alongside other commands.
+func executeUnlessDryRun(action func(cli.Context) error) func(cli.Context) error {
+	return func(ctx cli.Context) error {
+		if ctx.Bool(dryRunFlagName) {
+			return dryRun(ctx)
+		}
+		return action(ctx)
+	}
+}
+
+// dryRun prints every command of the service as it would be run, whether or not it is running, with the output of
+// compiling them going to stderr.
+func dryRun(ctx cli.Context) error {
+	format := ctx.String(formatFlagName)
+	if format != launchlib.DryRunFormatJSON && format != launchlib.DryRunFormatShell {
+		return cli.WithExitCode(1, errors.Errorf("invalid format '%s', must be '%s' or '%s'", format,
+			launchlib.DryRunFormatJSON, launchlib.DryRunFormatShell))
+	}
+	paths, err := getServicePaths(ctx)
+	if err != nil {
+		return cli.WithExitCode(1, err)
+	}
+	staticConfig, customConfig, err := launchlib.GetConfigsFromFiles(paths.staticConfigFile, paths.customConfigFile,
+		ctx.App.Stderr)
+	if err != nil {
+		return cli.WithExitCode(1, errors.Wrap(err, "failed to read static and custom configuration files"))
+	}
+	serviceCmds, err := launchlib.CompileCmdsFromConfigInDir(paths.workingDir, &staticConfig, &customConfig,
+		launchlib.NewSimpleWriterLogger(ctx.App.Stderr))
+	if err != nil {
+		return cli.WithExitCode(1, errors.Wrap(err, "failed to compile commands from static and custom configurations"))
+	}
+	descriptions := launchlib.DescribeServiceCmds(paths.workingDir, &staticConfig, &customConfig, serviceCmds)
+	if err := launchlib.WriteDryRun(ctx.App.Stdout, descriptions, format); err != nil {
+		return cli.WithExitCode(1, errors.Wrap(err, "failed to print commands"))
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"fmt"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+	"github.com/palantir/pkg/cli"
+)
+
+// eventSource is the source of the lifecycle events recorded by go-init.
+const eventSource = "go-init"
+
+// recordEvent appends the given lifecycle event to the given event log. Events are timestamped with the wall clock
+// rather than Clock, since they are for alerting on rather than for go-init itself. For the same reason, failing to
+// append an event is logged rather than failing the go-init command.
+func recordEvent(ctx cli.Context, eventLogFile string, event launchlib.Event) {
+	if err := (launchlib.EventLog{Path: eventLogFile, Source: eventSource}).Append(event); err != nil {
+		_, _ = fmt.Fprintf(ctx.App.Stdout, "failed to record %s event: %v\n", event.Type, err)
+	}
+}
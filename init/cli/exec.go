@@ -0,0 +1,131 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+	"github.com/palantir/pkg/cli"
+	"github.com/palantir/pkg/cli/flag"
+	"github.com/pkg/errors"
+)
+
+const (
+	entrypointParamName = "entrypoint"
+
+	// extraArgsSeparator separates the arguments of go-init from the extra args of the entrypoint run by exec.
+	extraArgsSeparator = "--"
+)
+
+var execCliCommand = cli.Command{
+	Name: "exec",
+	Usage: `
+Runs the named entrypoint of the service in the foreground: the primary process as configured by the static and custom
+configurations, but with the mainClass, or executable, and args of the entrypoint, followed by any args given after --.
+Runs alongside the processes of the service, if running, and starts none of its subProcesses or initTasks.
+Forwards SIGHUP, SIGINT, SIGQUIT, SIGTERM, SIGUSR1 and SIGUSR2 to the entrypoint.
+Exits with the exit code of the entrypoint, 128 plus the number of the signal that terminated it, or 1 if it could not
+be started.`,
+	Flags: []flag.Flag{
+		flag.StringParam{Name: entrypointParamName, Usage: "The name of the entrypoint to run"},
+	},
+	Action: execEntrypoint,
+}
+
+// extraArgsKey is the key of the extra args of the entrypoint run by exec in the context of go-init.
+type extraArgsKey struct{}
+
+// Run runs the given go-init app with the given arguments, of which those following the first -- are not parsed but
+// passed to the entrypoint run by exec.
+func Run(app *cli.App, args []string) int {
+	var extraArgs []string
+	for i, arg := range args {
+		if arg == extraArgsSeparator {
+			args, extraArgs = args[:i], args[i+1:]
+			break
+		}
+	}
+	if extraArgs != nil {
+		app.ContextConfig = func(_ cli.Context, ctx context.Context) context.Context {
+			return context.WithValue(ctx, extraArgsKey{}, extraArgs)
+		}
+		app.Before = func(ctx cli.Context) error {
+			if ctx.Command.Name != execCliCommand.Name {
+				return errors.Errorf("only %s accepts arguments after %s", execCliCommand.Name, extraArgsSeparator)
+			}
+			return nil
+		}
+	}
+	return app.Run(args)
+}
+
+func execEntrypoint(ctx cli.Context) error {
+	paths, err := getServicePaths(ctx)
+	if err != nil {
+		return cli.WithExitCode(1, err)
+	}
+	staticConfig, customConfig, err := launchlib.GetConfigsFromFiles(paths.staticConfigFile, paths.customConfigFile,
+		ctx.App.Stderr)
+	if err != nil {
+		return cli.WithExitCode(1, errors.Wrap(err, "failed to read static and custom configuration files"))
+	}
+	if err := launchlib.MkDirsInDir(paths.workingDir, staticConfig.Dirs, ctx.App.Stderr); err != nil {
+		return cli.WithExitCode(1, errors.Wrap(err, "failed to create directories"))
+	}
+
+	// The output of go-init goes to stderr so that the stdout of the entrypoint, such as an admin tool, is only its own.
+	extraArgs, _ := ctx.Context().Value(extraArgsKey{}).([]string)
+	cmd, err := launchlib.CompileEntrypointCmdFromConfigInDir(paths.workingDir, ctx.String(entrypointParamName),
+		extraArgs, &staticConfig, &customConfig, launchlib.NewSimpleWriterLogger(ctx.App.Stderr).PrimaryLogger)
+	if err != nil {
+		return cli.WithExitCode(1, errors.Wrap(err, "failed to compile entrypoint from static and custom configurations"))
+	}
+	cmd.Dir = paths.workingDir
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = ctx.App.Stdout
+	cmd.Stderr = ctx.App.Stderr
+
+	// The cli package exits as soon as it receives SIGTERM or SIGINT, so its handling of them is reset so that they are
+	// forwarded instead, as the shim does.
+	signal.Reset(syscall.SIGTERM, syscall.SIGINT)
+	signals := make(chan os.Signal, len(shimForwardedSignals))
+	signal.Notify(signals, shimForwardedSignals...)
+	defer signal.Stop(signals)
+
+	startedAt := Clock.Now()
+	if err := cmd.Start(); err != nil {
+		return cli.WithExitCode(1, errors.Wrapf(err, "failed to start '%s'", cmd.Path))
+	}
+	go func() {
+		for sig := range signals {
+			_ = cmd.Process.Signal(sig)
+		}
+	}()
+	if err := cmd.Wait(); err != nil {
+		if _, ok := err.(*exec.ExitError); !ok {
+			return cli.WithExitCode(1, errors.Wrapf(err, "failed to wait for '%s'", cmd.Path))
+		}
+	}
+	if code := newExitRecord(cmd.ProcessState, startedAt, Clock.Now()).shimExitCode(); code != 0 {
+		// An empty error is not printed, so the exit code is reported without adding to the output of the entrypoint.
+		return cli.WithExitCode(code, errors.New(""))
+	}
+	return nil
+}
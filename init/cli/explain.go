@@ -0,0 +1,66 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"github.com/palantir/go-java-launcher/launchlib"
+	"github.com/palantir/pkg/cli"
+	"github.com/palantir/pkg/cli/flag"
+	"github.com/pkg/errors"
+)
+
+var explainFormatFlag = flag.StringFlag{
+	Name:  formatFlagName,
+	Value: launchlib.ExplainFormatText,
+	Usage: "Prints the explanation as a 'text' table or as 'json'",
+}
+
+var explainCliCommand = cli.Command{
+	Name: "explain",
+	Usage: `
+Prints where each JVM arg of the java processes of the service defined by the static and custom configurations at
+service/bin/launcher-static.yml and var/conf/launcher-custom.yml comes from: the jvmOpts of the static or custom
+configuration, container support or the classpath. The -Xmx and -Xms args that container support drops are listed
+along with the reason they are dropped. Takes no lock and writes no logs, so that it can be run alongside other
+commands.
+Exits:
+- 0 if the explanation was printed
+- 1 if the configuration could not be read or the explanation could not be printed
+If exit code is nonzero, writes an error message to stderr.`,
+	Flags:  []flag.Flag{explainFormatFlag},
+	Action: explain,
+}
+
+func explain(ctx cli.Context) error {
+	format := ctx.String(formatFlagName)
+	if format != launchlib.ExplainFormatJSON && format != launchlib.ExplainFormatText {
+		return cli.WithExitCode(1, errors.Errorf("invalid format '%s', must be '%s' or '%s'", format,
+			launchlib.ExplainFormatJSON, launchlib.ExplainFormatText))
+	}
+	paths, err := getServicePaths(ctx)
+	if err != nil {
+		return cli.WithExitCode(1, err)
+	}
+	staticConfig, customConfig, err := launchlib.GetConfigsFromFiles(paths.staticConfigFile, paths.customConfigFile,
+		ctx.App.Stderr)
+	if err != nil {
+		return cli.WithExitCode(1, errors.Wrap(err, "failed to read static and custom configuration files"))
+	}
+	explanations := launchlib.ExplainServiceJvmArgs(paths.workingDir, &staticConfig, &customConfig)
+	if err := launchlib.WriteExplanations(ctx.App.Stdout, explanations, format); err != nil {
+		return cli.WithExitCode(1, errors.Wrap(err, "failed to print explanation"))
+	}
+	return nil
+}
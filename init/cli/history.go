@@ -0,0 +1,232 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+	"github.com/palantir/pkg/cli"
+	"github.com/pkg/errors"
+)
+
+const (
+	forceFlagName = "force"
+
+	// crashLoopExitCode is the exit code of go-init start when it refuses to start a crash looping process.
+	crashLoopExitCode = 151
+	// minHistoryLength is the number of starts kept in the history of a process, unless more are needed to detect that
+	// it is crash looping.
+	minHistoryLength = 20
+)
+
+// startHistory is the record of the recent starts of a process and of how each of them ended, as kept in
+// var/run/${PROCESS}.history so that a process that keeps exiting by itself soon after being started can be detected.
+type startHistory struct {
+	Starts []historyEntry `json:"starts"`
+	// StartCount is the number of times the process has been started, including starts no longer kept in Starts.
+	StartCount int `json:"startCount,omitempty"`
+}
+
+type historyEntry struct {
+	Pid       int       `json:"pid"`
+	StartedAt time.Time `json:"startedAt"`
+	// ExitedAt and ExitReason are unset if it is not known how the process exited, e.g. because it is still running.
+	ExitedAt   *time.Time `json:"exitedAt,omitempty"`
+	ExitReason string     `json:"exitReason,omitempty"`
+	// Stopped is whether the process was stopped by go-init rather than exiting by itself.
+	Stopped bool `json:"stopped,omitempty"`
+}
+
+// recordStart adds a start of the process with the given pid at the given time, keeping at most the given number of
+// the most recent starts.
+func (h *startHistory) recordStart(pid int, startedAt time.Time, length int) {
+	h.StartCount = h.starts() + 1
+	h.Starts = append(h.Starts, historyEntry{Pid: pid, StartedAt: startedAt})
+	if len(h.Starts) > length {
+		h.Starts = h.Starts[len(h.Starts)-length:]
+	}
+}
+
+// recordExit records how the started process that the given exit record is of exited, if it is in the history.
+func (h *startHistory) recordExit(exit *exitRecord) {
+	if entry := h.lastStartOf(exit.Pid); entry != nil && entry.ExitedAt == nil {
+		exitedAt := exit.ExitedAt
+		entry.ExitedAt = &exitedAt
+		entry.ExitReason = exit.reason()
+	}
+}
+
+// recordStopped records that the started process with the given pid was stopped by go-init, and returns whether it is
+// in the history.
+func (h *startHistory) recordStopped(pid int) bool {
+	entry := h.lastStartOf(pid)
+	if entry == nil {
+		return false
+	}
+	entry.Stopped = true
+	return true
+}
+
+// starts returns the number of times the process has been started. Histories written by older versions of go-init
+// have no start count, so only the starts they keep are counted.
+func (h startHistory) starts() int {
+	if h.StartCount < len(h.Starts) {
+		return len(h.Starts)
+	}
+	return h.StartCount
+}
+
+func (h *startHistory) lastStartOf(pid int) *historyEntry {
+	for i := len(h.Starts) - 1; i >= 0; i-- {
+		if h.Starts[i].Pid == pid {
+			return &h.Starts[i]
+		}
+	}
+	return nil
+}
+
+// crashLoopUntil returns whether the process has exited by itself at least as many times as the given crash loop
+// configuration allows within its window before the given time and, if so, when enough of those exits will have left
+// the window for the process to be started again.
+func (h startHistory) crashLoopUntil(crashLoop launchlib.CrashLoopConfig, now time.Time) (time.Time, []historyEntry,
+	bool) {
+	windowStart := now.Add(-crashLoop.Window)
+	var crashes []historyEntry
+	for _, entry := range h.Starts {
+		if entry.ExitedAt != nil && !entry.Stopped && entry.ExitedAt.After(windowStart) {
+			crashes = append(crashes, entry)
+		}
+	}
+	if len(crashes) < crashLoop.MaxCrashes {
+		return time.Time{}, nil, false
+	}
+	sort.SliceStable(crashes, func(i, j int) bool {
+		return crashes[i].ExitedAt.Before(*crashes[j].ExitedAt)
+	})
+	return crashes[len(crashes)-crashLoop.MaxCrashes].ExitedAt.Add(crashLoop.Window), crashes, true
+}
+
+// readHistory returns the history in the given history file, which is empty if there is none.
+func readHistory(historyFile string) (startHistory, error) {
+	historyBytes, err := ioutil.ReadFile(historyFile)
+	if os.IsNotExist(err) {
+		return startHistory{}, nil
+	}
+	if err != nil {
+		return startHistory{}, errors.Wrap(err, "failed to read history file")
+	}
+	var history startHistory
+	if err := json.Unmarshal(historyBytes, &history); err != nil {
+		return startHistory{}, errors.Wrapf(err, "history file '%s' did not contain a start history", historyFile)
+	}
+	return history, nil
+}
+
+// writeHistory writes the given history to the given history file, replacing it atomically.
+func writeHistory(historyFile string, history startHistory) error {
+	if err := os.MkdirAll(filepath.Dir(historyFile), 0755); err != nil {
+		return errors.Wrap(err, "unable to create history file directory")
+	}
+	historyBytes, err := json.Marshal(history)
+	if err != nil {
+		return errors.Wrap(err, "failed to serialize start history")
+	}
+	tmpFile := historyFile + ".tmp"
+	if err := ioutil.WriteFile(tmpFile, historyBytes, 0644); err != nil {
+		return errors.Wrapf(err, "failed to write start history to '%s'", tmpFile)
+	}
+	if err := os.Rename(tmpFile, historyFile); err != nil {
+		return errors.Wrapf(err, "failed to write start history to '%s'", historyFile)
+	}
+	return nil
+}
+
+// readCommandHistory returns the history of the given command, along with how it last exited if that is not yet in
+// its history.
+func readCommandHistory(cmd CommandContext) (startHistory, error) {
+	history, err := readHistory(cmd.HistoryFile)
+	if err != nil {
+		return startHistory{}, err
+	}
+	if exit, err := readExitRecord(cmd.ExitFile); err == nil && exit != nil {
+		history.recordExit(exit)
+	}
+	return history, nil
+}
+
+// updateHistory applies the given update to the history of the given command, and writes the history if the update
+// changed it. The history only informs whether the command is crash looping, so failing to update it is logged rather
+// than failing the go-init command.
+func updateHistory(ctx cli.Context, name string, cmd CommandContext, update func(*startHistory) bool) {
+	history, err := readCommandHistory(cmd)
+	if err != nil {
+		_, _ = fmt.Fprintf(ctx.App.Stdout, "Discarding the start history of command '%s': %v\n", name, err)
+		history = startHistory{}
+	}
+	if !update(&history) {
+		return
+	}
+	if err := writeHistory(cmd.HistoryFile, history); err != nil {
+		_, _ = fmt.Fprintf(ctx.App.Stdout, "failed to update the start history of command '%s': %v\n", name, err)
+	}
+}
+
+// historyLength is the number of starts kept in the history of the given command.
+func historyLength(cmd CommandContext) int {
+	if cmd.Start.CrashLoop != nil && cmd.Start.CrashLoop.MaxCrashes > minHistoryLength {
+		return cmd.Start.CrashLoop.MaxCrashes
+	}
+	return minHistoryLength
+}
+
+// checkCrashLoops returns an error describing the given commands that are crash looping as per their crash loop
+// configuration, if any are.
+func checkCrashLoops(ctx cli.Context, cmds map[string]CommandContext) error {
+	names := commandNames(cmds)
+	sort.Strings(names)
+	var loops []string
+	for _, name := range names {
+		cmd := cmds[name]
+		if cmd.Start.CrashLoop == nil {
+			continue
+		}
+		history, err := readCommandHistory(cmd)
+		if err != nil {
+			_, _ = fmt.Fprintf(ctx.App.Stdout, "Not checking whether command '%s' is crash looping: %v\n", name, err)
+			continue
+		}
+		until, crashes, looping := history.crashLoopUntil(*cmd.Start.CrashLoop, Clock.Now())
+		if !looping {
+			continue
+		}
+		last := crashes[len(crashes)-1]
+		loops = append(loops, fmt.Sprintf("command '%s' exited by itself %d times within %s, most recently %s, so "+
+			"will not be started before %s", name, len(crashes), cmd.Start.CrashLoop.Window, last.ExitReason,
+			until.UTC().Format(time.RFC3339)))
+	}
+	if len(loops) == 0 {
+		return nil
+	}
+	return errors.Errorf("refusing to start crash looping commands unless --%s is given: %s", forceFlagName,
+		strings.Join(loops, "; "))
+}
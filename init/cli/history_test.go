@@ -0,0 +1,150 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+
+	time2 "github.com/palantir/go-java-launcher/init/cli/time"
+	"github.com/palantir/go-java-launcher/launchlib"
+	"github.com/palantir/pkg/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testCrashLoop = launchlib.CrashLoopConfig{MaxCrashes: 2, Window: 10 * time.Minute}
+
+func historyWithExits(start time.Time, exits ...time.Duration) startHistory {
+	var history startHistory
+	for i, exit := range exits {
+		history.recordStart(i+1, start, minHistoryLength)
+		history.recordExit(&exitRecord{Pid: i + 1, ExitCode: 1, ExitedAt: start.Add(exit)})
+	}
+	return history
+}
+
+func TestStartHistory_CrashLoopUntil(t *testing.T) {
+	start := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	_, _, looping := historyWithExits(start, time.Minute).crashLoopUntil(testCrashLoop, start.Add(2*time.Minute))
+	assert.False(t, looping, "a single crash should not be a crash loop")
+
+	history := historyWithExits(start, time.Minute, 2*time.Minute, 3*time.Minute)
+	until, crashes, looping := history.crashLoopUntil(testCrashLoop, start.Add(4*time.Minute))
+	require.True(t, looping)
+	assert.Len(t, crashes, 3)
+	assert.Equal(t, start.Add(12*time.Minute), until, "should wait until all but one crash have left the window")
+
+	_, _, looping = history.crashLoopUntil(testCrashLoop, until)
+	assert.False(t, looping, "crashes that have left the window should not count")
+}
+
+func TestStartHistory_IgnoresStoppedAndRunningStarts(t *testing.T) {
+	start := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	history := historyWithExits(start, time.Minute, 2*time.Minute)
+	assert.True(t, history.recordStopped(2))
+	assert.False(t, history.recordStopped(3), "unknown pids should not be recorded")
+	history.recordStart(3, start.Add(3*time.Minute), minHistoryLength)
+
+	_, _, looping := history.crashLoopUntil(testCrashLoop, start.Add(4*time.Minute))
+	assert.False(t, looping)
+}
+
+func TestStartHistory_RecordExitMatchesLastStart(t *testing.T) {
+	start := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	history := historyWithExits(start, time.Minute)
+	history.recordStart(1, start.Add(2*time.Minute), minHistoryLength)
+	history.recordExit(&exitRecord{Pid: 1, ExitCode: 3, ExitedAt: start.Add(3 * time.Minute)})
+	history.recordExit(&exitRecord{Pid: 1, ExitCode: 4, ExitedAt: start.Add(4 * time.Minute)})
+
+	require.Len(t, history.Starts, 2)
+	assert.Equal(t, "exited with code 1 at 2026-01-01T00:01:00Z", history.Starts[0].ExitReason)
+	assert.Equal(t, "exited with code 3 at 2026-01-01T00:03:00Z", history.Starts[1].ExitReason)
+}
+
+func TestStartHistory_RecordStartKeepsLength(t *testing.T) {
+	start := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	var history startHistory
+	for pid := 1; pid <= 5; pid++ {
+		history.recordStart(pid, start, 3)
+	}
+	require.Len(t, history.Starts, 3)
+	assert.Equal(t, 3, history.Starts[0].Pid)
+	assert.Equal(t, 5, history.Starts[2].Pid)
+	assert.Equal(t, 5, history.starts(), "starts no longer kept should still be counted")
+}
+
+func TestStartHistory_StartsOfOlderHistory(t *testing.T) {
+	start := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	history := startHistory{Starts: []historyEntry{{Pid: 1, StartedAt: start}, {Pid: 2, StartedAt: start}}}
+	assert.Equal(t, 2, history.starts())
+	history.recordStart(3, start, minHistoryLength)
+	assert.Equal(t, 3, history.starts())
+}
+
+func TestWriteHistory(t *testing.T) {
+	historyFile := filepath.Join(t.TempDir(), "run", "primary.history")
+	history, err := readHistory(historyFile)
+	require.NoError(t, err)
+	assert.Empty(t, history.Starts)
+
+	written := historyWithExits(time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC), time.Minute)
+	require.NoError(t, writeHistory(historyFile, written))
+	history, err = readHistory(historyFile)
+	require.NoError(t, err)
+	assert.Equal(t, written, history)
+	assert.NoFileExists(t, historyFile+".tmp")
+}
+
+func TestCheckCrashLoops(t *testing.T) {
+	start := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	previousClock := Clock
+	clock := time2.NewFakeClockAt(start.Add(4 * time.Minute))
+	Clock = clock
+	defer func() {
+		Clock = previousClock
+	}()
+
+	runDir := t.TempDir()
+	crashLoop := testCrashLoop
+	cmds := map[string]CommandContext{
+		"primary": {
+			HistoryFile: filepath.Join(runDir, "primary.history"),
+			ExitFile:    filepath.Join(runDir, "primary.exit"),
+			Start:       launchlib.StartBehavior{CrashLoop: &crashLoop},
+		},
+		"sidecar": {
+			HistoryFile: filepath.Join(runDir, "sidecar.history"),
+			ExitFile:    filepath.Join(runDir, "sidecar.exit"),
+		},
+	}
+	history := historyWithExits(start, time.Minute, 2*time.Minute)
+	require.NoError(t, writeHistory(cmds["primary"].HistoryFile, history))
+	require.NoError(t, writeHistory(cmds["sidecar"].HistoryFile, history))
+
+	ctx := cli.Context{App: cli.NewApp()}
+	var out bytes.Buffer
+	ctx.App.Stdout = &out
+	assert.EqualError(t, checkCrashLoops(ctx, cmds), "refusing to start crash looping commands unless --force is "+
+		"given: command 'primary' exited by itself 2 times within 10m0s, most recently exited with code 1 at "+
+		"2026-01-01T00:02:00Z, so will not be started before 2026-01-01T00:11:00Z")
+
+	clock.Advance(7 * time.Minute)
+	assert.NoError(t, checkCrashLoops(ctx, cmds))
+	assert.Empty(t, out.String())
+}
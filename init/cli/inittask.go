@@ -0,0 +1,94 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"fmt"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+	"github.com/palantir/pkg/cli"
+	"github.com/pkg/errors"
+)
+
+// initTask is an init task of the service, along with the directories it needs and where its output is written to.
+type initTask struct {
+	launchlib.InitTaskCmd
+	Logger launchlib.CreateLogger
+	Dirs   []string
+	// OutputFile is the file the output of the task is written to.
+	OutputFile string
+}
+
+// newInitTasks returns the init tasks of the service given the compiled commands of its init tasks and their
+// configurations, which are in the same order.
+func newInitTasks(cmds []launchlib.InitTaskCmd, configs []launchlib.InitTaskConfig, loggers launchlib.ServiceLoggers,
+	paths servicePaths) []initTask {
+	tasks := make([]initTask, 0, len(cmds))
+	for i, cmd := range cmds {
+		cmd.Cmd.Dir = paths.workingDir
+		tasks = append(tasks, initTask{
+			InitTaskCmd: cmd,
+			Logger:      loggers.SubProcessLogger(cmd.Name),
+			Dirs:        configs[i].Dirs,
+			OutputFile:  paths.subProcessOutputFile(cmd.Name),
+		})
+	}
+	return tasks
+}
+
+// runInitTasks runs the init tasks of the service in order if its primary command is among the given commands to be
+// started, so that they are not run again when only a subProcess is started. It returns an error describing the first
+// task that fails, including the last lines of its log, in which case none of the commands should be started.
+func runInitTasks(ctx cli.Context, cmds map[string]CommandContext) error {
+	name, ok := primaryCommandName(cmds)
+	if !ok {
+		return nil
+	}
+	for _, task := range cmds[name].InitTasks {
+		_, _ = fmt.Fprintf(ctx.App.Stdout, "Running initTask '%s'\n", task.Name)
+		if err := runInitTask(ctx, task); err != nil {
+			return initTaskFailure(task, err)
+		}
+	}
+	return nil
+}
+
+func runInitTask(ctx cli.Context, task initTask) error {
+	if err := launchlib.MkDirsInDir(task.Cmd.Dir, task.Dirs, ctx.App.Stdout); err != nil {
+		return errors.Wrapf(err, "failed to create directories for initTask '%s'", task.Name)
+	}
+	logger, err := task.Logger()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cErr := logger.Close(); cErr != nil {
+			_, _ = fmt.Fprintf(ctx.App.Stdout, "failed to close logger for initTask '%s'\n", task.Name)
+		}
+	}()
+	task.Cmd.Stdout = logger
+	task.Cmd.Stderr = logger
+	return task.Run()
+}
+
+// initTaskFailure adds the tail of the output of the given init task to the given error describing its failure.
+func initTaskFailure(task initTask, err error) error {
+	tail, tailErr := tailLines(task.OutputFile, startFailureLogLines)
+	if tailErr != nil {
+		return errors.Wrapf(err, "failed to run initTask '%s' (unable to read its log: %v)", task.Name, tailErr)
+	}
+	return errors.Errorf("%v\nLast lines of the log of initTask '%s' at %s:\n%s", err, task.Name, task.OutputFile,
+		tail)
+}
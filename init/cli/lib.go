@@ -15,16 +15,14 @@
 package cli
 
 import (
-	"fmt"
-	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"strconv"
-	"strings"
 	"syscall"
+	"time"
 
 	ps "github.com/mitchellh/go-ps"
+	time2 "github.com/palantir/go-java-launcher/init/cli/time"
 	"github.com/palantir/go-java-launcher/launchlib"
 	"github.com/palantir/pkg/cli"
 	"github.com/pkg/errors"
@@ -40,27 +38,59 @@ const (
 )
 
 var (
-	launcherStaticFile = "service/bin/launcher-static.yml"
-	launcherCustomFile = "var/conf/launcher-custom.yml"
-	pidfileFormat      = "var/run/%s.pid"
-
-	logDir                     = "var/log"
-	PrimaryOutputFile          = filepath.Join(logDir, outputLogFile)
-	SubProcessOutputFileFormat = filepath.Join(logDir, "%s-"+outputLogFile)
+	// PrimaryOutputFile and SubProcessOutputFileFormat are the default locations of the output of the primary process
+	// and, formatted with their names, of subProcesses, as written by FileLoggers created with NewFileLoggers.
+	PrimaryOutputFile          = filepath.Join("var/log", outputLogFile)
+	SubProcessOutputFileFormat = filepath.Join("var/log", "%s-"+outputLogFile)
 )
 
 type CommandContext struct {
 	Command *exec.Cmd
 	Logger  launchlib.CreateLogger
 	Dirs    []string
+	Stop    launchlib.StopBehavior
+	Start   launchlib.StartBehavior
+	// ConfigHash is the hash of the arguments and configured environment variables of the compiled command.
+	ConfigHash string
+	// OutputFile is the file the output of the command is written to.
+	OutputFile string
+	// Pidfile is the file the record of the started command is written to.
+	Pidfile string
+	// ExitFile is the file the record of how the started command exited is written to.
+	ExitFile string
+	// HistoryFile is the file the recent starts of the command, and how each of them ended, are kept in.
+	HistoryFile string
+	// EventLogFile is the file the lifecycle events of the command are appended to.
+	EventLogFile string
+	// Primary is whether the command is that of the primary process rather than of a subProcess.
+	Primary bool
+	// InitTasks are run to completion, in order, before the service is started, and are only set for the primary
+	// command.
+	InitTasks []initTask
+	// LogRotation is nil if the output of the command is written directly to its output file rather than through a
+	// go-init log-writer that rotates it.
+	LogRotation *launchlib.LogRotationBehavior
+}
+
+// trackedProcess is a process started by go-init, along with the record of it read from its pidfile.
+type trackedProcess struct {
+	*os.Process
+	record pidfileRecord
 }
 
 type servicePids map[string]int
 
 type serviceStatus struct {
+	configuredCmds map[string]CommandContext
 	notRunningCmds map[string]CommandContext
 	writtenPids    servicePids
-	runningProcs   map[string]*os.Process
+	pidfileRecords map[string]pidfileRecord
+	runningProcs   map[string]*trackedProcess
+	// exitRecords are the records of how commands last exited, for those that have exited since go-init recorded it.
+	exitRecords map[string]exitRecord
+	// restartRequiredCmds are the running commands that were started with a command other than the one they are now
+	// configured with.
+	restartRequiredCmds map[string]CommandContext
 }
 
 func getServiceStatus(ctx cli.Context, loggers launchlib.ServiceLoggers) (*serviceStatus, error) {
@@ -70,23 +100,37 @@ func getServiceStatus(ctx cli.Context, loggers launchlib.ServiceLoggers) (*servi
 	}
 
 	currentStatus := &serviceStatus{
+		configuredCmds: cmds,
 		notRunningCmds: map[string]CommandContext{},
-		runningProcs:   map[string]*os.Process{},
+		runningProcs:   map[string]*trackedProcess{},
 		writtenPids:    servicePids{},
+		pidfileRecords: map[string]pidfileRecord{},
+		exitRecords:    map[string]exitRecord{},
+
+		restartRequiredCmds: map[string]CommandContext{},
 	}
 
 	for name, cmd := range cmds {
-		pid, process, err := getCmdProcess(name)
+		record, process, err := getCmdProcess(cmd)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to determine running processes")
 		}
 
-		if pid != nil {
-			currentStatus.writtenPids[name] = *pid
+		if record != nil {
+			currentStatus.writtenPids[name] = record.Pid
+			currentStatus.pidfileRecords[name] = *record
+		}
+		// How a command last exited does not affect whether it is running, so an unreadable exit record is ignored.
+		if exit, err := readExitRecord(cmd.ExitFile); err == nil && exit != nil {
+			currentStatus.exitRecords[name] = *exit
 		}
 
 		if process != nil {
 			currentStatus.runningProcs[name] = process
+			// Records written by older versions of go-init have no hash, so it is unknown whether they have drifted.
+			if record.ConfigHash != "" && record.ConfigHash != cmd.ConfigHash {
+				currentStatus.restartRequiredCmds[name] = cmd
+			}
 		} else {
 			currentStatus.notRunningCmds[name] = cmd
 		}
@@ -94,46 +138,67 @@ func getServiceStatus(ctx cli.Context, loggers launchlib.ServiceLoggers) (*servi
 	return currentStatus, nil
 }
 
-func getCmdProcess(name string) (*int, *os.Process, error) {
-	pidBytes, err := ioutil.ReadFile(fmt.Sprintf(pidfileFormat, name))
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, nil, nil
-		}
-		return nil, nil, errors.Wrap(err, "failed to read pidfile")
-	}
-
-	pid, err := strconv.Atoi(strings.TrimSpace(string(pidBytes)))
-	if err != nil {
-		return nil, nil, errors.Wrap(err, "pid file did not contain an integer")
+func getCmdProcess(cmd CommandContext) (*pidfileRecord, *trackedProcess, error) {
+	record, err := readPidfile(cmd.Pidfile)
+	if err != nil || record == nil {
+		return nil, nil, err
 	}
 
-	running, proc, err := isPidRunning(pid)
+	running, proc, err := isPidRunning(*record)
 	if err != nil {
 		return nil, nil, err
 	}
 	if running {
-		return &pid, proc, nil
+		return record, proc, nil
 	}
-	return &pid, nil, nil
+	return record, nil, nil
 }
 
 func getConfiguredCommands(ctx cli.Context, loggers launchlib.ServiceLoggers) (map[string]CommandContext, error) {
-	staticConfig, customConfig, err := launchlib.GetConfigsFromFiles(launcherStaticFile, launcherCustomFile,
+	paths, err := getServicePaths(ctx)
+	if err != nil {
+		return nil, err
+	}
+	staticConfig, customConfig, err := launchlib.GetConfigsFromFiles(paths.staticConfigFile, paths.customConfigFile,
 		ctx.App.Stdout)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to read static and custom configuration files")
+		err = errors.Wrap(err, "failed to read static and custom configuration files")
+		recordEvent(ctx, paths.eventLogFile(), launchlib.Event{Type: launchlib.EventConfigError, Message: err.Error()})
+		return nil, err
 	}
-	serviceCmds, err := launchlib.CompileCmdsFromConfig(&staticConfig, &customConfig, loggers)
+	serviceCmds, err := launchlib.CompileCmdsFromConfigInDir(paths.workingDir, &staticConfig, &customConfig, loggers)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to compile commands from static and custom configurations")
+		err = errors.Wrap(err, "failed to compile commands from static and custom configurations")
+		recordEvent(ctx, paths.eventLogFile(), launchlib.Event{Type: launchlib.EventConfigError, Message: err.Error()})
+		return nil, err
 	}
 
 	cmds := make(map[string]CommandContext)
+	stopBehavior, err := launchlib.ResolveStopBehavior(&staticConfig.StaticLauncherConfig,
+		&customConfig.CustomLauncherConfig)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to resolve stop configuration for '%s'", staticConfig.ServiceName)
+	}
+	configHash := launchlib.CommandHash(serviceCmds.Primary.Args, launchlib.ConfiguredEnv(
+		&staticConfig.StaticLauncherConfig, &customConfig.CustomLauncherConfig, paths.workingDir))
+	startBehavior := resolveStartBehavior(launchlib.ResolveStartBehavior(&staticConfig.StaticLauncherConfig,
+		&customConfig.CustomLauncherConfig), paths)
+	serviceCmds.Primary.Dir = paths.workingDir
 	cmds[staticConfig.ServiceName] = CommandContext{
-		serviceCmds.Primary,
-		loggers.PrimaryLogger,
-		staticConfig.Dirs,
+		Command:      serviceCmds.Primary,
+		Logger:       loggers.PrimaryLogger,
+		Dirs:         staticConfig.Dirs,
+		Stop:         stopBehavior,
+		Start:        startBehavior,
+		ConfigHash:   configHash,
+		OutputFile:   paths.primaryOutputFile(),
+		Pidfile:      paths.pidfile(staticConfig.ServiceName),
+		ExitFile:     paths.exitFile(staticConfig.ServiceName),
+		HistoryFile:  paths.historyFile(staticConfig.ServiceName),
+		EventLogFile: paths.eventLogFile(),
+		Primary:      true,
+		LogRotation:  launchlib.ResolveLogRotationBehavior(&staticConfig.StaticLauncherConfig),
+		InitTasks:    newInitTasks(serviceCmds.InitTasks, staticConfig.InitTasks, loggers, paths),
 	}
 	for name, subProc := range serviceCmds.SubProcesses {
 		subStatic, ok := staticConfig.SubProcesses[name]
@@ -141,18 +206,44 @@ func getConfiguredCommands(ctx cli.Context, loggers launchlib.ServiceLoggers) (m
 			return nil, errors.Errorf("command given for non-existent subProcess '%s'", name)
 		}
 
+		subCustom := customConfig.SubProcesses[name]
+		stopBehavior, err := launchlib.ResolveStopBehavior(&subStatic, &subCustom)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to resolve stop configuration for '%s'", name)
+		}
+		subProc.Dir = paths.workingDir
 		cmds[name] = CommandContext{
-			subProc,
-			loggers.SubProcessLogger(name),
-			subStatic.Dirs,
+			Command:      subProc,
+			Logger:       loggers.SubProcessLogger(name),
+			Dirs:         subStatic.Dirs,
+			Stop:         stopBehavior,
+			Start:        resolveStartBehavior(launchlib.ResolveStartBehavior(&subStatic, &subCustom), paths),
+			ConfigHash:   launchlib.CommandHash(subProc.Args, launchlib.ConfiguredEnv(&subStatic, &subCustom, paths.workingDir)),
+			OutputFile:   paths.subProcessOutputFile(name),
+			Pidfile:      paths.pidfile(name),
+			ExitFile:     paths.exitFile(name),
+			HistoryFile:  paths.historyFile(name),
+			EventLogFile: paths.eventLogFile(),
+			LogRotation:  launchlib.ResolveLogRotationBehavior(&subStatic),
 		}
 	}
 	return cmds, nil
 }
 
-func isPidRunning(pid int) (bool, *os.Process, error) {
+// resolveStartBehavior returns the given start behavior with its readiness file resolved against the service root.
+func resolveStartBehavior(behavior launchlib.StartBehavior, paths servicePaths) launchlib.StartBehavior {
+	if behavior.Readiness != nil && behavior.Readiness.File != "" {
+		readiness := *behavior.Readiness
+		readiness.File = paths.resolve(readiness.File)
+		behavior.Readiness = &readiness
+	}
+	return behavior
+}
+
+func isPidRunning(record pidfileRecord) (bool, *trackedProcess, error) {
 	// Docs say FindProcess always succeeds on Unix.
-	proc, _ := os.FindProcess(pid)
+	osProc, _ := os.FindProcess(record.Pid)
+	proc := &trackedProcess{Process: osProc, record: record}
 	running, err := isProcRunning(proc)
 	if err != nil {
 		return false, nil, err
@@ -163,7 +254,9 @@ func isPidRunning(pid int) (bool, *os.Process, error) {
 	return false, nil, nil
 }
 
-func isProcRunning(proc *os.Process) (bool, error) {
+// isProcRunning returns whether the given process is running and is the process recorded in its pidfile, as opposed to
+// an unrelated process that reused its pid.
+func isProcRunning(proc *trackedProcess) (bool, error) {
 	// This is the way to check if a process exists: https://linux.die.net/man/2/kill.
 	// On linux, this may respond true if there is a thread running with the same id.
 	running := proc.Signal(syscall.Signal(0)) == nil
@@ -179,8 +272,36 @@ func isProcRunning(proc *os.Process) (bool, error) {
 	}
 	for _, p := range procs {
 		if p.Pid() == proc.Pid {
-			return true, nil
+			return proc.record.identifiesProcess()
 		}
 	}
 	return false, nil
 }
+
+// newDeadlineTimers creates one timer per given duration up front, since timers cannot be created while the clock is
+// being advanced in tests, and returns a channel on which each duration is sent once its timer fires, along with a
+// function that stops the timers.
+func newDeadlineTimers(durations map[time.Duration]struct{}) (<-chan time.Duration, func()) {
+	expired := make(chan time.Duration, len(durations))
+	done := make(chan struct{})
+	timers := make([]time2.Timer, 0, len(durations))
+	for duration := range durations {
+		timer := Clock.NewTimer(duration)
+		timers = append(timers, timer)
+		go func(duration time.Duration) {
+			select {
+			case _, ok := <-timer.Chan():
+				if ok {
+					expired <- duration
+				}
+			case <-done:
+			}
+		}(duration)
+	}
+	return expired, func() {
+		close(done)
+		for _, timer := range timers {
+			timer.Stop()
+		}
+	}
+}
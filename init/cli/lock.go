@@ -0,0 +1,148 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	time2 "github.com/palantir/go-java-launcher/init/cli/time"
+	"github.com/palantir/pkg/cli"
+	"github.com/palantir/pkg/cli/flag"
+	"github.com/pkg/errors"
+)
+
+const (
+	lockTimeoutFlagName = "lock-timeout"
+	lockPollInterval    = 100 * time.Millisecond
+
+	// LSB reserves exit codes 150-199 for use by applications.
+	lockContendedExitCode = 150
+)
+
+var lockTimeoutFlag = flag.DurationFlag{
+	Name:  lockTimeoutFlagName,
+	Value: "30s",
+	Usage: "How long to wait for another go-init command acting on the service to finish before failing",
+}
+
+// executeWithLock runs the given action while holding an exclusive advisory lock on the lock file, so that go-init
+// commands that change the state of the service do not run concurrently. The lock is taken before anything, including
+// the startup log, is written. If the lock cannot be taken, the error is appended to the startup log.
+func executeWithLock(action func(cli.Context) error) func(cli.Context) error {
+	return executeWithReleasableLock(func(ctx cli.Context, _ func()) error {
+		return action(ctx)
+	})
+}
+
+// executeWithReleasableLock runs the given action as executeWithLock does, but passes it a function that releases the
+// lock before the action returns, for actions that keep running once they have changed the state of the service.
+func executeWithReleasableLock(action func(cli.Context, func()) error) func(cli.Context) error {
+	return func(ctx cli.Context) error {
+		timeout := ctx.Duration(lockTimeoutFlagName)
+		if timeout < 0 {
+			return logErrorAndReturnWithExitCode(ctx,
+				errors.Errorf("lock timeout must not be negative, found %s", timeout), 2)
+		}
+		paths, err := getServicePaths(ctx)
+		if err != nil {
+			return logErrorAndReturnWithExitCode(ctx, err, 1)
+		}
+		lock, err := acquireLock(paths.lockFile(), timeout)
+		if err != nil {
+			// The holder of the lock may be writing to the startup log, so it is only ever appended to.
+			if err := os.MkdirAll(paths.logDir, 0755); err == nil {
+				outputFile, err := os.OpenFile(paths.primaryOutputFile(), appendOutputFileFlag, outputFileMode)
+				if err == nil {
+					defer func() {
+						_ = outputFile.Close()
+					}()
+					ctx.App.Stdout = outputFile
+				}
+			}
+			return logErrorAndReturnWithExitCode(ctx, err, lockContendedExitCode)
+		}
+		var release sync.Once
+		releaseLock := func() {
+			release.Do(func() {
+				// Closing the file releases the lock.
+				_ = lock.Close()
+			})
+		}
+		defer releaseLock()
+		return action(ctx, releaseLock)
+	}
+}
+
+// acquireLock takes an exclusive lock on the lock file, waiting up to the given timeout for it to be released if
+// another process holds it.
+func acquireLock(lockFile string, timeout time.Duration) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(lockFile), 0755); err != nil {
+		return nil, errors.Wrap(err, "unable to create lock file directory")
+	}
+	lock, err := os.OpenFile(lockFile, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open lock file")
+	}
+
+	var timer time2.Timer
+	var ticker time2.Ticker
+	for {
+		locked, err := tryLock(lock)
+		if err != nil {
+			_ = lock.Close()
+			return nil, err
+		}
+		if locked {
+			return lock, nil
+		}
+		if timeout == 0 {
+			_ = lock.Close()
+			return nil, lockContendedError(lockFile, timeout)
+		}
+		// The timer and ticker are only created once the lock is found to be contended, so that uncontended
+		// commands do not add sleepers to the clock.
+		if timer == nil {
+			timer = Clock.NewTimer(timeout)
+			defer timer.Stop()
+			ticker = Clock.NewTicker(lockPollInterval)
+			defer ticker.Stop()
+		}
+		select {
+		case <-ticker.Chan():
+		case <-timer.Chan():
+			_ = lock.Close()
+			return nil, lockContendedError(lockFile, timeout)
+		}
+	}
+}
+
+func tryLock(lock *os.File) (bool, error) {
+	err := syscall.Flock(int(lock.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if err == syscall.EWOULDBLOCK {
+		return false, nil
+	} else if err != nil {
+		return false, errors.Wrap(err, "failed to lock lock file")
+	}
+	return true, nil
+}
+
+func lockContendedError(lockFile string, timeout time.Duration) error {
+	return errors.Errorf("another go-init command is acting on the service and did not finish within %s, as the "+
+		"lock on '%s' is still held", timeout, lockFile)
+}
@@ -0,0 +1,31 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"testing"
+
+	"github.com/palantir/pkg/cli/flag"
+	"github.com/stretchr/testify/assert"
+)
+
+// To prevent accidental changes to parameter default values
+func TestInit_DefaultGlobalParameters(t *testing.T) {
+	assert.Contains(t, App().Flags, flag.Flag(flag.DurationFlag{
+		Name:  "lock-timeout",
+		Value: "30s",
+		Usage: "How long to wait for another go-init command acting on the service to finish before failing",
+	}))
+}
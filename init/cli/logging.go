@@ -19,13 +19,17 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
-	"strconv"
+	"strings"
 
 	"github.com/palantir/go-java-launcher/launchlib"
 	"github.com/pkg/errors"
 )
 
-const limit = 5
+const (
+	limit = 5
+
+	tailMaxBytes = 64 * 1024
+)
 
 type FileFlags interface {
 	Get(path string) int
@@ -60,26 +64,44 @@ func NewAlwaysAppending() FileFlags {
 }
 
 type FileLoggers struct {
-	flags   FileFlags
-	mode    os.FileMode
-	rotated map[string]struct{}
+	flags                FileFlags
+	mode                 os.FileMode
+	primaryOutputFile    string
+	subProcessOutputFile func(name string) string
+	rotated              map[string]struct{}
 }
 
+// NewFileLoggers creates loggers that write to PrimaryOutputFile and SubProcessOutputFileFormat.
 func NewFileLoggers(flags FileFlags, mode os.FileMode) *FileLoggers {
 	return &FileLoggers{
-		flags:   flags,
-		mode:    mode,
+		flags:             flags,
+		mode:              mode,
+		primaryOutputFile: PrimaryOutputFile,
+		subProcessOutputFile: func(name string) string {
+			return fmt.Sprintf(SubProcessOutputFileFormat, name)
+		},
 		rotated: make(map[string]struct{}),
 	}
 }
 
+// newServiceFileLoggers creates loggers that write to the output files in the log directory of the given paths.
+func newServiceFileLoggers(flags FileFlags, mode os.FileMode, paths servicePaths) *FileLoggers {
+	return &FileLoggers{
+		flags:                flags,
+		mode:                 mode,
+		primaryOutputFile:    paths.primaryOutputFile(),
+		subProcessOutputFile: paths.subProcessOutputFile,
+		rotated:              make(map[string]struct{}),
+	}
+}
+
 func (f *FileLoggers) PrimaryLogger() (io.WriteCloser, error) {
-	return f.OpenFile(PrimaryOutputFile)
+	return f.OpenFile(f.primaryOutputFile)
 }
 
 func (f *FileLoggers) SubProcessLogger(name string) launchlib.CreateLogger {
 	return func() (io.WriteCloser, error) {
-		return f.OpenFile(fmt.Sprintf(SubProcessOutputFileFormat, name))
+		return f.OpenFile(f.subProcessOutputFile(name))
 	}
 }
 
@@ -98,11 +120,7 @@ func (f *FileLoggers) OpenFile(path string) (*os.File, error) {
 }
 
 func rotate(path string) {
-	_ = os.Remove(path + "." + strconv.Itoa(limit))
-	for i := limit; i > 0; i-- {
-		_ = os.Rename(path+"."+strconv.Itoa(i-1), path+"."+strconv.Itoa(i))
-	}
-	_ = os.Rename(path, path+".0")
+	rotateFile(path, limit)
 }
 
 var devNull = launchlib.NoopClosingWriter{Writer: ioutil.Discard}
@@ -116,3 +134,34 @@ func (d *DevNullLoggers) PrimaryLogger() (io.WriteCloser, error) {
 func (d *DevNullLoggers) SubProcessLogger(name string) launchlib.CreateLogger {
 	return d.PrimaryLogger
 }
+
+// tailLines returns up to the last n lines of the file at the given path, reading at most the last tailMaxBytes bytes.
+func tailLines(path string, n int) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to open '%s'", path)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	info, err := file.Stat()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to stat '%s'", path)
+	}
+	if info.Size() > tailMaxBytes {
+		if _, err := file.Seek(-tailMaxBytes, io.SeekEnd); err != nil {
+			return "", errors.Wrapf(err, "failed to seek in '%s'", path)
+		}
+	}
+	content, err := ioutil.ReadAll(file)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read '%s'", path)
+	}
+
+	lines := strings.Split(strings.TrimRight(string(content), "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n"), nil
+}
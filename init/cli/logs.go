@@ -0,0 +1,501 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+	"github.com/palantir/pkg/cli"
+	"github.com/palantir/pkg/cli/flag"
+	"github.com/pkg/errors"
+)
+
+const (
+	logsNamesParamName = "names"
+	followFlagName     = "follow"
+	linesFlagName      = "lines"
+	sinceFlagName      = "since"
+
+	followPollInterval = 250 * time.Millisecond
+)
+
+var (
+	logsNamesParam = flag.StringSlice{
+		Name:     logsNamesParamName,
+		Usage:    "The names of the processes to print the logs of, all processes if none are given",
+		Optional: true,
+	}
+	followFlag = flag.BoolFlag{
+		Name:  followFlagName,
+		Usage: "Keep printing the logs as they are written, following them across rotations, until interrupted",
+	}
+	linesFlag = flag.IntFlag{
+		Name:  linesFlagName,
+		Value: 10,
+		Usage: "The number of lines to print from the end of the log of each process",
+	}
+	sinceFlag = flag.DurationFlag{
+		Name:  sinceFlagName,
+		Value: "0s",
+		Usage: "If not 0, print the lines of the log files, including rotated ones, written within this duration " +
+			"instead of the last lines",
+	}
+)
+
+var logsCliCommand = cli.Command{
+	Name: "logs",
+	Usage: `
+Prints the logs of the processes of the service defined by the static and custom configurations at
+service/bin/launcher-static.yml and var/conf/launcher-custom.yml, var/log/startup.log for the primary process and
+var/log/${SUB_PROCESS}-startup.log for each subProcess. If more than one process is given, or none are given and the
+service has subProcesses, each line is prefixed with the name of its process. --since prints the lines that start with
+a timestamp within the given duration, along with the lines that follow them without a timestamp, e.g. stack traces.
+Exits:
+- 0 if the logs were printed, or if following them was interrupted
+- 1 if the logs could not be read
+- 2 if a process is not configured or a flag is invalid`,
+	Flags:  []flag.Flag{followFlag, linesFlag, sinceFlag, logsNamesParam},
+	Action: logs,
+}
+
+// processLog is the log file of a process, along with the prefix of each line printed from it.
+type processLog struct {
+	name   string
+	path   string
+	prefix string
+}
+
+func logs(ctx cli.Context) error {
+	lines := ctx.Int(linesFlagName)
+	if lines < 0 {
+		return cli.WithExitCode(2, errors.Errorf("lines must not be negative, found %d", lines))
+	}
+	since := ctx.Duration(sinceFlagName)
+	if since < 0 {
+		return cli.WithExitCode(2, errors.Errorf("since must not be negative, found %s", since))
+	}
+	// Signals are caught before the logs are printed so that interrupting go-init while it follows them is not an
+	// error.
+	signals := make(chan os.Signal, 1)
+	if ctx.Bool(followFlagName) {
+		signal.Reset(syscall.SIGINT, syscall.SIGTERM)
+		signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+		defer signal.Stop(signals)
+	}
+
+	processLogs, err := getProcessLogs(ctx, ctx.Slice(logsNamesParamName))
+	if err != nil {
+		return err
+	}
+
+	// Logs are followed from before their last lines are printed, so that lines written in between are not missed.
+	var followers []*logFollower
+	if ctx.Bool(followFlagName) {
+		if followers, err = newLogFollowers(processLogs); err != nil {
+			return cli.WithExitCode(1, err)
+		}
+		defer closeLogFollowers(followers)
+	}
+
+	if since > 0 {
+		err = printLogsSince(ctx.App.Stdout, processLogs, Clock.Now().Add(-since))
+	} else {
+		err = printLastLines(ctx.App.Stdout, processLogs, lines)
+	}
+	if err != nil {
+		return cli.WithExitCode(1, err)
+	}
+	if !ctx.Bool(followFlagName) {
+		return nil
+	}
+	if err := followLogs(ctx.App.Stdout, processLogs, followers, signals); err != nil {
+		return cli.WithExitCode(1, err)
+	}
+	return nil
+}
+
+// getProcessLogs returns the logs of the processes with the given names, or of every process of the service if none
+// are given, with the primary process first.
+func getProcessLogs(ctx cli.Context, names []string) ([]processLog, error) {
+	paths, err := getServicePaths(ctx)
+	if err != nil {
+		return nil, cli.WithExitCode(1, err)
+	}
+	staticConfig, _, err := launchlib.GetConfigsFromFiles(paths.staticConfigFile, paths.customConfigFile,
+		ioutil.Discard)
+	if err != nil {
+		return nil, cli.WithExitCode(1, errors.Wrap(err, "failed to read static and custom configuration files"))
+	}
+
+	configured := map[string]string{staticConfig.ServiceName: paths.primaryOutputFile()}
+	configuredNames := []string{staticConfig.ServiceName}
+	subProcessNames := make([]string, 0, len(staticConfig.SubProcesses))
+	for name := range staticConfig.SubProcesses {
+		configured[name] = paths.subProcessOutputFile(name)
+		subProcessNames = append(subProcessNames, name)
+	}
+	sort.Strings(subProcessNames)
+	configuredNames = append(configuredNames, subProcessNames...)
+
+	if len(names) == 0 {
+		names = configuredNames
+	}
+	processLogs := make([]processLog, 0, len(names))
+	for _, name := range names {
+		path, ok := configured[name]
+		if !ok {
+			return nil, cli.WithExitCode(2, errors.Errorf("process '%s' is not configured, expected one of %v", name,
+				configuredNames))
+		}
+		var prefix string
+		if len(names) > 1 {
+			prefix = "[" + name + "] "
+		}
+		processLogs = append(processLogs, processLog{name: name, path: path, prefix: prefix})
+	}
+	return processLogs, nil
+}
+
+// printLastLines prints the given number of lines from the end of each of the given logs that exists.
+func printLastLines(w io.Writer, processLogs []processLog, lines int) error {
+	if lines == 0 {
+		return nil
+	}
+	for _, processLog := range processLogs {
+		tail, err := tailLines(processLog.path, lines)
+		if os.IsNotExist(errors.Cause(err)) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if tail != "" {
+			if err := printLines(w, processLog.prefix, strings.NewReader(tail)); err != nil {
+				return errors.Wrapf(err, "failed to read the last lines of '%s'", processLog.path)
+			}
+		}
+	}
+	return nil
+}
+
+// logFile is a log file, or a rotated log file, of a process.
+type logFile struct {
+	processLog
+	path    string
+	modTime time.Time
+	// rotation is the index of a rotated log file, which is higher the older the file is, or -1 for the log file.
+	rotation int
+}
+
+// printLogsSince prints the lines written after the given time of each of the log files, including rotated and
+// compressed ones, of the given logs that were last written to after the given time. The files of each log are printed
+// from the oldest rotated file to the log file itself, and the files of different logs in the order they were last
+// written to.
+func printLogsSince(w io.Writer, processLogs []processLog, since time.Time) error {
+	var logs [][]logFile
+	for _, processLog := range processLogs {
+		rotated, err := filepath.Glob(processLog.path + ".*")
+		if err != nil {
+			return errors.Wrapf(err, "failed to list rotated files of '%s'", processLog.path)
+		}
+		var files []logFile
+		for _, path := range append(rotated, processLog.path) {
+			rotation := -1
+			if path != processLog.path {
+				var ok bool
+				if rotation, ok = rotationIndex(processLog.path, path); !ok {
+					continue
+				}
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				continue
+			}
+			if info.ModTime().After(since) {
+				files = append(files, logFile{processLog: processLog, path: path, modTime: info.ModTime(),
+					rotation: rotation})
+			}
+		}
+		// Rotated files often share a modification time, so they are ordered by their index.
+		sort.Slice(files, func(i, j int) bool {
+			return files[i].rotation > files[j].rotation
+		})
+		if len(files) > 0 {
+			logs = append(logs, files)
+		}
+	}
+
+	for len(logs) > 0 {
+		next := 0
+		for i := range logs {
+			if logs[i][0].modTime.Before(logs[next][0].modTime) {
+				next = i
+			}
+		}
+		if err := printLogFileSince(w, logs[next][0], since); err != nil {
+			return err
+		}
+		if logs[next] = logs[next][1:]; len(logs[next]) == 0 {
+			logs = append(logs[:next], logs[next+1:]...)
+		}
+	}
+	return nil
+}
+
+// rotationIndex returns the index of the given path as a rotated file of the log file at logPath, e.g. 2 for
+// startup.log.2.gz, and whether it is one.
+func rotationIndex(logPath, path string) (int, bool) {
+	index, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(path, logPath+"."), gzipSuffix))
+	return index, err == nil && index >= 0
+}
+
+// printLogFileSince prints the lines of the given log file that start with a timestamp after the given time, along with
+// the lines without a timestamp that follow them. Lines before the first timestamped line of the file are printed, as
+// the file was last written to after the given time.
+func printLogFileSince(w io.Writer, file logFile, since time.Time) error {
+	f, err := os.Open(file.path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open '%s'", file.path)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	var r io.Reader = f
+	if strings.HasSuffix(file.path, gzipSuffix) {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return errors.Wrapf(err, "failed to decompress '%s'", file.path)
+		}
+		r = gz
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), tailMaxBytes)
+	printing := true
+	for scanner.Scan() {
+		if timestamp, ok := lineTimestamp(scanner.Text()); ok {
+			printing = timestamp.After(since)
+		}
+		if printing {
+			_, _ = fmt.Fprintf(w, "%s%s\n", file.prefix, scanner.Text())
+		}
+	}
+	return errors.Wrapf(scanner.Err(), "failed to read '%s'", file.path)
+}
+
+// logTimestampPattern matches the timestamp a log line starts with, optionally in brackets, as written by most logging
+// frameworks, e.g. 2026-01-02T15:04:05.123Z or 2026-01-02 15:04:05,123.
+var logTimestampPattern = regexp.MustCompile(
+	`^\[?(\d{4}-\d{2}-\d{2})[T ](\d{2}:\d{2}:\d{2})(?:[.,](\d{1,9}))?(Z|[+-]\d{2}:?\d{2})?`)
+
+// lineTimestamp returns the timestamp the given log line starts with, in local time if it has no zone, and false if it
+// does not start with a timestamp.
+func lineTimestamp(line string) (time.Time, bool) {
+	match := logTimestampPattern.FindStringSubmatch(line)
+	if match == nil {
+		return time.Time{}, false
+	}
+	layout, value := "2006-01-02T15:04:05", match[1]+"T"+match[2]
+	if fraction := match[3]; fraction != "" {
+		layout += "." + strings.Repeat("0", len(fraction))
+		value += "." + fraction
+	}
+	if zone := match[4]; zone == "Z" {
+		layout, value = layout+"Z07:00", value+zone
+	} else if zone != "" {
+		layout, value = layout+"-0700", value+strings.Replace(zone, ":", "", 1)
+	}
+	timestamp, err := time.ParseInLocation(layout, value, time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return timestamp, true
+}
+
+// printLines prints each line read from the given reader with the given prefix.
+func printLines(w io.Writer, prefix string, r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), tailMaxBytes)
+	for scanner.Scan() {
+		_, _ = fmt.Fprintf(w, "%s%s\n", prefix, scanner.Text())
+	}
+	return scanner.Err()
+}
+
+// newLogFollowers returns followers of each of the given logs that read the lines appended to them from now on.
+func newLogFollowers(processLogs []processLog) ([]*logFollower, error) {
+	followers := make([]*logFollower, 0, len(processLogs))
+	for _, processLog := range processLogs {
+		follower, err := newLogFollower(processLog.path)
+		if err != nil {
+			closeLogFollowers(followers)
+			return nil, err
+		}
+		followers = append(followers, follower)
+	}
+	return followers, nil
+}
+
+func closeLogFollowers(followers []*logFollower) {
+	for _, follower := range followers {
+		follower.close()
+	}
+}
+
+// followLogs prints the lines the given followers read from the given logs, interleaved in the order they are read,
+// until a signal is received.
+func followLogs(w io.Writer, processLogs []processLog, followers []*logFollower, signals <-chan os.Signal) error {
+	ticker := Clock.NewTicker(followPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-signals:
+			return nil
+		case <-ticker.Chan():
+			for i, follower := range followers {
+				lines, err := follower.poll()
+				if err != nil {
+					return err
+				}
+				for _, line := range lines {
+					_, _ = fmt.Fprintf(w, "%s%s\n", processLogs[i].prefix, line)
+				}
+			}
+		}
+	}
+}
+
+// logFollower reads the lines appended to a log file, following it when it is rotated, i.e. moved and replaced by a new
+// file, or truncated.
+type logFollower struct {
+	path string
+	// file is nil if the log file did not exist when it was last polled.
+	file   *os.File
+	offset int64
+	// partial is the start of a line that has not yet been fully written.
+	partial string
+}
+
+// newLogFollower returns a follower of the log file at the given path that reads the lines appended to it from now on.
+func newLogFollower(path string) (*logFollower, error) {
+	f := &logFollower{path: path}
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open '%s'", path)
+	}
+	offset, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		_ = file.Close()
+		return nil, errors.Wrapf(err, "failed to seek in '%s'", path)
+	}
+	f.file = file
+	f.offset = offset
+	return f, nil
+}
+
+// poll returns the lines written to the log file since it was last polled. If the log file has been rotated, the lines
+// written to it before it was rotated are returned along with those written to the new log file.
+func (f *logFollower) poll() ([]string, error) {
+	var lines []string
+	if f.file != nil {
+		read, err := f.read()
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, read...)
+	}
+
+	info, err := os.Stat(f.path)
+	if os.IsNotExist(err) {
+		return lines, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to stat '%s'", f.path)
+	}
+	if f.file != nil {
+		current, err := f.file.Stat()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to stat '%s'", f.path)
+		}
+		if os.SameFile(info, current) {
+			if info.Size() >= f.offset {
+				return lines, nil
+			}
+			// The log file was truncated, so is read again from its start.
+			f.offset = 0
+			f.partial = ""
+			read, err := f.read()
+			return append(lines, read...), err
+		}
+		_ = f.file.Close()
+	}
+
+	// The log file was rotated, or has been created, so the new file is read from its start.
+	file, err := os.Open(f.path)
+	if err != nil {
+		f.file = nil
+		return lines, nil
+	}
+	if f.partial != "" {
+		lines = append(lines, f.partial)
+	}
+	f.file = file
+	f.offset = 0
+	f.partial = ""
+	read, err := f.read()
+	return append(lines, read...), err
+}
+
+// read returns the complete lines written to the open log file after the current offset.
+func (f *logFollower) read() ([]string, error) {
+	if _, err := f.file.Seek(f.offset, io.SeekStart); err != nil {
+		return nil, errors.Wrapf(err, "failed to seek in '%s'", f.path)
+	}
+	content, err := ioutil.ReadAll(f.file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read '%s'", f.path)
+	}
+	f.offset += int64(len(content))
+
+	text := f.partial + string(content)
+	lastNewline := strings.LastIndex(text, "\n")
+	f.partial = text[lastNewline+1:]
+	if lastNewline < 0 {
+		return nil, nil
+	}
+	return strings.Split(text[:lastNewline], "\n"), nil
+}
+
+func (f *logFollower) close() {
+	if f.file != nil {
+		_ = f.file.Close()
+	}
+}
@@ -0,0 +1,221 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogFollower_ReadsAppendedLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "startup.log")
+	require.NoError(t, ioutil.WriteFile(path, []byte("before\n"), 0644))
+	follower, err := newLogFollower(path)
+	require.NoError(t, err)
+	defer follower.close()
+
+	appendToFile(t, path, "first\nsec")
+	lines, err := follower.poll()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"first"}, lines)
+
+	appendToFile(t, path, "ond\n")
+	lines, err = follower.poll()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"second"}, lines)
+}
+
+func TestLogFollower_FollowsRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "startup.log")
+	require.NoError(t, ioutil.WriteFile(path, nil, 0644))
+	follower, err := newLogFollower(path)
+	require.NoError(t, err)
+	defer follower.close()
+
+	appendToFile(t, path, "first\n")
+	rotateFile(path, 2)
+	require.NoError(t, ioutil.WriteFile(path, []byte("second\n"), 0644))
+	lines, err := follower.poll()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"first", "second"}, lines)
+}
+
+func TestLogFollower_FollowsTruncation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "startup.log")
+	require.NoError(t, ioutil.WriteFile(path, []byte("before\n"), 0644))
+	follower, err := newLogFollower(path)
+	require.NoError(t, err)
+	defer follower.close()
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("new\n"), 0644))
+	lines, err := follower.poll()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"new"}, lines)
+}
+
+func TestLogFollower_WaitsForFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "startup.log")
+	follower, err := newLogFollower(path)
+	require.NoError(t, err)
+	defer follower.close()
+
+	lines, err := follower.poll()
+	require.NoError(t, err)
+	assert.Empty(t, lines)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("first\n"), 0644))
+	lines, err = follower.poll()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"first"}, lines)
+}
+
+func TestPrintLogsSince(t *testing.T) {
+	dir := t.TempDir()
+	primary := filepath.Join(dir, "startup.log")
+	sidecar := filepath.Join(dir, "sidecar-startup.log")
+	now := time.Now()
+
+	writeLogFile(t, primary+".1", "primary old\n", now.Add(-time.Hour))
+	writeGzipLogFile(t, primary+".0.gz", "primary rotated\n", now.Add(-3*time.Minute))
+	writeLogFile(t, sidecar, "sidecar current\n", now.Add(-2*time.Minute))
+	writeLogFile(t, primary, "primary current\n", now.Add(-time.Minute))
+
+	var out bytes.Buffer
+	require.NoError(t, printLogsSince(&out, []processLog{
+		{name: "primary", path: primary, prefix: "[primary] "},
+		{name: "sidecar", path: sidecar, prefix: "[sidecar] "},
+	}, now.Add(-10*time.Minute)))
+	assert.Equal(t, "[primary] primary rotated\n[sidecar] sidecar current\n[primary] primary current\n", out.String())
+}
+
+func TestPrintLogsSince_FiltersTimestampedLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "startup.log")
+	since := time.Date(2026, time.January, 2, 15, 0, 0, 0, time.UTC)
+	writeLogFile(t, path, "starting\n"+
+		"2026-01-02T14:59:59.999Z old\n"+
+		"java.lang.Exception: old\n"+
+		"2026-01-02T15:00:01Z new\n"+
+		"\tat Main.main(Main.java:1)\n"+
+		"[2026-01-02 14:00:00,000] older\n"+
+		"2026-01-02 16:30:00+01:00 newest\n", since.Add(time.Hour))
+
+	var out bytes.Buffer
+	require.NoError(t, printLogsSince(&out, []processLog{{name: "primary", path: path}}, since))
+	assert.Equal(t, "starting\n2026-01-02T15:00:01Z new\n\tat Main.main(Main.java:1)\n"+
+		"2026-01-02 16:30:00+01:00 newest\n", out.String())
+}
+
+func TestPrintLogsSince_OrdersRotatedFilesByIndex(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "startup.log")
+	modTime := time.Now().Add(-time.Minute)
+	writeLogFile(t, path, "current\n", modTime)
+	writeGzipLogFile(t, path+".1.gz", "rotated 1\n", modTime)
+	writeLogFile(t, path+".2", "rotated 2\n", modTime)
+	writeGzipLogFile(t, path+".10.gz", "rotated 10\n", modTime)
+
+	var out bytes.Buffer
+	require.NoError(t, printLogsSince(&out, []processLog{{name: "primary", path: path}}, modTime.Add(-time.Minute)))
+	assert.Equal(t, "rotated 10\nrotated 2\nrotated 1\ncurrent\n", out.String())
+}
+
+func TestPrintLogsSince_FailsForUnreadableLines(t *testing.T) {
+	now := time.Now()
+	for _, tc := range []struct {
+		name  string
+		write func(path string)
+		msg   string
+	}{
+		{
+			name: "line longer than the buffer",
+			write: func(path string) {
+				writeLogFile(t, path, strings.Repeat("a", tailMaxBytes+1)+"\n", now)
+			},
+			msg: "token too long",
+		},
+		{
+			name: "truncated gzip",
+			write: func(path string) {
+				writeGzipLogFile(t, path+".1.gz", "rotated\n", now)
+				compressed, err := ioutil.ReadFile(path + ".1.gz")
+				require.NoError(t, err)
+				require.NoError(t, ioutil.WriteFile(path+".1.gz", compressed[:len(compressed)-4], 0644))
+			},
+			msg: "unexpected EOF",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "startup.log")
+			tc.write(path)
+
+			err := printLogsSince(ioutil.Discard, []processLog{{name: "primary", path: path}}, now.Add(-time.Minute))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.msg)
+		})
+	}
+}
+
+func TestLineTimestamp(t *testing.T) {
+	for _, tc := range []struct {
+		line string
+		want time.Time
+		ok   bool
+	}{
+		{line: "2026-01-02T15:04:05Z message", want: time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC), ok: true},
+		{line: "2026-01-02T15:04:05.123Z", want: time.Date(2026, 1, 2, 15, 4, 5, 123000000, time.UTC), ok: true},
+		{line: "[2026-01-02 15:04:05,5+0100]", want: time.Date(2026, 1, 2, 14, 4, 5, 500000000, time.UTC), ok: true},
+		{line: "2026-01-02 15:04:05 message", want: time.Date(2026, 1, 2, 15, 4, 5, 0, time.Local), ok: true},
+		{line: "INFO 2026-01-02T15:04:05Z message"},
+		{line: "2026-13-02T15:04:05Z message"},
+		{line: ""},
+	} {
+		t.Run(tc.line, func(t *testing.T) {
+			got, ok := lineTimestamp(tc.line)
+			assert.Equal(t, tc.ok, ok)
+			assert.True(t, tc.want.Equal(got), "expected %s, found %s", tc.want, got)
+		})
+	}
+}
+
+func appendToFile(t *testing.T, path, content string) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = file.WriteString(content)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+}
+
+func writeLogFile(t *testing.T, path, content string, modTime time.Time) {
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+func writeGzipLogFile(t *testing.T, path, content string, modTime time.Time) {
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	_, err := gz.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	require.NoError(t, ioutil.WriteFile(path, compressed.Bytes(), 0644))
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
@@ -0,0 +1,292 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+	"github.com/palantir/pkg/cli"
+	"github.com/palantir/pkg/cli/flag"
+	"github.com/pkg/errors"
+)
+
+const metricsOutputFlagName = "output"
+
+var metricsOutputFlag = flag.StringFlag{
+	Name: metricsOutputFlagName,
+	Usage: "The file to write the metrics to, e.g. a .prom file in the directory of the node_exporter textfile " +
+		"collector, rather than printing them",
+}
+
+var metricsCliCommand = cli.Command{
+	Name: "metrics",
+	Usage: `
+Writes metrics of the processes of the service defined by the static and custom configurations at
+service/bin/launcher-static.yml and var/conf/launcher-custom.yml in the Prometheus text format: whether each process is
+up, when it started, how many times go-init has started it, the exit code it last exited with and its resident set
+size, CPU time and number of open file descriptors, along with the memory limit of the container's cgroup. With
+--output, the metrics are written to a temporary file that is then renamed to the output file, so that a partially
+written file is never scraped.
+Exits:
+- 0 if the metrics were written
+- 1 if the metrics could not be determined or written
+If exit code is nonzero, writes an error message to stderr and var/log/startup.log.`,
+	Flags:  []flag.Flag{metricsOutputFlag},
+	Action: executeWithLoggers(metrics, NewAlwaysAppending()),
+}
+
+// metricFamily is a metric as described by the HELP and TYPE lines of the Prometheus text format.
+type metricFamily struct {
+	name       string
+	help       string
+	metricType string
+}
+
+var (
+	processUpMetric = metricFamily{
+		name:       "go_init_process_up",
+		help:       "Whether the process is running.",
+		metricType: "gauge",
+	}
+	processStartTimeMetric = metricFamily{
+		name:       "go_init_process_start_time_seconds",
+		help:       "Start time of the running process since unix epoch in seconds.",
+		metricType: "gauge",
+	}
+	processStartsMetric = metricFamily{
+		name:       "go_init_process_starts",
+		help:       "Number of times go-init has started the process, as kept in its start history.",
+		metricType: "gauge",
+	}
+	processLastExitCodeMetric = metricFamily{
+		name:       "go_init_process_last_exit_code",
+		help:       "Last exit code of the process, 128 plus the signal number if killed by a signal.",
+		metricType: "gauge",
+	}
+	processResidentMemoryMetric = metricFamily{
+		name:       "go_init_process_resident_memory_bytes",
+		help:       "Resident set size of the running process in bytes.",
+		metricType: "gauge",
+	}
+	processCPUMetric = metricFamily{
+		name:       "go_init_process_cpu_seconds_total",
+		help:       "User and system CPU time used by the running process in seconds.",
+		metricType: "counter",
+	}
+	processOpenFdsMetric = metricFamily{
+		name:       "go_init_process_open_fds",
+		help:       "Number of file descriptors the running process has open.",
+		metricType: "gauge",
+	}
+	memoryLimitMetric = metricFamily{
+		name:       "go_init_memory_limit_bytes",
+		help:       "Memory limit of the container's cgroup in bytes.",
+		metricType: "gauge",
+	}
+)
+
+// processMetrics are the metrics of a process. Metrics that are unknown, e.g. the resource usage of a process that is
+// not running, are nil and are not written.
+type processMetrics struct {
+	name             string
+	up               bool
+	startTimeSeconds *float64
+	starts           *int
+	lastExitCode     *int
+	rssBytes         *uint64
+	cpuSeconds       *float64
+	openFds          *int
+}
+
+// serviceMetrics are the metrics of the processes of a service, ordered by name.
+type serviceMetrics struct {
+	processes []processMetrics
+	// memoryLimitBytes is nil if the container memory limit cannot be read, e.g. because there is no memory cgroup.
+	memoryLimitBytes *uint64
+}
+
+func metrics(ctx cli.Context, loggers launchlib.ServiceLoggers) error {
+	// Executed with logging for errors, however we discard the verbose logging of getServiceStatus
+	serviceStatus, err := getServiceStatus(ctx, &DevNullLoggers{})
+	if err != nil {
+		return logErrorAndReturnWithExitCode(ctx, errors.Wrap(err, "failed to determine service status"), 1)
+	}
+	collected := collectMetrics(ctx, serviceStatus, launchlib.DefaultMemoryLimit)
+
+	output := ctx.String(metricsOutputFlagName)
+	if output == "" {
+		if err := writeMetrics(os.Stdout, collected); err != nil {
+			return logErrorAndReturnWithExitCode(ctx, errors.Wrap(err, "failed to print metrics"), 1)
+		}
+		return nil
+	}
+	if err := writeMetricsFile(output, collected); err != nil {
+		return logErrorAndReturnWithExitCode(ctx, err, 1)
+	}
+	return nil
+}
+
+// collectMetrics returns the metrics of the processes of the service with the given status. Metrics that cannot be
+// read, e.g. from /proc because the process has just exited, are omitted.
+func collectMetrics(ctx cli.Context, serviceStatus *serviceStatus, memoryLimit launchlib.MemoryLimit) serviceMetrics {
+	var collected serviceMetrics
+	if limit, err := memoryLimit.MemoryLimitInBytes(); err == nil {
+		collected.memoryLimitBytes = &limit
+	}
+
+	uptime, uptimeErr := systemUptime()
+	now := Clock.Now()
+	names := commandNames(serviceStatus.configuredCmds)
+	sort.Strings(names)
+	for _, name := range names {
+		cmd := serviceStatus.configuredCmds[name]
+		process := processMetrics{name: name}
+		if history, err := readCommandHistory(cmd); err != nil {
+			_, _ = fmt.Fprintf(ctx.App.Stdout, "Not reporting the starts of command '%s': %v\n", name, err)
+		} else if starts := history.starts(); starts > 0 {
+			process.starts = &starts
+		}
+		if exit, ok := serviceStatus.exitRecords[name]; ok {
+			exitCode := exit.shimExitCode()
+			process.lastExitCode = &exitCode
+		}
+		if proc, ok := serviceStatus.runningProcs[name]; ok {
+			process.up = true
+			if stat, err := readProcessStat(proc.Pid); err == nil {
+				if uptimeErr == nil {
+					startedAt := now.Add(clockTicksToDuration(stat.StartTime) - uptime)
+					startTimeSeconds := float64(startedAt.UnixNano()) / 1e9
+					process.startTimeSeconds = &startTimeSeconds
+				}
+				rssBytes := stat.RSSPages * uint64(os.Getpagesize())
+				process.rssBytes = &rssBytes
+				cpuSeconds := clockTicksToDuration(stat.CPUTime).Seconds()
+				process.cpuSeconds = &cpuSeconds
+			}
+			if openFds, err := processOpenFds(proc.Pid); err == nil {
+				process.openFds = &openFds
+			}
+		}
+		collected.processes = append(collected.processes, process)
+	}
+	return collected
+}
+
+// writeMetrics writes the given metrics in the Prometheus text format.
+func writeMetrics(w io.Writer, collected serviceMetrics) error {
+	var buf bytes.Buffer
+	writeFamily := func(family metricFamily, value func(processMetrics) (string, bool)) {
+		_, _ = fmt.Fprintf(&buf, "# HELP %s %s\n# TYPE %s %s\n", family.name, family.help, family.name,
+			family.metricType)
+		for _, process := range collected.processes {
+			if v, ok := value(process); ok {
+				_, _ = fmt.Fprintf(&buf, "%s{process=\"%s\"} %s\n", family.name, escapeLabelValue(process.name), v)
+			}
+		}
+	}
+	writeFamily(processUpMetric, func(p processMetrics) (string, bool) {
+		if p.up {
+			return "1", true
+		}
+		return "0", true
+	})
+	writeFamily(processStartTimeMetric, func(p processMetrics) (string, bool) {
+		return formatFloatMetric(p.startTimeSeconds)
+	})
+	writeFamily(processStartsMetric, func(p processMetrics) (string, bool) {
+		return formatIntMetric(p.starts)
+	})
+	writeFamily(processLastExitCodeMetric, func(p processMetrics) (string, bool) {
+		return formatIntMetric(p.lastExitCode)
+	})
+	writeFamily(processResidentMemoryMetric, func(p processMetrics) (string, bool) {
+		if p.rssBytes == nil {
+			return "", false
+		}
+		return strconv.FormatUint(*p.rssBytes, 10), true
+	})
+	writeFamily(processCPUMetric, func(p processMetrics) (string, bool) {
+		return formatFloatMetric(p.cpuSeconds)
+	})
+	writeFamily(processOpenFdsMetric, func(p processMetrics) (string, bool) {
+		return formatIntMetric(p.openFds)
+	})
+	if collected.memoryLimitBytes != nil {
+		_, _ = fmt.Fprintf(&buf, "# HELP %s %s\n# TYPE %s %s\n%s %d\n", memoryLimitMetric.name,
+			memoryLimitMetric.help, memoryLimitMetric.name, memoryLimitMetric.metricType, memoryLimitMetric.name,
+			*collected.memoryLimitBytes)
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// writeMetricsFile writes the given metrics to the given file, replacing it atomically. The temporary file does not
+// end in .prom, so it is not read by the node_exporter textfile collector while it is being written.
+func writeMetricsFile(output string, collected serviceMetrics) (rErr error) {
+	tmpFile, err := ioutil.TempFile(filepath.Dir(output), filepath.Base(output)+".*.tmp")
+	if err != nil {
+		return errors.Wrapf(err, "failed to create temporary metrics file for '%s'", output)
+	}
+	defer func() {
+		if rErr != nil {
+			_ = os.Remove(tmpFile.Name())
+		}
+	}()
+	if err := writeMetrics(tmpFile, collected); err != nil {
+		_ = tmpFile.Close()
+		return errors.Wrapf(err, "failed to write metrics to '%s'", tmpFile.Name())
+	}
+	// Temporary files are only readable by their owner, whereas the collector may run as another user.
+	if err := tmpFile.Chmod(0644); err != nil {
+		_ = tmpFile.Close()
+		return errors.Wrapf(err, "failed to write metrics to '%s'", tmpFile.Name())
+	}
+	if err := tmpFile.Close(); err != nil {
+		return errors.Wrapf(err, "failed to write metrics to '%s'", tmpFile.Name())
+	}
+	if err := os.Rename(tmpFile.Name(), output); err != nil {
+		return errors.Wrapf(err, "failed to write metrics to '%s'", output)
+	}
+	return nil
+}
+
+func formatFloatMetric(value *float64) (string, bool) {
+	if value == nil {
+		return "", false
+	}
+	return strconv.FormatFloat(*value, 'g', -1, 64), true
+}
+
+func formatIntMetric(value *int) (string, bool) {
+	if value == nil {
+		return "", false
+	}
+	return strconv.Itoa(*value), true
+}
+
+// escapeLabelValue escapes the backslashes, double quotes and line feeds in the given label value, as per the
+// Prometheus text format.
+func escapeLabelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
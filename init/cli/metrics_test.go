@@ -0,0 +1,121 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/palantir/pkg/cli/flag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// To prevent accidental changes to parameter default values
+func TestInitMetrics_DefaultParameters(t *testing.T) {
+	assert.Equal(t, []flag.Flag{flag.StringFlag{
+		Name: "output",
+		Usage: "The file to write the metrics to, e.g. a .prom file in the directory of the node_exporter textfile " +
+			"collector, rather than printing them",
+	}}, metricsCliCommand.Flags)
+}
+
+func testServiceMetrics() serviceMetrics {
+	startTimeSeconds, starts, exitCode, rssBytes, cpuSeconds, openFds := 1767225600.5, 2, 137, uint64(1048576),
+		12.34, 42
+	memoryLimitBytes := uint64(2147483648)
+	return serviceMetrics{
+		processes: []processMetrics{
+			{
+				name:             "primary",
+				up:               true,
+				startTimeSeconds: &startTimeSeconds,
+				starts:           &starts,
+				rssBytes:         &rssBytes,
+				cpuSeconds:       &cpuSeconds,
+				openFds:          &openFds,
+			},
+			{
+				name:         "sidecar",
+				lastExitCode: &exitCode,
+			},
+		},
+		memoryLimitBytes: &memoryLimitBytes,
+	}
+}
+
+func TestWriteMetrics(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, writeMetrics(&buf, testServiceMetrics()))
+	assert.Equal(t, `# HELP go_init_process_up Whether the process is running.
+# TYPE go_init_process_up gauge
+go_init_process_up{process="primary"} 1
+go_init_process_up{process="sidecar"} 0
+# HELP go_init_process_start_time_seconds Start time of the running process since unix epoch in seconds.
+# TYPE go_init_process_start_time_seconds gauge
+go_init_process_start_time_seconds{process="primary"} 1.7672256005e+09
+# HELP go_init_process_starts Number of times go-init has started the process, as kept in its start history.
+# TYPE go_init_process_starts gauge
+go_init_process_starts{process="primary"} 2
+# HELP go_init_process_last_exit_code Last exit code of the process, 128 plus the signal number if killed by a signal.
+# TYPE go_init_process_last_exit_code gauge
+go_init_process_last_exit_code{process="sidecar"} 137
+# HELP go_init_process_resident_memory_bytes Resident set size of the running process in bytes.
+# TYPE go_init_process_resident_memory_bytes gauge
+go_init_process_resident_memory_bytes{process="primary"} 1048576
+# HELP go_init_process_cpu_seconds_total User and system CPU time used by the running process in seconds.
+# TYPE go_init_process_cpu_seconds_total counter
+go_init_process_cpu_seconds_total{process="primary"} 12.34
+# HELP go_init_process_open_fds Number of file descriptors the running process has open.
+# TYPE go_init_process_open_fds gauge
+go_init_process_open_fds{process="primary"} 42
+# HELP go_init_memory_limit_bytes Memory limit of the container's cgroup in bytes.
+# TYPE go_init_memory_limit_bytes gauge
+go_init_memory_limit_bytes 2147483648
+`, buf.String())
+}
+
+func TestWriteMetricsFile(t *testing.T) {
+	dir := t.TempDir()
+	output := filepath.Join(dir, "service.prom")
+	require.NoError(t, ioutil.WriteFile(output, []byte("stale\n"), 0644))
+
+	require.NoError(t, writeMetricsFile(output, testServiceMetrics()))
+	var expected bytes.Buffer
+	require.NoError(t, writeMetrics(&expected, testServiceMetrics()))
+	written, err := ioutil.ReadFile(output)
+	require.NoError(t, err)
+	assert.Equal(t, expected.String(), string(written))
+
+	info, err := os.Stat(output)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0644), info.Mode().Perm())
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 1, "the temporary file should have been renamed")
+}
+
+func TestWriteMetricsFile_MissingDirectory(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "missing", "service.prom")
+	assert.Error(t, writeMetricsFile(output, testServiceMetrics()))
+	assert.NoFileExists(t, output)
+}
+
+func TestEscapeLabelValue(t *testing.T) {
+	assert.Equal(t, `a\\b\"c\nd`, escapeLabelValue("a\\b\"c\nd"))
+}
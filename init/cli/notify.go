@@ -0,0 +1,41 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+	"github.com/palantir/pkg/cli"
+)
+
+// notifySystemd notifies systemd of the given states if go-init was started by it as a Type=notify service. Failing to
+// notify systemd does not affect the service, so is only logged.
+func notifySystemd(ctx cli.Context, states ...string) {
+	if _, err := launchlib.SdNotify(states...); err != nil {
+		_, _ = fmt.Fprintf(ctx.App.Stdout, "failed to notify systemd of '%s': %v\n", strings.Join(states, ", "), err)
+	}
+}
+
+// primaryCommandName returns the name of the command of the primary process among the given commands.
+func primaryCommandName(cmds map[string]CommandContext) (string, bool) {
+	for name, cmd := range cmds {
+		if cmd.Primary {
+			return name, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,139 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+	"github.com/palantir/pkg/cli"
+	"github.com/palantir/pkg/cli/flag"
+	"github.com/pkg/errors"
+)
+
+const (
+	serviceRootFlagName  = "service-root"
+	staticConfigFlagName = "static-config"
+	customConfigFlagName = "custom-config"
+	pidDirFlagName       = "pid-dir"
+	logDirFlagName       = "log-dir"
+
+	lockFileName = "go-init.lock"
+)
+
+var (
+	serviceRootFlag = flag.StringFlag{
+		Name:   serviceRootFlagName,
+		Value:  ".",
+		EnvVar: "GO_INIT_SERVICE_ROOT",
+		Usage:  "The root of the service, which relative paths are resolved against and processes are started in",
+	}
+	staticConfigFlag = flag.StringFlag{
+		Name:   staticConfigFlagName,
+		Value:  "service/bin/launcher-static.yml",
+		EnvVar: "GO_INIT_STATIC_CONFIG",
+		Usage:  "The static launcher configuration of the service",
+	}
+	customConfigFlag = flag.StringFlag{
+		Name:   customConfigFlagName,
+		Value:  "var/conf/launcher-custom.yml",
+		EnvVar: "GO_INIT_CUSTOM_CONFIG",
+		Usage:  "The custom launcher configuration of the service",
+	}
+	pidDirFlag = flag.StringFlag{
+		Name:   pidDirFlagName,
+		Value:  "var/run",
+		EnvVar: "GO_INIT_PID_DIR",
+		Usage:  "The directory pidfiles and the lock file are written to",
+	}
+	logDirFlag = flag.StringFlag{
+		Name:   logDirFlagName,
+		Value:  launchlib.DefaultLogDir,
+		EnvVar: launchlib.LogDirEnvVar,
+		Usage:  "The directory the startup logs of the service are written to",
+	}
+)
+
+// servicePaths are the locations of the files of a service, as given by the global flags. Paths given relative to a
+// relative service root are kept relative to the working directory so that messages about the default layout refer to
+// e.g. var/log/startup.log rather than its absolute path.
+type servicePaths struct {
+	// root is the service root as given, and workingDir its absolute path.
+	root             string
+	workingDir       string
+	staticConfigFile string
+	customConfigFile string
+	pidDir           string
+	logDir           string
+}
+
+// getServicePaths resolves the locations of the files of the service from the global flags of the given context.
+// Relative paths are resolved against the service root, which is itself relative to the working directory if relative.
+func getServicePaths(ctx cli.Context) (servicePaths, error) {
+	root := ctx.String(serviceRootFlagName)
+	workingDir, err := filepath.Abs(root)
+	if err != nil {
+		return servicePaths{}, errors.Wrap(err, "failed to resolve service root")
+	}
+	paths := servicePaths{
+		root:       root,
+		workingDir: workingDir,
+	}
+	paths.staticConfigFile = paths.resolve(ctx.String(staticConfigFlagName))
+	paths.customConfigFile = paths.resolve(ctx.String(customConfigFlagName))
+	paths.pidDir = paths.resolve(ctx.String(pidDirFlagName))
+	paths.logDir = paths.resolve(ctx.String(logDirFlagName))
+	return paths, nil
+}
+
+// resolve returns the given path resolved against the service root if it is relative.
+func (p servicePaths) resolve(path string) string {
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(p.root, path)
+}
+
+func (p servicePaths) pidfile(name string) string {
+	return filepath.Join(p.pidDir, name+".pid")
+}
+
+// exitFile is the file the exit record of the process with the given name is written to once it exits.
+func (p servicePaths) exitFile(name string) string {
+	return filepath.Join(p.pidDir, name+".exit")
+}
+
+// historyFile is the file the recent starts and exits of the process with the given name are kept in.
+func (p servicePaths) historyFile(name string) string {
+	return filepath.Join(p.pidDir, name+".history")
+}
+
+// eventLogFile is the file the lifecycle events of the service are appended to.
+func (p servicePaths) eventLogFile() string {
+	return filepath.Join(p.logDir, launchlib.EventLogFileName)
+}
+
+func (p servicePaths) lockFile() string {
+	return filepath.Join(p.pidDir, lockFileName)
+}
+
+func (p servicePaths) primaryOutputFile() string {
+	return filepath.Join(p.logDir, outputLogFile)
+}
+
+func (p servicePaths) subProcessOutputFile(name string) string {
+	return filepath.Join(p.logDir, fmt.Sprintf("%s-%s", name, outputLogFile))
+}
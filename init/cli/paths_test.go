@@ -0,0 +1,82 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/palantir/pkg/cli/flag"
+	"github.com/stretchr/testify/assert"
+)
+
+// To prevent accidental changes to parameter default values
+func TestInit_DefaultPathParameters(t *testing.T) {
+	for _, f := range []flag.StringFlag{
+		{
+			Name:   "service-root",
+			Value:  ".",
+			EnvVar: "GO_INIT_SERVICE_ROOT",
+			Usage:  "The root of the service, which relative paths are resolved against and processes are started in",
+		},
+		{
+			Name:   "static-config",
+			Value:  "service/bin/launcher-static.yml",
+			EnvVar: "GO_INIT_STATIC_CONFIG",
+			Usage:  "The static launcher configuration of the service",
+		},
+		{
+			Name:   "custom-config",
+			Value:  "var/conf/launcher-custom.yml",
+			EnvVar: "GO_INIT_CUSTOM_CONFIG",
+			Usage:  "The custom launcher configuration of the service",
+		},
+		{
+			Name:   "pid-dir",
+			Value:  "var/run",
+			EnvVar: "GO_INIT_PID_DIR",
+			Usage:  "The directory pidfiles and the lock file are written to",
+		},
+		{
+			Name:   "log-dir",
+			Value:  "var/log",
+			EnvVar: "GO_INIT_LOG_DIR",
+			Usage:  "The directory the startup logs of the service are written to",
+		},
+	} {
+		assert.Contains(t, App().Flags, flag.Flag(f))
+	}
+}
+
+func TestServicePaths(t *testing.T) {
+	paths := servicePaths{root: "service", workingDir: "/srv/service", pidDir: "service/var/run",
+		logDir: "/var/log/service"}
+	assert.Equal(t, "service/var/run/primary.pid", paths.pidfile("primary"))
+	assert.Equal(t, "service/var/run/go-init.lock", paths.lockFile())
+	assert.Equal(t, "service/var/ready", paths.resolve("var/ready"))
+	assert.Equal(t, "/var/ready", paths.resolve("/var/ready"))
+	assert.Equal(t, "/var/log/service/startup.log", paths.primaryOutputFile())
+	assert.Equal(t, "/var/log/service/sidecar-startup.log", paths.subProcessOutputFile("sidecar"))
+}
+
+func TestServicePaths_DefaultOutputFiles(t *testing.T) {
+	paths := servicePaths{root: ".", workingDir: "/srv/service", logDir: "var/log"}
+	assert.Equal(t, PrimaryOutputFile, paths.primaryOutputFile())
+	assert.Equal(t, fmt.Sprintf(SubProcessOutputFileFormat, "sidecar"), paths.subProcessOutputFile("sidecar"))
+
+	loggers := NewFileLoggers(NewAlwaysAppending(), outputFileMode)
+	assert.Equal(t, PrimaryOutputFile, loggers.primaryOutputFile)
+	assert.Equal(t, "var/log/sidecar-startup.log", loggers.subProcessOutputFile("sidecar"))
+}
@@ -0,0 +1,129 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+	"github.com/pkg/errors"
+)
+
+// pidfileRecord identifies a process started by go-init. Pidfiles written by older versions of go-init contain only
+// the pid, and are read as records without any identity metadata.
+type pidfileRecord struct {
+	Pid int `json:"pid"`
+	// StartTime is the start time of the process in clock ticks since boot, as per /proc/<pid>/stat.
+	StartTime uint64 `json:"startTime,omitempty"`
+	// Executable is the path of the executable the process was running when it was started, as per /proc/<pid>/exe.
+	Executable string `json:"executable,omitempty"`
+	// ConfigHash is the hash of the arguments and configured environment variables the process was started with.
+	ConfigHash string `json:"configHash,omitempty"`
+	// Args are the arguments the process was started with.
+	Args []string `json:"args,omitempty"`
+}
+
+// newPidfileRecord returns the record of the given command started as the process with the given pid. Identity metadata
+// that cannot be read, e.g. because /proc is not available, is omitted.
+func newPidfileRecord(cmd CommandContext, pid int) pidfileRecord {
+	record := pidfileRecord{
+		Pid:        pid,
+		ConfigHash: cmd.ConfigHash,
+		Args:       cmd.Command.Args,
+	}
+	if startTime, err := processStartTime(pid); err == nil {
+		record.StartTime = startTime
+	}
+	// Commands run through cgexec exec the configured executable only once cgexec has started, so the executable
+	// read now may not be the one the process ends up running. The start time is unaffected by exec.
+	if cmd.Command.Path != launchlib.CgexecPath {
+		if executable, err := processExecutable(pid); err == nil {
+			record.Executable = executable
+		}
+	}
+	return record
+}
+
+// identifiesProcess returns whether the running process with the record's pid is the process the record was written
+// for, rather than an unrelated process that reused the pid. Records without identity metadata cannot be verified, so
+// any process with their pid is trusted.
+func (r pidfileRecord) identifiesProcess() (bool, error) {
+	if r.StartTime != 0 {
+		startTime, err := processStartTime(r.Pid)
+		if os.IsNotExist(errors.Cause(err)) {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+		if startTime != r.StartTime {
+			return false, nil
+		}
+	}
+	if r.Executable != "" {
+		executable, err := processExecutable(r.Pid)
+		if os.IsNotExist(errors.Cause(err)) {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+		if executable != r.Executable {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func readPidfile(pidfile string) (*pidfileRecord, error) {
+	pidBytes, err := ioutil.ReadFile(pidfile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "failed to read pidfile")
+	}
+
+	content := strings.TrimSpace(string(pidBytes))
+	if pid, err := strconv.Atoi(content); err == nil {
+		return &pidfileRecord{Pid: pid}, nil
+	}
+	var record pidfileRecord
+	if err := json.Unmarshal([]byte(content), &record); err != nil {
+		return nil, errors.Wrap(err, "pid file did not contain an integer or a process record")
+	}
+	if record.Pid <= 0 {
+		return nil, errors.Errorf("pid file contained an invalid pid %d", record.Pid)
+	}
+	return &record, nil
+}
+
+func writePidfile(name, pidfile string, record pidfileRecord) error {
+	if err := os.MkdirAll(filepath.Dir(pidfile), 0755); err != nil {
+		return errors.Wrapf(err, "unable to create pidfile directory.")
+	}
+
+	recordBytes, err := json.Marshal(record)
+	if err != nil {
+		return errors.Wrapf(err, "failed to serialize pidfile for command '%s'", name)
+	}
+	if err := ioutil.WriteFile(pidfile, recordBytes, 0644); err != nil {
+		return errors.Wrapf(err, "failed to save pid to file for command '%s'", name)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadPidfile(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		content   string
+		expected  *pidfileRecord
+		expectErr string
+	}{
+		{name: "legacy pid", content: "1234", expected: &pidfileRecord{Pid: 1234}},
+		{name: "legacy pid with newline", content: "1234\n", expected: &pidfileRecord{Pid: 1234}},
+		{
+			name: "record",
+			content: `{"pid":1234,"startTime":5678,"executable":"/usr/bin/java","configHash":"abc",` +
+				`"args":["java","Main"]}`,
+			expected: &pidfileRecord{
+				Pid:        1234,
+				StartTime:  5678,
+				Executable: "/usr/bin/java",
+				ConfigHash: "abc",
+				Args:       []string{"java", "Main"},
+			},
+		},
+		{name: "record without identity metadata", content: `{"pid":1234}`, expected: &pidfileRecord{Pid: 1234}},
+		{name: "empty", content: "", expectErr: "pid file did not contain an integer or a process record"},
+		{name: "garbage", content: "pid", expectErr: "pid file did not contain an integer or a process record"},
+		{name: "record without pid", content: `{"startTime":5678}`, expectErr: "pid file contained an invalid pid 0"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			pidfile := filepath.Join(t.TempDir(), "primary.pid")
+			require.NoError(t, ioutil.WriteFile(pidfile, []byte(tc.content), 0644))
+
+			record, err := readPidfile(pidfile)
+			if tc.expectErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, record)
+		})
+	}
+}
+
+func TestReadPidfile_Missing(t *testing.T) {
+	record, err := readPidfile(filepath.Join(t.TempDir(), "primary.pid"))
+	require.NoError(t, err)
+	assert.Nil(t, record)
+}
+
+func TestPidfileRecord_IdentifiesProcess(t *testing.T) {
+	pid := os.Getpid()
+	startTime, err := processStartTime(pid)
+	require.NoError(t, err)
+	executable, err := processExecutable(pid)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name     string
+		record   pidfileRecord
+		expected bool
+	}{
+		{name: "legacy record", record: pidfileRecord{Pid: pid}, expected: true},
+		{name: "matching record", record: pidfileRecord{Pid: pid, StartTime: startTime, Executable: executable},
+			expected: true},
+		{name: "reused pid with other start time",
+			record: pidfileRecord{Pid: pid, StartTime: startTime + 1, Executable: executable}},
+		{name: "reused pid with other executable",
+			record: pidfileRecord{Pid: pid, StartTime: startTime, Executable: "/usr/bin/other"}},
+		{name: "record without executable", record: pidfileRecord{Pid: pid, StartTime: startTime}, expected: true},
+		{name: "exited process", record: pidfileRecord{Pid: 99999999, StartTime: startTime}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			identified, err := tc.record.identifiesProcess()
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, identified)
+		})
+	}
+}
+
+func TestPidfileRecord_IdentifiesProcessWithReplacedExecutable(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pidfile-test")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+	sleepBytes, err := ioutil.ReadFile("/bin/sleep")
+	require.NoError(t, err)
+	executable := filepath.Join(dir, "sleep")
+	require.NoError(t, ioutil.WriteFile(executable, sleepBytes, 0755))
+
+	cmd := exec.Command(executable, "10")
+	require.NoError(t, cmd.Start())
+	defer func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}()
+	record := newPidfileRecord(CommandContext{Command: cmd}, cmd.Process.Pid)
+	require.Equal(t, executable, record.Executable)
+
+	// An upgrade replaces the executable on disk while the old process keeps running.
+	require.NoError(t, os.Remove(executable))
+	require.NoError(t, ioutil.WriteFile(executable, sleepBytes, 0755))
+
+	identified, err := record.identifiesProcess()
+	require.NoError(t, err)
+	assert.True(t, identified)
+}
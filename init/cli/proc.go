@@ -0,0 +1,142 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// clockTicksPerSecond is the unit of the times in /proc/<pid>/stat, which the kernel always reports as 100 per second
+// regardless of its internal tick rate.
+const clockTicksPerSecond = 100
+
+// deletedExecutableSuffix is appended by the kernel to /proc/<pid>/exe when the executable has been removed from disk.
+const deletedExecutableSuffix = " (deleted)"
+
+// processStat holds the fields of /proc/<pid>/stat that go-init uses.
+type processStat struct {
+	// StartTime is the time the process started in clock ticks since boot.
+	StartTime uint64
+	// CPUTime is the user and system CPU time used by the process in clock ticks.
+	CPUTime uint64
+	// RSSPages is the resident set size of the process in pages.
+	RSSPages uint64
+}
+
+// processStartTime returns the start time of the process with the given pid in clock ticks since boot, which together
+// with the pid uniquely identifies a process.
+func processStartTime(pid int) (uint64, error) {
+	stat, err := readProcessStat(pid)
+	if err != nil {
+		return 0, err
+	}
+	return stat.StartTime, nil
+}
+
+func readProcessStat(pid int) (processStat, error) {
+	statBytes, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return processStat{}, errors.Wrapf(err, "failed to read stat of process with pid %d", pid)
+	}
+	return parseProcessStat(string(statBytes))
+}
+
+// parseProcessStat parses the content of a /proc/<pid>/stat file. The 2nd field is the parenthesised command name,
+// which may itself contain spaces and parentheses, so fields are counted from the last closing parenthesis.
+func parseProcessStat(stat string) (processStat, error) {
+	commEnd := strings.LastIndex(stat, ")")
+	if commEnd < 0 {
+		return processStat{}, errors.New("process stat did not contain a command name")
+	}
+	// Fields following the command name start at the 3rd field.
+	fields := strings.Fields(stat[commEnd+1:])
+	field := func(number int, name string) (uint64, error) {
+		index := number - 3
+		if len(fields) <= index {
+			return 0, errors.Errorf("process stat contained only %d fields", len(fields)+2)
+		}
+		value, err := strconv.ParseUint(fields[index], 10, 64)
+		if err != nil {
+			return 0, errors.Wrapf(err, "process stat did not contain a valid %s", name)
+		}
+		return value, nil
+	}
+
+	var parsed processStat
+	var err error
+	if parsed.StartTime, err = field(22, "start time"); err != nil {
+		return processStat{}, err
+	}
+	utime, err := field(14, "user time")
+	if err != nil {
+		return processStat{}, err
+	}
+	stime, err := field(15, "system time")
+	if err != nil {
+		return processStat{}, err
+	}
+	parsed.CPUTime = utime + stime
+	if parsed.RSSPages, err = field(24, "resident set size"); err != nil {
+		return processStat{}, err
+	}
+	return parsed, nil
+}
+
+func processExecutable(pid int) (string, error) {
+	executable, err := os.Readlink(fmt.Sprintf("/proc/%d/exe", pid))
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read executable of process with pid %d", pid)
+	}
+	// The kernel marks an executable that was replaced on disk, e.g. by an upgrade, as deleted, but the process is
+	// still the one that was started from its path.
+	return strings.TrimSuffix(executable, deletedExecutableSuffix), nil
+}
+
+// processOpenFds returns the number of file descriptors the process with the given pid has open.
+func processOpenFds(pid int) (int, error) {
+	fds, err := ioutil.ReadDir(fmt.Sprintf("/proc/%d/fd", pid))
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to read file descriptors of process with pid %d", pid)
+	}
+	return len(fds), nil
+}
+
+// systemUptime returns the time since boot, as per /proc/uptime.
+func systemUptime() (time.Duration, error) {
+	uptimeBytes, err := ioutil.ReadFile("/proc/uptime")
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to read system uptime")
+	}
+	fields := strings.Fields(string(uptimeBytes))
+	if len(fields) == 0 {
+		return 0, errors.New("system uptime was empty")
+	}
+	seconds, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, errors.Wrap(err, "system uptime was not a number")
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+func clockTicksToDuration(ticks uint64) time.Duration {
+	return time.Duration(ticks) * time.Second / clockTicksPerSecond
+}
@@ -0,0 +1,50 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseProcessStat(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		stat      string
+		want      processStat
+		expectErr bool
+	}{
+		{name: "simple command", stat: "42 (java) S 1 2 3 4 5 6 7 8 9 10 70 30 13 14 15 16 17 18 12345 20 2048 22",
+			want: processStat{StartTime: 12345, CPUTime: 100, RSSPages: 2048}},
+		{name: "command with spaces and parentheses",
+			stat: "42 (a (b) c) S 1 2 3 4 5 6 7 8 9 10 11 12 13 14 15 16 17 18 678 20 21 22",
+			want: processStat{StartTime: 678, CPUTime: 23, RSSPages: 21}},
+		{name: "no command", stat: "42 S 1 2", expectErr: true},
+		{name: "too few fields", stat: "42 (java) S 1 2 3", expectErr: true},
+		{name: "invalid start time",
+			stat: "42 (java) S 1 2 3 4 5 6 7 8 9 10 11 12 13 14 15 16 17 18 abc 20 21 22", expectErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseProcessStat(tc.stat)
+			if tc.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.want, got)
+			}
+		})
+	}
+}
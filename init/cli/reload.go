@@ -0,0 +1,125 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"syscall"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+	"github.com/palantir/pkg/cli"
+	"github.com/palantir/pkg/cli/flag"
+	"github.com/pkg/errors"
+)
+
+const signalFlagName = "signal"
+
+var reloadSignalFlag = flag.StringFlag{
+	Name:  signalFlagName,
+	Value: "SIGHUP",
+	Usage: "The signal sent to the running processes to cause them to reload their configuration",
+}
+
+var reloadCliCommand = cli.Command{
+	Name: "reload",
+	Usage: `
+Causes the running processes of the service defined by the static and custom configurations at
+service/bin/launcher-static.yml and var/conf/launcher-custom.yml to reload their configuration by sending them a
+signal, SIGHUP unless otherwise specified.
+Exits:
+- 0 if the signal was sent to all running processes
+- 1 if the signal could not be sent
+- 2 if the signal is invalid
+- 7 if no processes are running
+- 150 if another go-init command holds the lock for longer than --lock-timeout
+If exit code is nonzero, writes an error message to stderr and var/log/startup.log.`,
+	Flags:  []flag.Flag{reloadSignalFlag},
+	Action: executeWithLock(executeWithLoggers(reload, NewAlwaysAppending())),
+}
+
+var forceReloadCliCommand = cli.Command{
+	Name: "force-reload",
+	Usage: `
+Reloads the service defined by the static and custom configurations at service/bin/launcher-static.yml and
+var/conf/launcher-custom.yml as per the reload command if all of its processes are running, or restarts it as per the
+restart command if only some of its processes are running.
+Exits:
+- 0 if the service was reloaded or restarted
+- 1 if the service could not be reloaded or restarted
+- 2 if the signal is invalid
+- 7 if no processes are running
+- 150 if another go-init command holds the lock for longer than --lock-timeout
+If exit code is nonzero, writes an error message to stderr and var/log/startup.log.`,
+	Flags:  []flag.Flag{reloadSignalFlag},
+	Action: executeWithLock(executeWithLoggers(forceReload, NewAlwaysAppending())),
+}
+
+func reload(ctx cli.Context, loggers launchlib.ServiceLoggers) error {
+	sig, err := launchlib.ParseSignal(ctx.String(signalFlagName))
+	if err != nil {
+		return logErrorAndReturnWithExitCode(ctx, errors.Wrap(err, "invalid reload signal"), 2)
+	}
+	serviceStatus, err := getServiceStatus(ctx, loggers)
+	if err != nil {
+		return logErrorAndReturnWithExitCode(ctx,
+			errors.Wrap(err, "failed to determine service status to determine what processes to reload"), 1)
+	}
+	if len(serviceStatus.runningProcs) == 0 {
+		return logErrorAndReturnWithExitCode(ctx, errors.Errorf("commands '%v' are not running",
+			commandNames(serviceStatus.notRunningCmds)), 7)
+	}
+	if err := reloadService(ctx, serviceStatus.runningProcs, serviceStatus.configuredCmds, sig); err != nil {
+		return logErrorAndReturnWithExitCode(ctx, errors.Wrap(err, "failed to reload service"), 1)
+	}
+	return nil
+}
+
+func forceReload(ctx cli.Context, loggers launchlib.ServiceLoggers) error {
+	sig, err := launchlib.ParseSignal(ctx.String(signalFlagName))
+	if err != nil {
+		return logErrorAndReturnWithExitCode(ctx, errors.Wrap(err, "invalid reload signal"), 2)
+	}
+	serviceStatus, err := getServiceStatus(ctx, loggers)
+	if err != nil {
+		return logErrorAndReturnWithExitCode(ctx,
+			errors.Wrap(err, "failed to determine service status to determine what processes to reload"), 1)
+	}
+	if len(serviceStatus.runningProcs) == 0 {
+		return logErrorAndReturnWithExitCode(ctx, errors.Errorf("commands '%v' are not running",
+			commandNames(serviceStatus.notRunningCmds)), 7)
+	}
+	if len(serviceStatus.notRunningCmds) > 0 {
+		_, _ = fmt.Fprintf(ctx.App.Stdout, "commands '%v' are not running, so the service will be restarted\n",
+			commandNames(serviceStatus.notRunningCmds))
+		return restartService(ctx, serviceStatus)
+	}
+	if err := reloadService(ctx, serviceStatus.runningProcs, serviceStatus.configuredCmds, sig); err != nil {
+		return logErrorAndReturnWithExitCode(ctx, errors.Wrap(err, "failed to reload service"), 1)
+	}
+	return nil
+}
+
+func reloadService(ctx cli.Context, procs map[string]*trackedProcess, cmds map[string]CommandContext,
+	sig syscall.Signal) error {
+	for name, proc := range procs {
+		if err := proc.Signal(sig); err != nil && !strings.Contains(err.Error(), "os: process already finished") {
+			return errors.Wrapf(err, "failed to send signal %s to '%s' process", sig, name)
+		}
+		recordSignalSent(ctx, name, proc, cmds[name], sig, "")
+		_, _ = fmt.Fprintf(ctx.App.Stdout, "Sent signal %s to '%s' process with pid %d\n", sig, name, proc.Pid)
+	}
+	return nil
+}
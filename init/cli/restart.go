@@ -0,0 +1,82 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"fmt"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+	"github.com/palantir/pkg/cli"
+	"github.com/pkg/errors"
+)
+
+var restartCliCommand = cli.Command{
+	Name: "restart",
+	Usage: `
+Stops the service defined by the static and custom configurations at service/bin/launcher-static.yml and
+var/conf/launcher-custom.yml if it is running, and then starts it again. Restarting a service that is not running
+starts it. If successful, exits 0, otherwise exits 1 and writes an error message to stderr and var/log/startup.log.
+Processes are stopped as per the stop command.
+Exits 150 if another go-init command holds the lock for longer than --lock-timeout.`,
+	Action: executeWithLock(executeWithLoggers(restart, NewTruncatingFirst())),
+}
+
+var tryRestartCliCommand = cli.Command{
+	Name: "try-restart",
+	Usage: `
+Restarts the service defined by the static and custom configurations at service/bin/launcher-static.yml and
+var/conf/launcher-custom.yml if at least one of its processes is running, and does nothing otherwise. If successful,
+exits 0, otherwise exits 1 and writes an error message to stderr and var/log/startup.log. Processes are stopped as
+per the stop command.
+Exits 150 if another go-init command holds the lock for longer than --lock-timeout.`,
+	Action: executeWithLock(executeWithLoggers(tryRestart, NewTruncatingFirst())),
+}
+
+func restart(ctx cli.Context, loggers launchlib.ServiceLoggers) error {
+	serviceStatus, err := getServiceStatus(ctx, loggers)
+	if err != nil {
+		return logErrorAndReturnWithExitCode(ctx,
+			errors.Wrap(err, "failed to determine service status to determine what commands to restart"), 1)
+	}
+	return restartService(ctx, serviceStatus)
+}
+
+func tryRestart(ctx cli.Context, loggers launchlib.ServiceLoggers) error {
+	serviceStatus, err := getServiceStatus(ctx, loggers)
+	if err != nil {
+		return logErrorAndReturnWithExitCode(ctx,
+			errors.Wrap(err, "failed to determine service status to determine what commands to restart"), 1)
+	}
+	if len(serviceStatus.runningProcs) == 0 {
+		_, _ = fmt.Fprintln(ctx.App.Stdout, "Service not running, so it will not be restarted")
+		return nil
+	}
+	return restartService(ctx, serviceStatus)
+}
+
+func restartService(ctx cli.Context, serviceStatus *serviceStatus) error {
+	if len(serviceStatus.runningProcs) > 0 {
+		if err := stopService(ctx, serviceStatus.runningProcs, serviceStatus.configuredCmds); err != nil {
+			return logErrorAndReturnWithExitCode(ctx, errors.Wrap(err, "failed to stop service"), 1)
+		}
+	}
+	if err := removePidfiles(ctx, serviceStatus.configuredCmds); err != nil {
+		return logErrorAndReturnWithExitCode(ctx, err, 1)
+	}
+	if _, err := startService(ctx, serviceStatus.configuredCmds, true); err != nil {
+		return logErrorAndReturnWithExitCode(ctx, errors.Wrap(err, "failed to start service"), 1)
+	}
+	return nil
+}
@@ -0,0 +1,310 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+	"github.com/palantir/pkg/cli"
+	"github.com/palantir/pkg/cli/flag"
+	"github.com/pkg/errors"
+)
+
+const (
+	logFileFlagName              = "file"
+	logMaxSizeFlagName           = "max-size"
+	logMaxFilesFlagName          = "max-files"
+	logPruneRotatedAfterFlagName = "prune-rotated-after"
+	logCompressFlagName          = "compress"
+
+	gzipSuffix = ".gz"
+)
+
+// SelfCommand returns a command that runs go-init with the given arguments, which go-init uses to spawn the processes
+// that do work on behalf of the processes it starts, such as writing their output.
+var SelfCommand = func(args ...string) *exec.Cmd {
+	executable, err := os.Executable()
+	if err != nil {
+		executable = os.Args[0]
+	}
+	return exec.Command(executable, args...)
+}
+
+var logWriterCliCommand = cli.Command{
+	Name: "log-writer",
+	Usage: `
+Used by go-init to write the output of the processes it starts that have logRotation configured: writes its stdin to
+the given file until stdin is closed, rotating the file before it grows beyond --max-size and keeping at most
+--max-files rotated files. Ignores SIGHUP, SIGINT and SIGTERM so that it writes all the output of the process, which
+closes stdin when it exits.`,
+	Flags: []flag.Flag{
+		flag.StringFlag{Name: logFileFlagName, Usage: "The log file to write to"},
+		flag.StringFlag{Name: logMaxSizeFlagName, Usage: "The size in bytes the log file is rotated before exceeding"},
+		flag.IntFlag{Name: logMaxFilesFlagName, Value: launchlib.DefaultLogRotationMaxFiles,
+			Usage: "The number of rotated files to keep"},
+		flag.DurationFlag{Name: logPruneRotatedAfterFlagName, Value: "0s",
+			Usage: "The age after which rotated files are removed when the log file is opened or rotated, if not 0"},
+		flag.BoolFlag{Name: logCompressFlagName, Usage: "Whether to compress rotated files with gzip"},
+	},
+	Action: writeLog,
+}
+
+func writeLog(ctx cli.Context) error {
+	signal.Ignore(syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM)
+
+	maxSize, err := strconv.ParseInt(ctx.String(logMaxSizeFlagName), 10, 64)
+	if err != nil || maxSize <= 0 {
+		return cli.WithExitCode(2, errors.Errorf("max size must be a positive number of bytes, found '%s'",
+			ctx.String(logMaxSizeFlagName)))
+	}
+	writer, err := newRotatingWriter(ctx.String(logFileFlagName), launchlib.LogRotationBehavior{
+		MaxSize:           maxSize,
+		MaxFiles:          ctx.Int(logMaxFilesFlagName),
+		PruneRotatedAfter: ctx.Duration(logPruneRotatedAfterFlagName),
+		Compress:          ctx.Bool(logCompressFlagName),
+	})
+	if err != nil {
+		return cli.WithExitCode(1, err)
+	}
+	defer func() {
+		_ = writer.Close()
+	}()
+	if _, err := io.Copy(writer, os.Stdin); err != nil {
+		return cli.WithExitCode(1, errors.Wrapf(err, "failed to write to '%s'", writer.path))
+	}
+	return nil
+}
+
+// startLogWriter starts a go-init log-writer that writes the output of the given command to its output file as per the
+// log rotation of the command, and returns the pipe the command should write its output to. The log-writer is
+// detached from go-init in the same way as the command, and exits once every writer of the pipe has closed it.
+func startLogWriter(cmdCtx CommandContext) (*os.File, error) {
+	outputFile, err := filepath.Abs(cmdCtx.OutputFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to resolve output file '%s'", cmdCtx.OutputFile)
+	}
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create pipe for output")
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+
+	rotation := cmdCtx.LogRotation
+	args := []string{logWriterCliCommand.Name,
+		"--" + logFileFlagName, outputFile,
+		"--" + logMaxSizeFlagName, strconv.FormatInt(rotation.MaxSize, 10),
+		"--" + logMaxFilesFlagName, strconv.Itoa(rotation.MaxFiles),
+		"--" + logPruneRotatedAfterFlagName, rotation.PruneRotatedAfter.String(),
+	}
+	if rotation.Compress {
+		args = append(args, "--"+logCompressFlagName)
+	}
+	// Errors of the log-writer are written directly to the output file, since it has nowhere else to write them.
+	errFile, err := os.OpenFile(outputFile, appendOutputFileFlag, outputFileMode)
+	if err != nil {
+		_ = writer.Close()
+		return nil, errors.Wrapf(err, "could not open logging file '%s'", outputFile)
+	}
+	defer func() {
+		_ = errFile.Close()
+	}()
+	logWriter := SelfCommand(args...)
+	logWriter.Stdin = reader
+	logWriter.Stderr = errFile
+	logWriter.Dir = cmdCtx.Command.Dir
+	logWriter.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := logWriter.Start(); err != nil {
+		_ = writer.Close()
+		return nil, errors.Wrap(err, "failed to start log writer")
+	}
+	// Reaps the log-writer if go-init is still running when it exits, e.g. when running the service in the foreground.
+	go func() {
+		_ = logWriter.Wait()
+	}()
+	return writer, nil
+}
+
+// openLogFile opens the log file of a rotatingWriter.
+var openLogFile = os.OpenFile
+
+// rotatingWriter writes to a log file, rotating it as per its log rotation behavior.
+type rotatingWriter struct {
+	path     string
+	behavior launchlib.LogRotationBehavior
+	file     *os.File
+	size     int64
+}
+
+func newRotatingWriter(path string, behavior launchlib.LogRotationBehavior) (*rotatingWriter, error) {
+	w := &rotatingWriter{path: path, behavior: behavior}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	w.pruneRotatedFiles()
+	return w, nil
+}
+
+func (w *rotatingWriter) open() error {
+	file, err := openLogFile(w.path, appendOutputFileFlag, outputFileMode)
+	if err != nil {
+		return errors.Wrapf(err, "could not open logging file '%s'", w.path)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return errors.Wrapf(err, "failed to stat '%s'", w.path)
+	}
+	w.file = file
+	w.size = info.Size()
+	return nil
+}
+
+// Write writes the given bytes to the log file, first rotating it if they would grow it beyond its maximum size. Writes
+// are not split across files, so a single write larger than the maximum size is written to a file of its own. Failing
+// to rotate the log file does not prevent the output from being written, so is reported in the current log file, and
+// rotation is retried once the output written to it since has reached the maximum size.
+func (w *rotatingWriter) Write(p []byte) (int, error) {
+	if w.size > 0 && w.size+int64(len(p)) > w.behavior.MaxSize {
+		if err := w.rotate(); err != nil {
+			_, _ = fmt.Fprintf(w.file, "go-init failed to rotate log file: %v\n", err)
+			w.size = 0
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *rotatingWriter) Close() error {
+	return w.file.Close()
+}
+
+// rotate rotates the log file and opens a new one, leaving the current file open if the new one cannot be opened.
+// Failing to close, compress or remove rotated files does not prevent the output from being written, so is reported in
+// the new log file instead.
+func (w *rotatingWriter) rotate() error {
+	current := w.file
+	rotateFile(w.path, w.behavior.MaxFiles-1)
+	if err := w.open(); err != nil {
+		return err
+	}
+	if err := current.Close(); err != nil {
+		_, _ = fmt.Fprintf(w, "go-init failed to close rotated log file: %v\n", err)
+	}
+	if w.behavior.Compress {
+		if err := compressFile(rotatedFile(w.path, 0)); err != nil {
+			_, _ = fmt.Fprintf(w, "go-init failed to compress rotated log file: %v\n", err)
+		}
+	}
+	w.pruneRotatedFiles()
+	return nil
+}
+
+// pruneRotatedFiles removes the rotated files of the log file that are beyond its maximum number of files, e.g. because
+// go-init start rotated the log file, or that are older than its maximum age.
+func (w *rotatingWriter) pruneRotatedFiles() {
+	rotated, err := filepath.Glob(w.path + ".*")
+	if err != nil {
+		return
+	}
+	now := Clock.Now()
+	for _, file := range rotated {
+		index, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(file, w.path+"."), gzipSuffix))
+		if err != nil {
+			continue
+		}
+		if index < w.behavior.MaxFiles {
+			if w.behavior.PruneRotatedAfter <= 0 {
+				continue
+			}
+			info, err := os.Stat(file)
+			if err != nil || now.Sub(info.ModTime()) <= w.behavior.PruneRotatedAfter {
+				continue
+			}
+		}
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			_, _ = fmt.Fprintf(w, "go-init failed to remove rotated log file: %v\n", err)
+		}
+	}
+}
+
+// rotatedFile returns the path of the given rotated file of the log file at the given path, without the suffix of a
+// compressed file.
+func rotatedFile(path string, index int) string {
+	return path + "." + strconv.Itoa(index)
+}
+
+// rotateFile moves the log file at the given path to path.0, first moving each rotated file path.N, or path.N.gz if it
+// was compressed, to path.N+1 and removing those that would be moved beyond path.<limit>.
+func rotateFile(path string, limit int) {
+	for _, suffix := range []string{"", gzipSuffix} {
+		_ = os.Remove(rotatedFile(path, limit) + suffix)
+		for i := limit; i > 0; i-- {
+			_ = os.Rename(rotatedFile(path, i-1)+suffix, rotatedFile(path, i)+suffix)
+		}
+	}
+	_ = os.Rename(path, rotatedFile(path, 0))
+}
+
+// compressFile compresses the file at the given path with gzip, replacing it with the compressed file.
+func compressFile(path string) (rErr error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open '%s'", path)
+	}
+	defer func() {
+		_ = src.Close()
+	}()
+
+	// The compressed file is written under a temporary name so that a partially compressed file is never rotated.
+	tmpPath := path + gzipSuffix + ".tmp"
+	dst, err := os.OpenFile(tmpPath, truncOutputFileFlag, outputFileMode)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create '%s'", tmpPath)
+	}
+	defer func() {
+		if rErr != nil {
+			_ = os.Remove(tmpPath)
+		}
+	}()
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		_ = dst.Close()
+		return errors.Wrapf(err, "failed to compress '%s'", path)
+	}
+	if err := gz.Close(); err != nil {
+		_ = dst.Close()
+		return errors.Wrapf(err, "failed to compress '%s'", path)
+	}
+	if err := dst.Close(); err != nil {
+		return errors.Wrapf(err, "failed to close '%s'", tmpPath)
+	}
+	if err := os.Rename(tmpPath, path+gzipSuffix); err != nil {
+		return errors.Wrapf(err, "failed to rename '%s'", tmpPath)
+	}
+	return os.Remove(path)
+}
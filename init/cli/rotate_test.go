@@ -0,0 +1,187 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	time2 "github.com/palantir/go-java-launcher/init/cli/time"
+	"github.com/palantir/go-java-launcher/launchlib"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotatingWriter_RotatesBeforeMaxSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "startup.log")
+	writer, err := newRotatingWriter(path, launchlib.LogRotationBehavior{MaxSize: 10, MaxFiles: 2})
+	require.NoError(t, err)
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		_, err := writer.Write([]byte(line))
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+
+	assertFileContent(t, path, "fourth\n")
+	assertFileContent(t, path+".0", "third\n")
+	assertFileContent(t, path+".1", "second\n")
+	assert.NoFileExists(t, path+".2")
+}
+
+func TestRotatingWriter_AppendsToExistingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "startup.log")
+	require.NoError(t, ioutil.WriteFile(path, []byte("first\n"), 0644))
+	writer, err := newRotatingWriter(path, launchlib.LogRotationBehavior{MaxSize: 15, MaxFiles: 2})
+	require.NoError(t, err)
+
+	for _, line := range []string{"second\n", "third\n"} {
+		_, err := writer.Write([]byte(line))
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+
+	assertFileContent(t, path, "third\n")
+	assertFileContent(t, path+".0", "first\nsecond\n")
+}
+
+func TestRotatingWriter_KeepsWritingWhenRotationFails(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "startup.log")
+	writer, err := newRotatingWriter(path, launchlib.LogRotationBehavior{MaxSize: 10, MaxFiles: 3})
+	require.NoError(t, err)
+
+	openLogFile = func(name string, flag int, perm os.FileMode) (*os.File, error) {
+		return nil, errors.New("too many open files")
+	}
+	defer func() {
+		openLogFile = os.OpenFile
+	}()
+	for _, line := range []string{"first\n", "second\n"} {
+		n, err := writer.Write([]byte(line))
+		require.NoError(t, err)
+		assert.Equal(t, len(line), n)
+	}
+	openLogFile = os.OpenFile
+	_, err = writer.Write([]byte("third\n"))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	// The output written while the new log file failed to open is kept in the file that was being rotated.
+	assertFileContent(t, path, "third\n")
+	assertFileContent(t, path+".1", "first\ngo-init failed to rotate log file: could not open logging file '"+path+
+		"': too many open files\nsecond\n")
+}
+
+func TestRotatingWriter_Compresses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "startup.log")
+	writer, err := newRotatingWriter(path, launchlib.LogRotationBehavior{MaxSize: 10, MaxFiles: 2, Compress: true})
+	require.NoError(t, err)
+
+	for _, line := range []string{"first\n", "second\n", "third\n"} {
+		_, err := writer.Write([]byte(line))
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+
+	assertFileContent(t, path, "third\n")
+	assertGzipFileContent(t, path+".0.gz", "second\n")
+	assertGzipFileContent(t, path+".1.gz", "first\n")
+	assert.NoFileExists(t, path+".0")
+	assert.NoFileExists(t, path+".1")
+}
+
+func TestRotatingWriter_PrunesRotatedFiles(t *testing.T) {
+	now := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	previousClock := Clock
+	Clock = time2.NewFakeClockAt(now)
+	defer func() {
+		Clock = previousClock
+	}()
+
+	path := filepath.Join(t.TempDir(), "startup.log")
+	for _, file := range []struct {
+		name string
+		age  time.Duration
+	}{
+		{name: path + ".0", age: time.Hour},
+		{name: path + ".1.gz", age: 2 * time.Hour},
+		{name: path + ".2", age: time.Hour},
+		{name: path + ".3", age: time.Hour},
+	} {
+		require.NoError(t, ioutil.WriteFile(file.name, []byte("rotated\n"), 0644))
+		modTime := now.Add(-file.age)
+		require.NoError(t, os.Chtimes(file.name, modTime, modTime))
+	}
+
+	writer, err := newRotatingWriter(path, launchlib.LogRotationBehavior{
+		MaxSize:           10,
+		MaxFiles:          3,
+		PruneRotatedAfter: 90 * time.Minute,
+	})
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	// Files beyond the maximum number of files, e.g. those rotated by go-init start, and files older than the maximum
+	// age are removed.
+	assert.FileExists(t, path+".0")
+	assert.NoFileExists(t, path+".1.gz")
+	assert.FileExists(t, path+".2")
+	assert.NoFileExists(t, path+".3")
+}
+
+func TestRotateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "startup.log")
+	for name, content := range map[string]string{
+		path:           "current\n",
+		path + ".0":    "newest\n",
+		path + ".1.gz": "older\n",
+		path + ".2":    "oldest\n",
+	} {
+		require.NoError(t, ioutil.WriteFile(name, []byte(content), 0644))
+	}
+
+	rotateFile(path, 2)
+
+	assert.NoFileExists(t, path)
+	assertFileContent(t, path+".0", "current\n")
+	assertFileContent(t, path+".1", "newest\n")
+	assertFileContent(t, path+".2.gz", "older\n")
+	assert.NoFileExists(t, path+".2")
+	assert.NoFileExists(t, path+".3")
+}
+
+func assertFileContent(t *testing.T, path, expected string) {
+	content, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(content))
+}
+
+func assertGzipFileContent(t *testing.T, path, expected string) {
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer func() {
+		_ = file.Close()
+	}()
+	reader, err := gzip.NewReader(file)
+	require.NoError(t, err)
+	content, err := ioutil.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(content))
+}
@@ -0,0 +1,206 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+	"github.com/palantir/pkg/cli"
+	"github.com/pkg/errors"
+)
+
+var runCliCommand = cli.Command{
+	Name: "run",
+	Usage: `
+Runs the service defined by the static and custom configurations at service/bin/launcher-static.yml and
+var/conf/launcher-custom.yml in the foreground, for supervisors such as systemd or runit. Starts its processes as
+children as per the start command, writing the same pidfiles so that status and stop work from another shell, and then
+keeps running until the service stops:
+- SIGTERM and SIGINT stop the service as per the stop command
+- SIGHUP, SIGQUIT, SIGUSR1 and SIGUSR2 are forwarded to every process
+- if a process exits, the remaining processes are stopped as per the stop command
+The lock is only held while the service starts and stops.
+Exits:
+- 0 if the service was stopped by a signal or by another go-init command
+- 1 if the service could not be started, is already running, or a process exited by itself
+- 150 if another go-init command holds the lock for longer than --lock-timeout
+If exit code is nonzero, writes an error message to stderr and var/log/startup.log.`,
+	Action: executeWithReleasableLock(func(ctx cli.Context, releaseLock func()) error {
+		return executeWithLoggers(func(ctx cli.Context, loggers launchlib.ServiceLoggers) error {
+			return run(ctx, loggers, releaseLock)
+		}, NewTruncatingFirst())(ctx)
+	}),
+}
+
+var (
+	runStopSignals      = []os.Signal{syscall.SIGTERM, syscall.SIGINT}
+	runForwardedSignals = []os.Signal{syscall.SIGHUP, syscall.SIGQUIT, syscall.SIGUSR1, syscall.SIGUSR2}
+)
+
+func run(ctx cli.Context, loggers launchlib.ServiceLoggers, releaseLock func()) error {
+	// Signals are caught from before the processes are started so that none are lost, and are acted on once the
+	// service has started. The cli package exits as soon as it receives a stop signal, so its handling of them is reset
+	// so that the service can be stopped first.
+	signal.Reset(runStopSignals...)
+	signals := make(chan os.Signal, len(runStopSignals)+len(runForwardedSignals))
+	signal.Notify(signals, append(runStopSignals, runForwardedSignals...)...)
+	defer signal.Stop(signals)
+
+	serviceStatus, err := getServiceStatus(ctx, loggers)
+	if err != nil {
+		return logErrorAndReturnWithExitCode(ctx,
+			errors.Wrap(err, "failed to determine service status to determine what commands to run"), 1)
+	}
+	if len(serviceStatus.runningProcs) > 0 {
+		names := make([]string, 0, len(serviceStatus.runningProcs))
+		for name := range serviceStatus.runningProcs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return logErrorAndReturnWithExitCode(ctx, errors.Errorf("commands '%v' are already running, so the service "+
+			"cannot be run in the foreground", names), 1)
+	}
+	started, err := startService(ctx, serviceStatus.configuredCmds, false)
+	if err != nil {
+		return logErrorAndReturnWithExitCode(ctx, errors.Wrap(err, "failed to start service"), 1)
+	}
+	releaseLock()
+
+	names := commandNames(serviceStatus.configuredCmds)
+	sort.Strings(names)
+	_, _ = fmt.Fprintf(ctx.App.Stdout, "Running commands '%v' in the foreground\n", names)
+	notifySystemd(ctx, launchlib.SdNotifyReady)
+	return superviseService(ctx, serviceStatus.configuredCmds, started, signals)
+}
+
+// superviseService waits for a signal or for a process of the given started service to exit, and stops the service
+// once either a stop signal is received or a process exits. If the systemd watchdog is enabled, systemd is notified
+// that the service is alive for as long as its primary process is running.
+func superviseService(ctx cli.Context, cmds map[string]CommandContext, started *startedService,
+	signals <-chan os.Signal) error {
+	var watchdogTicks <-chan time.Time
+	watchdogInterval, err := launchlib.SdWatchdogInterval(os.Getpid())
+	if err != nil {
+		_, _ = fmt.Fprintf(ctx.App.Stdout, "Not notifying the systemd watchdog: %v\n", err)
+	}
+	if watchdogInterval > 0 {
+		ticker := Clock.NewTicker(watchdogInterval)
+		defer ticker.Stop()
+		watchdogTicks = ticker.Chan()
+	}
+	primaryName, _ := primaryCommandName(cmds)
+
+	for {
+		select {
+		case <-watchdogTicks:
+			if primary, ok := started.procs[primaryName]; ok {
+				if running, err := isProcRunning(primary); err == nil && running {
+					notifySystemd(ctx, launchlib.SdNotifyWatchdog)
+				}
+			}
+		case sig := <-signals:
+			if sig == syscall.SIGTERM || sig == syscall.SIGINT {
+				_, _ = fmt.Fprintf(ctx.App.Stdout, "Received %s, so stopping the service\n", sig)
+				notifySystemd(ctx, launchlib.SdNotifyStopping)
+				releaseLock := lockForSupervisedStop(ctx, ctx.Duration(lockTimeoutFlagName))
+				defer releaseLock()
+				if err := stopSupervisedService(ctx, cmds, started.procs, true); err != nil {
+					return logErrorAndReturnWithExitCode(ctx, errors.Wrap(err, "failed to stop service"), 1)
+				}
+				return nil
+			}
+			if err := reloadService(ctx, started.procs, cmds, sig.(syscall.Signal)); err != nil {
+				_, _ = fmt.Fprintf(ctx.App.Stdout, "failed to forward %s: %v\n", sig, err)
+			}
+		case e := <-started.exited:
+			if e.err == nil {
+				e.err = errors.New("exit status 0")
+			}
+			_, _ = fmt.Fprintf(ctx.App.Stdout, "Command '%s' exited: %v\n", e.name, e.err)
+			return handleSupervisedExit(ctx, cmds, started.procs, e)
+		}
+	}
+}
+
+// handleSupervisedExit stops the rest of the service once one of its processes has exited. A go-init command that stops
+// or restarts the service holds the lock while it does so, so once the lock is free, a process whose pidfile has been
+// removed or replaced was stopped by another go-init command rather than having exited by itself.
+func handleSupervisedExit(ctx cli.Context, cmds map[string]CommandContext, procs map[string]*trackedProcess,
+	e commandExit) error {
+	exitedPid := procs[e.name].Pid
+	delete(procs, e.name)
+	notifySystemd(ctx, launchlib.SdNotifyStopping)
+
+	// Another go-init command may hold the lock for as long as it takes to stop the service.
+	var longestStopTimeout time.Duration
+	for _, cmd := range cmds {
+		if cmd.Stop.Timeout > longestStopTimeout {
+			longestStopTimeout = cmd.Stop.Timeout
+		}
+	}
+	releaseLock := lockForSupervisedStop(ctx, ctx.Duration(lockTimeoutFlagName)+longestStopTimeout)
+	defer releaseLock()
+
+	if record, err := readPidfile(cmds[e.name].Pidfile); err == nil && (record == nil || record.Pid != exitedPid) {
+		_, _ = fmt.Fprintf(ctx.App.Stdout, "Command '%s' was stopped by another go-init command\n", e.name)
+		if err := stopSupervisedService(ctx, cmds, procs, false); err != nil {
+			return logErrorAndReturnWithExitCode(ctx, errors.Wrap(err, "failed to stop service"), 1)
+		}
+		return nil
+	}
+	if err := stopSupervisedService(ctx, cmds, procs, true); err != nil {
+		return logErrorAndReturnWithExitCode(ctx, errors.Wrapf(err, "failed to stop service after command '%s' "+
+			"exited", e.name), 1)
+	}
+	return logErrorAndReturnWithExitCode(ctx, errors.Errorf("command '%s' exited: %v", e.name, e.err), 1)
+}
+
+// lockForSupervisedStop takes the lock, waiting up to the given timeout for another go-init command to release it, and
+// returns a function that releases it. The service is stopped even if the lock cannot be taken, since the processes
+// started by go-init run must not outlive it.
+func lockForSupervisedStop(ctx cli.Context, timeout time.Duration) func() {
+	paths, err := getServicePaths(ctx)
+	if err != nil {
+		_, _ = fmt.Fprintf(ctx.App.Stdout, "Stopping the service without holding the lock: %v\n", err)
+		return func() {}
+	}
+	lock, err := acquireLock(paths.lockFile(), timeout)
+	if err != nil {
+		_, _ = fmt.Fprintf(ctx.App.Stdout, "Stopping the service without holding the lock: %v\n", err)
+		return func() {}
+	}
+	return func() {
+		_ = lock.Close()
+	}
+}
+
+// stopSupervisedService stops the given remaining processes of the service as per the stop command, and removes the
+// pidfiles of the service if they belong to this invocation.
+func stopSupervisedService(ctx cli.Context, cmds map[string]CommandContext, procs map[string]*trackedProcess,
+	removePidfilesOfService bool) error {
+	if err := stopService(ctx, procs, cmds); err != nil {
+		return err
+	}
+	if removePidfilesOfService {
+		return removePidfiles(ctx, cmds)
+	}
+	return nil
+}
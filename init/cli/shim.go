@@ -0,0 +1,304 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+	"github.com/palantir/pkg/cli"
+	"github.com/palantir/pkg/cli/flag"
+	"github.com/pkg/errors"
+)
+
+const (
+	exitFileFlagName = "exit-file"
+	nameFlagName     = "name"
+	eventLogFlagName = "event-log"
+
+	// shimPidFd is the file descriptor the shim writes the pid of the process it started to.
+	shimPidFd = 3
+)
+
+// shimForwardedSignals are the signals the shim forwards to the process it started, so that signalling the shim, e.g.
+// by a supervisor that only knows of the shim, has the same effect as signalling the process.
+var shimForwardedSignals = []os.Signal{syscall.SIGHUP, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGTERM,
+	syscall.SIGUSR1, syscall.SIGUSR2}
+
+var shimCliCommand = cli.Command{
+	Name: "shim",
+	Usage: `
+Used by go-init to start the processes of the service: reads the command to start as JSON from stdin, starts it in a
+session of its own with the stdout and stderr of the shim, writes its pid to file descriptor 3, waits for it to exit and
+writes how it exited to --exit-file and, if given, appends an event of its exit to --event-log. Forwards SIGHUP,
+SIGINT, SIGQUIT, SIGTERM, SIGUSR1 and SIGUSR2 to the process.
+Exits with the exit code of the process, 128 plus the number of the signal that terminated it, or 1 if it could not be
+started.`,
+	Flags: []flag.Flag{
+		flag.StringFlag{Name: exitFileFlagName, Usage: "The file to write the exit record of the process to"},
+		flag.StringFlag{Name: nameFlagName, Usage: "The name of the process, as recorded in the event log"},
+		flag.StringFlag{Name: eventLogFlagName, Usage: "The event log to append the exit of the process to"},
+	},
+	Action: runShim,
+}
+
+// shimCommand is the command the shim starts, as given to it on stdin.
+type shimCommand struct {
+	Path string   `json:"path"`
+	Args []string `json:"args"`
+	Env  []string `json:"env,omitempty"`
+	Dir  string   `json:"dir,omitempty"`
+}
+
+// exitRecord is how a process started by go-init exited, as written to var/run/${PROCESS}.exit.
+type exitRecord struct {
+	Pid int `json:"pid"`
+	// ExitCode is -1 if the process was terminated by a signal.
+	ExitCode   int       `json:"exitCode"`
+	Signal     int       `json:"signal,omitempty"`
+	CoreDumped bool      `json:"coreDumped,omitempty"`
+	StartedAt  time.Time `json:"startedAt"`
+	ExitedAt   time.Time `json:"exitedAt"`
+}
+
+func runShim(ctx cli.Context) error {
+	// The cli package exits as soon as it receives SIGTERM or SIGINT, so its handling of them is reset so that they are
+	// forwarded instead.
+	signal.Reset(syscall.SIGTERM, syscall.SIGINT)
+	signals := make(chan os.Signal, len(shimForwardedSignals))
+	signal.Notify(signals, shimForwardedSignals...)
+
+	var command shimCommand
+	if err := json.NewDecoder(os.Stdin).Decode(&command); err != nil {
+		return cli.WithExitCode(1, errors.Wrap(err, "failed to read command to start"))
+	}
+	cmd := exec.Command(command.Path)
+	cmd.Args = command.Args
+	cmd.Env = command.Env
+	cmd.Dir = command.Dir
+	cmd.Stdout = ctx.App.Stdout
+	cmd.Stderr = ctx.App.Stderr
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		return cli.WithExitCode(1, errors.Wrapf(err, "failed to start '%s'", command.Path))
+	}
+	startedAt := Clock.Now()
+	pidFile := os.NewFile(shimPidFd, "pid")
+	_, _ = fmt.Fprintf(pidFile, "%d\n", cmd.Process.Pid)
+	_ = pidFile.Close()
+
+	go func() {
+		for sig := range signals {
+			_ = cmd.Process.Signal(sig)
+		}
+	}()
+	if err := cmd.Wait(); err != nil {
+		if _, ok := err.(*exec.ExitError); !ok {
+			return cli.WithExitCode(1, errors.Wrapf(err, "failed to wait for '%s'", command.Path))
+		}
+	}
+	signal.Stop(signals)
+
+	record := newExitRecord(cmd.ProcessState, startedAt, Clock.Now())
+	if err := writeExitRecord(ctx.String(exitFileFlagName), record); err != nil {
+		_, _ = fmt.Fprintln(ctx.App.Stderr, err)
+	}
+	if eventLog := ctx.String(eventLogFlagName); eventLog != "" {
+		recordEvent(ctx, eventLog, record.event(ctx.String(nameFlagName)))
+	}
+	if code := record.shimExitCode(); code != 0 {
+		// An empty error is not printed, so the exit code is reported without adding to the output of the process.
+		return cli.WithExitCode(code, errors.New(""))
+	}
+	return nil
+}
+
+// startShim starts the given command through a go-init shim that writes its exit record to its exit file and records
+// its exit in its event log once it exits, and returns the pid of the command along with the started shim. The shim is
+// detached from go-init in the same way as the command would be, and its output goes to the output of the command.
+func startShim(name string, cmdCtx CommandContext) (int, *exec.Cmd, error) {
+	exitFile, err := filepath.Abs(cmdCtx.ExitFile)
+	if err != nil {
+		return 0, nil, errors.Wrapf(err, "failed to resolve exit file '%s'", cmdCtx.ExitFile)
+	}
+	eventLog, err := filepath.Abs(cmdCtx.EventLogFile)
+	if err != nil {
+		return 0, nil, errors.Wrapf(err, "failed to resolve event log '%s'", cmdCtx.EventLogFile)
+	}
+	commandBytes, err := json.Marshal(shimCommand{
+		Path: cmdCtx.Command.Path,
+		Args: cmdCtx.Command.Args,
+		Env:  cmdCtx.Command.Env,
+		Dir:  cmdCtx.Command.Dir,
+	})
+	if err != nil {
+		return 0, nil, errors.Wrap(err, "failed to serialize command")
+	}
+	pidReader, pidWriter, err := os.Pipe()
+	if err != nil {
+		return 0, nil, errors.Wrap(err, "failed to create pipe for pid")
+	}
+	defer func() {
+		_ = pidReader.Close()
+	}()
+
+	shim := SelfCommand(shimCliCommand.Name, "--"+exitFileFlagName, exitFile, "--"+nameFlagName, name,
+		"--"+eventLogFlagName, eventLog)
+	shim.Stdin = bytes.NewReader(commandBytes)
+	shim.Stdout = cmdCtx.Command.Stdout
+	shim.Stderr = cmdCtx.Command.Stderr
+	shim.ExtraFiles = []*os.File{pidWriter}
+	shim.SysProcAttr = cmdCtx.Command.SysProcAttr
+	err = shim.Start()
+	_ = pidWriter.Close()
+	if err != nil {
+		return 0, nil, errors.Wrap(err, "failed to start shim")
+	}
+
+	// The shim closes the pipe once it has written the pid, or exits without writing it if the command failed to start.
+	line, _ := bufio.NewReader(pidReader).ReadString('\n')
+	pid, err := strconv.Atoi(strings.TrimSpace(line))
+	if err != nil {
+		return 0, nil, errors.Errorf("shim failed to start command: %v", shim.Wait())
+	}
+	return pid, shim, nil
+}
+
+// waitForShim waits for the given shim to exit, and returns how the command it started as the process with the given
+// pid exited as per its exit record, or how the shim exited if it did not write one.
+func waitForShim(shim *exec.Cmd, exitFile string, pid int) error {
+	shimErr := shim.Wait()
+	if record, err := readExitRecord(exitFile); err == nil && record != nil && record.Pid == pid {
+		return record.err()
+	}
+	return shimErr
+}
+
+// newExitRecord returns the record of the process that exited with the given state.
+func newExitRecord(state *os.ProcessState, startedAt, exitedAt time.Time) exitRecord {
+	record := exitRecord{
+		Pid:       state.Pid(),
+		ExitCode:  state.ExitCode(),
+		StartedAt: startedAt,
+		ExitedAt:  exitedAt,
+	}
+	if status, ok := state.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		record.Signal = int(status.Signal())
+		record.CoreDumped = status.CoreDump()
+	}
+	return record
+}
+
+// err returns nil if the process exited with code 0, and otherwise an error describing how it exited, worded as the
+// errors returned when waiting for a command.
+func (r exitRecord) err() error {
+	if r.Signal != 0 {
+		if r.CoreDumped {
+			return errors.Errorf("signal: %s (core dumped)", syscall.Signal(r.Signal))
+		}
+		return errors.Errorf("signal: %s", syscall.Signal(r.Signal))
+	}
+	if r.ExitCode != 0 {
+		return errors.Errorf("exit status %d", r.ExitCode)
+	}
+	return nil
+}
+
+// reason describes how and when the process exited, as reported by status.
+func (r exitRecord) reason() string {
+	exitedAt := r.ExitedAt.UTC().Format(time.RFC3339)
+	if r.Signal != 0 {
+		coreDumped := ""
+		if r.CoreDumped {
+			coreDumped = " (core dumped)"
+		}
+		return fmt.Sprintf("killed by signal %d (%s)%s at %s", r.Signal, syscall.Signal(r.Signal), coreDumped,
+			exitedAt)
+	}
+	return fmt.Sprintf("exited with code %d at %s", r.ExitCode, exitedAt)
+}
+
+// event returns the event of the process with the given name having exited as per the record.
+func (r exitRecord) event(name string) launchlib.Event {
+	event := launchlib.Event{
+		Time:    r.ExitedAt,
+		Type:    launchlib.EventExitObserved,
+		Process: name,
+		Pid:     r.Pid,
+		Signal:  r.Signal,
+		Message: r.reason(),
+	}
+	if r.Signal == 0 {
+		exitCode := r.ExitCode
+		event.ExitCode = &exitCode
+	}
+	return event
+}
+
+// shimExitCode is the exit code of the shim, which reports a process terminated by a signal as a shell would.
+func (r exitRecord) shimExitCode() int {
+	if r.Signal != 0 {
+		return 128 + r.Signal
+	}
+	return r.ExitCode
+}
+
+// writeExitRecord writes the given record to the given exit file, replacing it atomically so that a partially written
+// record is never read.
+func writeExitRecord(exitFile string, record exitRecord) error {
+	if err := os.MkdirAll(filepath.Dir(exitFile), 0755); err != nil {
+		return errors.Wrap(err, "unable to create exit file directory")
+	}
+	recordBytes, err := json.Marshal(record)
+	if err != nil {
+		return errors.Wrap(err, "failed to serialize exit record")
+	}
+	tmpFile := exitFile + ".tmp"
+	if err := ioutil.WriteFile(tmpFile, recordBytes, 0644); err != nil {
+		return errors.Wrapf(err, "failed to write exit record to '%s'", tmpFile)
+	}
+	if err := os.Rename(tmpFile, exitFile); err != nil {
+		return errors.Wrapf(err, "failed to write exit record to '%s'", exitFile)
+	}
+	return nil
+}
+
+// readExitRecord returns the record in the given exit file, or nil if there is none.
+func readExitRecord(exitFile string) (*exitRecord, error) {
+	recordBytes, err := ioutil.ReadFile(exitFile)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read exit file")
+	}
+	var record exitRecord
+	if err := json.Unmarshal(recordBytes, &record); err != nil {
+		return nil, errors.Wrapf(err, "exit file '%s' did not contain an exit record", exitFile)
+	}
+	return &record, nil
+}
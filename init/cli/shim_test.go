@@ -0,0 +1,119 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewExitRecord(t *testing.T) {
+	startedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	exitedAt := startedAt.Add(time.Minute)
+	for _, tc := range []struct {
+		name           string
+		script         string
+		expectedCode   int
+		expectedSignal int
+		expectedErr    string
+		expectedReason string
+	}{
+		{
+			name:           "exit code",
+			script:         "exit 3",
+			expectedCode:   3,
+			expectedErr:    "exit status 3",
+			expectedReason: "exited with code 3 at 2026-01-02T03:05:05Z",
+		},
+		{
+			name:           "signal",
+			script:         "kill -TERM $$",
+			expectedCode:   -1,
+			expectedSignal: int(syscall.SIGTERM),
+			expectedErr:    "signal: terminated",
+			expectedReason: "killed by signal 15 (terminated) at 2026-01-02T03:05:05Z",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd := exec.Command("/bin/sh", "-c", tc.script)
+			waitErr := cmd.Run()
+			require.Error(t, waitErr)
+
+			record := newExitRecord(cmd.ProcessState, startedAt, exitedAt)
+			assert.Equal(t, cmd.ProcessState.Pid(), record.Pid)
+			assert.Equal(t, tc.expectedCode, record.ExitCode)
+			assert.Equal(t, tc.expectedSignal, record.Signal)
+			assert.EqualError(t, record.err(), waitErr.Error())
+			assert.EqualError(t, record.err(), tc.expectedErr)
+			assert.Equal(t, tc.expectedReason, record.reason())
+		})
+	}
+}
+
+func TestExitRecord_CleanExit(t *testing.T) {
+	record := exitRecord{Pid: 1234}
+	assert.NoError(t, record.err())
+	assert.Equal(t, 0, record.shimExitCode())
+}
+
+func TestExitRecord_CoreDumped(t *testing.T) {
+	record := exitRecord{Pid: 1234, ExitCode: -1, Signal: int(syscall.SIGSEGV), CoreDumped: true,
+		ExitedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)}
+	assert.EqualError(t, record.err(), "signal: segmentation fault (core dumped)")
+	assert.Equal(t, "killed by signal 11 (segmentation fault) (core dumped) at 2026-01-02T03:04:05Z", record.reason())
+	assert.Equal(t, 139, record.shimExitCode())
+}
+
+func TestExitRecord_Event(t *testing.T) {
+	exitedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	exitCode := 3
+	assert.Equal(t, launchlib.Event{
+		Time:     exitedAt,
+		Type:     launchlib.EventExitObserved,
+		Process:  "primary",
+		Pid:      1234,
+		ExitCode: &exitCode,
+		Message:  "exited with code 3 at 2026-01-02T03:04:05Z",
+	}, exitRecord{Pid: 1234, ExitCode: 3, ExitedAt: exitedAt}.event("primary"))
+	assert.Equal(t, launchlib.Event{
+		Time:    exitedAt,
+		Type:    launchlib.EventExitObserved,
+		Process: "primary",
+		Pid:     1234,
+		Signal:  int(syscall.SIGKILL),
+		Message: "killed by signal 9 (killed) at 2026-01-02T03:04:05Z",
+	}, exitRecord{Pid: 1234, ExitCode: -1, Signal: int(syscall.SIGKILL), ExitedAt: exitedAt}.event("primary"))
+}
+
+func TestWriteExitRecord(t *testing.T) {
+	exitFile := filepath.Join(t.TempDir(), "run", "primary.exit")
+	record, err := readExitRecord(exitFile)
+	require.NoError(t, err)
+	assert.Nil(t, record)
+
+	written := exitRecord{Pid: 1234, ExitCode: 1, StartedAt: time.Unix(1, 0).UTC(), ExitedAt: time.Unix(2, 0).UTC()}
+	require.NoError(t, writeExitRecord(exitFile, written))
+	assert.NoFileExists(t, exitFile+".tmp")
+	record, err = readExitRecord(exitFile)
+	require.NoError(t, err)
+	assert.Equal(t, &written, record)
+}
@@ -16,24 +16,46 @@ package cli
 
 import (
 	"fmt"
-	"io/ioutil"
+	"io"
 	"os"
-	"path/filepath"
-	"strconv"
+	"sort"
+	"syscall"
+	"time"
 
 	"github.com/palantir/go-java-launcher/launchlib"
 	"github.com/palantir/pkg/cli"
+	"github.com/palantir/pkg/cli/flag"
 	"github.com/pkg/errors"
 )
 
+const startFailureLogLines = 20
+
 var startCliCommand = cli.Command{
 	Name: "start",
 	Usage: `
 Ensures the service defined by the static and custom configurations at service/bin/launcher-static.yml and
 var/conf/launcher-custom.yml is running and its outputs are redirecting to var/log/startup.log and other
-var/log/${SUB_PROCESS}-startup.log files. If successful, exits 0, otherwise exits 1 and writes an error message to
-stderr and var/log/startup.log.`,
-	Action: executeWithLoggers(start, NewTruncatingFirst()),
+var/log/${SUB_PROCESS}-startup.log files. If the primary process is to be started, first runs each of the initTasks in
+order, writing its output to var/log/${INIT_TASK}-startup.log, and does not start the service if any of them fails or
+does not finish within its timeout, 10 minutes by default. Waits for each process to keep running for its
+startupConfirmationPeriod, 1 second by default, and for its readiness check, if configured, to pass. Starting is
+all-or-nothing: if any process fails to start, exits during this time or does not become ready, the processes started
+by this invocation are stopped and their pidfiles removed. If successful, exits 0, otherwise exits 1 and writes an
+error message, including the last lines of the log of the failed process, to stderr and var/log/startup.log.
+If a process with crashLoop configured has exited by itself, rather than being stopped by go-init, maxCrashes times
+within the crashLoop window, refuses to start the service and exits 151 until enough of those exits are older than the
+window, unless --force is given.
+With --dry-run, instead prints the command of each process and initTask, whether or not it is running, as it would be
+run, including its environment variables, working directory, cgroups and how its heap is sized, as JSON or, with
+--format shell, as shell commands, and starts nothing.
+Exits 150 if another go-init command holds the lock for longer than --lock-timeout.`,
+	Flags:  []flag.Flag{forceFlag, dryRunFlag, dryRunFormatFlag},
+	Action: executeUnlessDryRun(executeWithLock(executeWithLoggers(start, NewTruncatingFirst()))),
+}
+
+var forceFlag = flag.BoolFlag{
+	Name:  forceFlagName,
+	Usage: "Starts processes even if they are crash looping",
 }
 
 func start(ctx cli.Context, loggers launchlib.ServiceLoggers) error {
@@ -42,38 +64,229 @@ func start(ctx cli.Context, loggers launchlib.ServiceLoggers) error {
 		return logErrorAndReturnWithExitCode(ctx,
 			errors.Wrap(err, "failed to determine service status to determine what commands to run"), 1)
 	}
-	if err := startService(ctx, serviceStatus.notRunningCmds); err != nil {
+	if !ctx.Bool(forceFlagName) {
+		if err := checkCrashLoops(ctx, serviceStatus.notRunningCmds); err != nil {
+			return logErrorAndReturnWithExitCode(ctx, err, crashLoopExitCode)
+		}
+	}
+	started, err := startService(ctx, serviceStatus.notRunningCmds, true)
+	if err != nil {
 		return logErrorAndReturnWithExitCode(ctx, errors.Wrap(err, "failed to start service"), 1)
 	}
+
+	// go-init exits once the service has started, so systemd is told to track the primary process instead.
+	states := []string{launchlib.SdNotifyReady}
+	if name, ok := primaryCommandName(serviceStatus.configuredCmds); ok {
+		proc, ok := started.procs[name]
+		if !ok {
+			proc, ok = serviceStatus.runningProcs[name]
+		}
+		if ok {
+			states = append([]string{launchlib.SdNotifyMainPid(proc.Pid)}, states...)
+		}
+	}
+	notifySystemd(ctx, states...)
 	return nil
 }
 
-func startService(ctx cli.Context, notRunningCmds map[string]CommandContext) error {
-	for name, cmd := range notRunningCmds {
-		if err := startCommand(ctx, cmd); err != nil {
-			return errors.Wrapf(err, "failed to start command '%s'", name)
+// commandExit is the result of waiting for a started command to exit.
+type commandExit struct {
+	name string
+	err  error
+}
+
+// startedService is the processes started by startService.
+type startedService struct {
+	procs map[string]*trackedProcess
+	// exited receives the exit of each process.
+	exited <-chan commandExit
+}
+
+// startService starts the given commands and confirms that they have started as per their start configuration. Starting
+// is all-or-nothing: if any command fails to start, exits within its startup confirmation period or does not become
+// ready in time, the commands started by this invocation are stopped and their pidfiles removed. Commands are started
+// through shims if go-init will not be running to wait for them to exit.
+func startService(ctx cli.Context, notRunningCmds map[string]CommandContext, throughShims bool) (*startedService,
+	error) {
+	if err := runInitTasks(ctx, notRunningCmds); err != nil {
+		return nil, err
+	}
+
+	started := make(map[string]CommandContext, len(notRunningCmds))
+	startedProcs := make(map[string]*trackedProcess, len(notRunningCmds))
+	waits := make(map[string]func() error, len(notRunningCmds))
+	names := commandNames(notRunningCmds)
+	sort.Strings(names)
+	for _, name := range names {
+		cmd := notRunningCmds[name]
+		recordEvent(ctx, cmd.EventLogFile, launchlib.Event{Type: launchlib.EventStartRequested, Process: name})
+		proc, wait, err := startCommand(ctx, name, cmd, throughShims)
+		if err != nil {
+			return nil, rollbackStart(ctx, started, startedProcs, startFailure(name, cmd, errors.Wrapf(err,
+				"failed to start command '%s'", name)))
 		}
+		recordEvent(ctx, cmd.EventLogFile, launchlib.Event{
+			Type:        launchlib.EventProcessStarted,
+			Process:     name,
+			Pid:         proc.Pid,
+			CommandHash: cmd.ConfigHash,
+		})
+		updateHistory(ctx, name, cmd, func(history *startHistory) bool {
+			history.recordStart(proc.Pid, Clock.Now(), historyLength(cmd))
+			return true
+		})
+		record := newPidfileRecord(cmd, proc.Pid)
+		started[name] = cmd
+		startedProcs[name] = &trackedProcess{Process: proc, record: record}
+		waits[name] = wait
+		if err := writePidfile(name, cmd.Pidfile, record); err != nil {
+			return nil, rollbackStart(ctx, started, startedProcs, err)
+		}
+	}
+
+	// Buffered so that commands exiting when nothing is waiting for them, e.g. after go-init start has confirmed that
+	// they started, do not block the goroutines waiting on them.
+	exited := make(chan commandExit, len(started))
+	for name, wait := range waits {
+		go func(name string, wait func() error) {
+			exited <- commandExit{name: name, err: wait()}
+		}(name, wait)
+	}
+	if name, err := confirmStarted(ctx, started, exited); err != nil {
+		return nil, rollbackStart(ctx, started, startedProcs, startFailure(name, started[name], err))
+	}
+	return &startedService{procs: startedProcs, exited: exited}, nil
+}
 
-		pidfile := fmt.Sprintf(pidfileFormat, name)
-		if err := os.MkdirAll(filepath.Dir(pidfile), 0755); err != nil {
-			return errors.Wrapf(err, "unable to create pidfile directory.")
+// confirmStarted waits for each of the given started commands to keep running for its startup confirmation period
+// and, if it has a readiness check, to become ready within its readiness timeout. It returns the name of the first
+// command to exit or to not become ready in time, along with an error describing how it failed.
+func confirmStarted(ctx cli.Context, started map[string]CommandContext, exited <-chan commandExit) (string, error) {
+	if len(started) == 0 {
+		return "", nil
+	}
+
+	deadlines := make(map[time.Duration]struct{})
+	unconfirmed := make(map[string]struct{})
+	unready := make(map[string]*launchlib.ReadinessConfig)
+	for name, cmd := range started {
+		unconfirmed[name] = struct{}{}
+		deadlines[cmd.Start.ConfirmationPeriod] = struct{}{}
+		if cmd.Start.Readiness != nil {
+			unready[name] = cmd.Start.Readiness
+			deadlines[cmd.Start.Readiness.Timeout] = struct{}{}
 		}
+	}
+	expired, stopTimers := newDeadlineTimers(deadlines)
+	defer stopTimers()
+	var readinessTicks <-chan time.Time
+	if len(unready) > 0 {
+		ticker := Clock.NewTicker(launchlib.ReadinessPollInterval)
+		defer ticker.Stop()
+		readinessTicks = ticker.Chan()
+	}
 
-		if err := ioutil.WriteFile(pidfile, []byte(strconv.Itoa(cmd.Command.Process.Pid)), 0644); err != nil {
-			return errors.Wrapf(err, "failed to save pid to file for command '%s'", name)
+	for len(unconfirmed) > 0 || len(unready) > 0 {
+		select {
+		case e := <-exited:
+			if e.err == nil {
+				e.err = errors.New("exit status 0")
+			}
+			if _, ok := unconfirmed[e.name]; !ok {
+				return e.name, errors.Errorf("command '%s' exited before the service became ready: %v", e.name,
+					e.err)
+			}
+			return e.name, errors.Errorf("command '%s' exited within %s of starting: %v", e.name,
+				started[e.name].Start.ConfirmationPeriod, e.err)
+		case <-readinessTicks:
+			for name, readiness := range unready {
+				if launchlib.IsReady(readiness) {
+					_, _ = fmt.Fprintf(ctx.App.Stdout, "Command '%s' is ready\n", name)
+					delete(unready, name)
+				}
+			}
+		case deadline := <-expired:
+			// Timers may fire in any order, so every shorter deadline has also passed.
+			for name := range unconfirmed {
+				if started[name].Start.ConfirmationPeriod <= deadline {
+					delete(unconfirmed, name)
+				}
+			}
+			for name, readiness := range unready {
+				if readiness.Timeout > deadline {
+					continue
+				}
+				if !launchlib.IsReady(readiness) {
+					return name, errors.Errorf("command '%s' did not become ready within %s waiting for %s", name,
+						readiness.Timeout, launchlib.DescribeReadiness(readiness))
+				}
+				_, _ = fmt.Fprintf(ctx.App.Stdout, "Command '%s' is ready\n", name)
+				delete(unready, name)
+			}
 		}
 	}
-	return nil
+	return "", nil
 }
 
-func startCommand(ctx cli.Context, cmdCtx CommandContext) error {
-	if err := launchlib.MkDirs(cmdCtx.Dirs, ctx.App.Stdout); err != nil {
-		return errors.Wrap(err, "failed to create directories")
+// startFailure adds the tail of the output of the given command to the given error describing its failure to start.
+func startFailure(name string, cmd CommandContext, err error) error {
+	tail, tailErr := tailLines(cmd.OutputFile, startFailureLogLines)
+	if tailErr != nil {
+		return errors.Wrapf(err, "failed to start '%s' process (unable to read its log: %v)", name, tailErr)
 	}
+	return errors.Errorf("%v\nLast lines of the log of the '%s' process at %s:\n%s", err, name, cmd.OutputFile,
+		tail)
+}
 
-	logger, err := cmdCtx.Logger()
+// rollbackStart stops the given commands started by this invocation and removes their pidfiles, and returns the given
+// error describing why the start failed.
+func rollbackStart(ctx cli.Context, started map[string]CommandContext, procs map[string]*trackedProcess,
+	startErr error) error {
+	if len(started) == 0 {
+		return startErr
+	}
+	names := commandNames(started)
+	sort.Strings(names)
+	_, _ = fmt.Fprintf(ctx.App.Stdout, "Failed to start the service, so stopping commands '%v' started by this "+
+		"invocation\n", names)
+
+	// Processes that failed to start are not expected to have started serving, so are not drained by preStop actions.
+	if err := stopProcesses(ctx, procs, started); err != nil {
+		return errors.Wrapf(startErr, "failed to roll back start (%v)", err)
+	}
+	if err := removePidfiles(ctx, started); err != nil {
+		return errors.Wrapf(startErr, "failed to roll back start (%v)", err)
+	}
+	return startErr
+}
+
+// startCommand starts the given command, either directly or through a shim, and returns its process along with a
+// function that waits for it to exit and returns how it exited. Either way, its exit record is written and its exit is
+// recorded in the event log once it exits.
+func startCommand(ctx cli.Context, name string, cmdCtx CommandContext, throughShim bool) (*os.Process, func() error,
+	error) {
+	if err := launchlib.MkDirsInDir(cmdCtx.Command.Dir, cmdCtx.Dirs, ctx.App.Stdout); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to create directories")
+	}
+	if err := launchlib.RemoveReadinessFile(cmdCtx.Start.Readiness); err != nil {
+		return nil, nil, err
+	}
+
+	if cmdCtx.Start.Umask != nil {
+		// The umask is process-wide, so it is set only for as long as it takes to fork the command and its log-writer.
+		previousUmask := syscall.Umask(*cmdCtx.Start.Umask)
+		defer syscall.Umask(previousUmask)
+	}
+
+	var logger io.WriteCloser
+	var err error
+	if cmdCtx.LogRotation != nil {
+		logger, err = startLogWriter(cmdCtx)
+	} else {
+		logger, err = cmdCtx.Logger()
+	}
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 	defer func() {
 		if cErr := logger.Close(); cErr != nil {
@@ -82,8 +295,42 @@ func startCommand(ctx cli.Context, cmdCtx CommandContext) error {
 	}()
 	cmdCtx.Command.Stdout = logger
 	cmdCtx.Command.Stderr = logger
+	daemonize(cmdCtx)
+
+	if throughShim {
+		pid, shim, err := startShim(name, cmdCtx)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "failed to start command")
+		}
+		// Docs say FindProcess always succeeds on Unix.
+		proc, _ := os.FindProcess(pid)
+		return proc, func() error {
+			return waitForShim(shim, cmdCtx.ExitFile, pid)
+		}, nil
+	}
+
 	if err := cmdCtx.Command.Start(); err != nil {
-		return errors.Wrap(err, "failed to start command")
+		return nil, nil, errors.Wrap(err, "failed to start command")
 	}
-	return nil
+	startedAt := Clock.Now()
+	return cmdCtx.Command.Process, func() error {
+		err := cmdCtx.Command.Wait()
+		if state := cmdCtx.Command.ProcessState; state != nil {
+			record := newExitRecord(state, startedAt, Clock.Now())
+			if wErr := writeExitRecord(cmdCtx.ExitFile, record); wErr != nil {
+				_, _ = fmt.Fprintln(ctx.App.Stdout, wErr)
+			}
+			recordEvent(ctx, cmdCtx.EventLogFile, record.event(name))
+		}
+		return err
+	}, nil
+}
+
+// daemonize detaches the given command from go-init so that it is not affected by what happens to the session go-init
+// is run from, e.g. a hangup or Ctrl-C in the terminal of an operator. The command is started in a new session, and so
+// without a controlling terminal and in its own process group, and with its stdin left unset so that it reads from
+// /dev/null. Its working directory is the absolute path of the service root, as set when the command is compiled.
+func daemonize(cmdCtx CommandContext) {
+	cmdCtx.Command.Stdin = nil
+	cmdCtx.Command.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
 }
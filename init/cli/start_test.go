@@ -23,5 +23,19 @@ import (
 
 // To prevent accidental changes to parameter default values
 func TestInitStart_DefaultParameters(t *testing.T) {
-	assert.Equal(t, []flag.Flag(nil), startCliCommand.Flags)
+	assert.Equal(t, []flag.Flag{
+		flag.BoolFlag{
+			Name:  "force",
+			Usage: "Starts processes even if they are crash looping",
+		},
+		flag.BoolFlag{
+			Name:  "dry-run",
+			Usage: "Prints the commands that would be run rather than starting anything",
+		},
+		flag.StringFlag{
+			Name:  "format",
+			Value: "json",
+			Usage: "Prints the commands of a dry run as 'json' or as 'shell' commands",
+		},
+	}, startCliCommand.Flags)
 }
@@ -15,11 +15,19 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/palantir/go-java-launcher/launchlib"
 	"github.com/palantir/pkg/cli"
+	"github.com/palantir/pkg/cli/flag"
 	"github.com/pkg/errors"
 )
 
@@ -27,17 +35,49 @@ var statusCliCommand = cli.Command{
 	Name: "status",
 	Usage: `
 Determines the status of the service defined by the static and custom configurations at service/bin/launcher-static.yml
-and var/conf/launcher-custom.yml.
+and var/conf/launcher-custom.yml. With --format, prints the pid, uptime, resident set size, CPU time, pidfile, last exit
+reason and configuration hash of each process as JSON or as a table.
+If a process is running with arguments or environment variables other than those it is now configured with, e.g. after
+var/conf/launcher-custom.yml has been edited, reports that a restart is required, and with --verbose lists the arguments
+that differ.
 Exits:
-- 0 if all of its processes are running
+- 0 if all of its processes are running, even if a restart is required
 - 1 if at least one process is not running but there is a record of processes having been started
 - 3 if no processes are running and there is no record of processes having been started
 - 4 if the status cannot be determined
 If exit code is nonzero, writes an error message to stderr and var/log/startup.log.`,
+	Flags:  []flag.Flag{statusFormatFlag, statusVerboseFlag},
 	Action: executeWithLoggers(status, NewAlwaysAppending()),
 }
 
+const (
+	verboseFlagName = "verbose"
+	formatFlagName  = "format"
+	jsonFormat      = "json"
+	tableFormat     = "table"
+)
+
+var statusFormatFlag = flag.StringFlag{
+	Name: formatFlagName,
+	Usage: "Prints the status of each process as 'json' or as a 'table' rather than only printing the status of the " +
+		"service",
+}
+
+var statusVerboseFlag = flag.BoolFlag{
+	Name:  verboseFlagName,
+	Usage: "Lists the arguments that differ between how each process requiring a restart was started and is configured",
+}
+
 var (
+	RestartRequired = ServiceState{
+		Description: "Running, restart required",
+		Applicable: func(serviceStatus *serviceStatus, err error) bool {
+			return err == nil && len(serviceStatus.notRunningCmds) == 0 && len(serviceStatus.restartRequiredCmds) > 0
+		},
+		ExitStatus: func(serviceStatus *serviceStatus, err error) (int, error) {
+			return 0, nil
+		},
+	}
 	Running = ServiceState{
 		Description: "Running",
 		Applicable: func(serviceStatus *serviceStatus, err error) bool {
@@ -54,7 +94,8 @@ var (
 		},
 		ExitStatus: func(serviceStatus *serviceStatus, err error) (int, error) {
 			return 1, errors.Errorf("commands '%v' are not running but there is a record of commands '%v' "+
-				"having been started", commandNames(serviceStatus.notRunningCmds), serviceStatus.writtenPids)
+				"having been started%s", commandNames(serviceStatus.notRunningCmds), serviceStatus.writtenPids,
+				describeExits(serviceStatus))
 		},
 	}
 	NotRunning = ServiceState{
@@ -81,10 +122,16 @@ var (
 )
 
 func status(ctx cli.Context, loggers launchlib.ServiceLoggers) error {
+	format := ctx.String(formatFlagName)
+	if format != "" && format != jsonFormat && format != tableFormat {
+		return logErrorAndReturnWithExitCode(ctx, errors.Errorf("invalid format '%s', must be '%s' or '%s'", format,
+			jsonFormat, tableFormat), 4)
+	}
+
 	// Executed with logging for errors, however we discard the verbose logging of getServiceStatus
 	serviceStatus, err := getServiceStatus(ctx, &DevNullLoggers{})
 	var matched *ServiceState
-	for _, state := range []ServiceState{ErrorState, NotRunning, Dead, Running} {
+	for _, state := range []ServiceState{ErrorState, NotRunning, Dead, RestartRequired, Running} {
 		if state.Applicable(serviceStatus, err) {
 			matched = &state
 			break
@@ -97,6 +144,15 @@ func status(ctx cli.Context, loggers launchlib.ServiceLoggers) error {
 	}
 
 	code, err := matched.ExitStatus(serviceStatus, err)
+	if format != "" {
+		if pErr := printStatusReport(os.Stdout, format, newStatusReport(matched.Description, serviceStatus)); pErr != nil {
+			return logErrorAndReturnWithExitCode(ctx, errors.Wrap(pErr, "failed to print status"), 4)
+		}
+		if code != 0 {
+			return logErrorAndReturnWithExitCode(ctx, err, code)
+		}
+		return nil
+	}
 	if code != 0 {
 		_, _ = fmt.Fprintf(os.Stderr, matched.Description)
 		if err != nil {
@@ -108,9 +164,76 @@ func status(ctx cli.Context, loggers launchlib.ServiceLoggers) error {
 	}
 
 	fmt.Println(matched.Description)
+	if ctx.Bool(verboseFlagName) {
+		printRestartRequired(os.Stdout, serviceStatus)
+	}
 	return nil
 }
 
+// printRestartRequired lists the arguments that differ between how each command requiring a restart was started and
+// how it is configured now.
+func printRestartRequired(w io.Writer, serviceStatus *serviceStatus) {
+	names := commandNames(serviceStatus.restartRequiredCmds)
+	sort.Strings(names)
+	for _, name := range names {
+		record := serviceStatus.pidfileRecords[name]
+		removed, added := diffArgs(record.Args, serviceStatus.restartRequiredCmds[name].Command.Args)
+		if len(removed) == 0 && len(added) == 0 {
+			_, _ = fmt.Fprintf(w, "Command '%s' was started with environment variables other than those it is now "+
+				"configured with\n", name)
+			continue
+		}
+		_, _ = fmt.Fprintf(w, "Command '%s' was started with arguments other than those it is now configured with\n",
+			name)
+		for _, arg := range removed {
+			_, _ = fmt.Fprintf(w, "  - %s\n", arg)
+		}
+		for _, arg := range added {
+			_, _ = fmt.Fprintf(w, "  + %s\n", arg)
+		}
+	}
+}
+
+// diffArgs returns the arguments that are only in the started arguments and those that are only in the configured
+// arguments, in the order they appear in. Arguments given more than once are compared by the number of times they are
+// given.
+func diffArgs(started, configured []string) (removed, added []string) {
+	counts := make(map[string]int)
+	for _, arg := range started {
+		counts[arg]++
+	}
+	for _, arg := range configured {
+		if counts[arg] > 0 {
+			counts[arg]--
+			continue
+		}
+		added = append(added, arg)
+	}
+	for _, arg := range started {
+		if counts[arg] > 0 {
+			counts[arg]--
+			removed = append(removed, arg)
+		}
+	}
+	return removed, added
+}
+
+// describeExits describes how each of the commands that are not running last exited, if that is known.
+func describeExits(serviceStatus *serviceStatus) string {
+	names := commandNames(serviceStatus.notRunningCmds)
+	sort.Strings(names)
+	var exits []string
+	for _, name := range names {
+		if exit, ok := serviceStatus.exitRecords[name]; ok {
+			exits = append(exits, fmt.Sprintf("command '%s' %s", name, exit.reason()))
+		}
+	}
+	if len(exits) == 0 {
+		return ""
+	}
+	return ": " + strings.Join(exits, ", ")
+}
+
 type ServiceState struct {
 	Description string
 	Applicable  func(serviceStatus *serviceStatus, err error) bool
@@ -124,3 +247,103 @@ func commandNames(commands map[string]CommandContext) []string {
 	}
 	return names
 }
+
+// statusReport is the status of the service and of each of its processes, as printed by status --format.
+type statusReport struct {
+	Status    string          `json:"status"`
+	Processes []processReport `json:"processes"`
+}
+
+type processReport struct {
+	Name    string `json:"name"`
+	Pid     int    `json:"pid,omitempty"`
+	Running bool   `json:"running"`
+	// RestartRequired is whether the process is running with a command other than the one it is now configured with.
+	RestartRequired bool    `json:"restartRequired,omitempty"`
+	UptimeSeconds   float64 `json:"uptimeSeconds,omitempty"`
+	RSSBytes        uint64  `json:"rssBytes,omitempty"`
+	CPUTimeSeconds  float64 `json:"cpuTimeSeconds,omitempty"`
+	Pidfile         string  `json:"pidfile"`
+	// LastExitReason describes how the process last exited, if that is known.
+	LastExitReason string `json:"lastExitReason,omitempty"`
+	// ConfigHash is the hash of the configuration the process was started with, as recorded in its pidfile.
+	ConfigHash string `json:"configHash,omitempty"`
+}
+
+// newStatusReport returns the report of the given status of the service, which is nil if the status could not be
+// determined. Resource usage that cannot be read from /proc, e.g. because the process has just exited, is omitted.
+func newStatusReport(description string, serviceStatus *serviceStatus) statusReport {
+	report := statusReport{Status: description, Processes: []processReport{}}
+	if serviceStatus == nil {
+		return report
+	}
+
+	uptime, uptimeErr := systemUptime()
+	names := commandNames(serviceStatus.configuredCmds)
+	sort.Strings(names)
+	for _, name := range names {
+		process := processReport{
+			Name:    name,
+			Pidfile: serviceStatus.configuredCmds[name].Pidfile,
+		}
+		if record, ok := serviceStatus.pidfileRecords[name]; ok {
+			process.Pid = record.Pid
+			process.ConfigHash = record.ConfigHash
+		}
+		if exit, ok := serviceStatus.exitRecords[name]; ok {
+			process.LastExitReason = exit.reason()
+		}
+		if _, ok := serviceStatus.runningProcs[name]; ok {
+			process.Running = true
+			_, process.RestartRequired = serviceStatus.restartRequiredCmds[name]
+			if stat, err := readProcessStat(process.Pid); err == nil {
+				if uptimeErr == nil {
+					process.UptimeSeconds = (uptime - clockTicksToDuration(stat.StartTime)).Seconds()
+				}
+				process.RSSBytes = stat.RSSPages * uint64(os.Getpagesize())
+				process.CPUTimeSeconds = clockTicksToDuration(stat.CPUTime).Seconds()
+			}
+		}
+		report.Processes = append(report.Processes, process)
+	}
+	return report
+}
+
+func printStatusReport(w io.Writer, format string, report statusReport) error {
+	if format == jsonFormat {
+		reportBytes, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(reportBytes))
+		return err
+	}
+
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(table, "NAME\tPID\tRUNNING\tUPTIME\tRSS\tCPU TIME\tPIDFILE\tLAST EXIT\tCONFIG HASH")
+	for _, process := range report.Processes {
+		pid, uptime, rss, cpuTime := "-", "-", "-", "-"
+		if process.Pid != 0 {
+			pid = strconv.Itoa(process.Pid)
+		}
+		if process.Running {
+			uptime = secondsToDuration(process.UptimeSeconds).Truncate(time.Second).String()
+			rss = fmt.Sprintf("%.1fMiB", float64(process.RSSBytes)/launchlib.BytesInMebibyte)
+			cpuTime = secondsToDuration(process.CPUTimeSeconds).Truncate(10 * time.Millisecond).String()
+		}
+		_, _ = fmt.Fprintf(table, "%s\t%s\t%t\t%s\t%s\t%s\t%s\t%s\t%s\n", process.Name, pid, process.Running,
+			uptime, rss, cpuTime, process.Pidfile, orDash(process.LastExitReason), orDash(process.ConfigHash))
+	}
+	return table.Flush()
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
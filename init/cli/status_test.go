@@ -15,13 +15,119 @@
 package cli
 
 import (
+	"bytes"
+	"encoding/json"
+	"os/exec"
 	"testing"
 
 	"github.com/palantir/pkg/cli/flag"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // To prevent accidental changes to parameter default values
 func TestInitStatus_DefaultParameters(t *testing.T) {
-	assert.Equal(t, []flag.Flag(nil), statusCliCommand.Flags)
+	assert.Equal(t, []flag.Flag{flag.StringFlag{
+		Name: "format",
+		Usage: "Prints the status of each process as 'json' or as a 'table' rather than only printing the status " +
+			"of the service",
+	}, flag.BoolFlag{
+		Name:  "verbose",
+		Usage: "Lists the arguments that differ between how each process requiring a restart was started and is configured",
+	}}, statusCliCommand.Flags)
+}
+
+func TestDiffArgs(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		started    []string
+		configured []string
+		removed    []string
+		added      []string
+	}{
+		{
+			name:       "same args",
+			started:    []string{"java", "-Xmx1g", "Main"},
+			configured: []string{"java", "-Xmx1g", "Main"},
+		},
+		{
+			name:       "changed arg",
+			started:    []string{"java", "-Xmx1g", "Main"},
+			configured: []string{"java", "-Xmx2g", "Main"},
+			removed:    []string{"-Xmx1g"},
+			added:      []string{"-Xmx2g"},
+		},
+		{
+			name:       "repeated arg",
+			started:    []string{"java", "-Dfoo", "-Dfoo", "Main"},
+			configured: []string{"java", "-Dfoo", "Main", "arg"},
+			removed:    []string{"-Dfoo"},
+			added:      []string{"arg"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			removed, added := diffArgs(tc.started, tc.configured)
+			assert.Equal(t, tc.removed, removed)
+			assert.Equal(t, tc.added, added)
+		})
+	}
+}
+
+func TestPrintRestartRequired(t *testing.T) {
+	var out bytes.Buffer
+	printRestartRequired(&out, &serviceStatus{
+		pidfileRecords: map[string]pidfileRecord{
+			"primary": {Pid: 1, Args: []string{"java", "-Xmx1g", "Main"}},
+			"sidecar": {Pid: 2, Args: []string{"sidecar"}},
+		},
+		restartRequiredCmds: map[string]CommandContext{
+			"primary": {Command: &exec.Cmd{Args: []string{"java", "-Xmx2g", "Main"}}},
+			"sidecar": {Command: &exec.Cmd{Args: []string{"sidecar"}}},
+		},
+	})
+	assert.Equal(t, ""+
+		"Command 'primary' was started with arguments other than those it is now configured with\n"+
+		"  - -Xmx1g\n"+
+		"  + -Xmx2g\n"+
+		"Command 'sidecar' was started with environment variables other than those it is now configured with\n",
+		out.String())
+}
+
+var testStatusReport = statusReport{
+	Status: "Running",
+	Processes: []processReport{
+		{
+			Name:           "primary",
+			Pid:            1234,
+			Running:        true,
+			UptimeSeconds:  90.5,
+			RSSBytes:       256 * 1024 * 1024,
+			CPUTimeSeconds: 1.234,
+			Pidfile:        "var/run/primary.pid",
+			ConfigHash:     "abc123",
+		},
+		{
+			Name:    "sidecar",
+			Pidfile: "var/run/sidecar.pid",
+		},
+	},
+}
+
+func TestPrintStatusReport_JSON(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, printStatusReport(&out, jsonFormat, testStatusReport))
+
+	var report statusReport
+	require.NoError(t, json.Unmarshal(out.Bytes(), &report))
+	assert.Equal(t, testStatusReport, report)
+	assert.NotContains(t, out.String(), "lastExitReason")
+}
+
+func TestPrintStatusReport_Table(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, printStatusReport(&out, tableFormat, testStatusReport))
+	assert.Equal(t, ""+
+		"NAME     PID   RUNNING  UPTIME  RSS       CPU TIME  PIDFILE              LAST EXIT  CONFIG HASH\n"+
+		"primary  1234  true     1m30s   256.0MiB  1.23s     var/run/primary.pid  -          abc123\n"+
+		"sidecar  -     false    -       -         -         var/run/sidecar.pid  -          -\n", out.String())
 }
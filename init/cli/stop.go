@@ -17,6 +17,8 @@ package cli
 import (
 	"fmt"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
@@ -24,6 +26,7 @@ import (
 	time2 "github.com/palantir/go-java-launcher/init/cli/time"
 	"github.com/palantir/go-java-launcher/launchlib"
 	"github.com/palantir/pkg/cli"
+	"github.com/palantir/pkg/cli/flag"
 	"github.com/pkg/errors"
 )
 
@@ -32,25 +35,50 @@ var (
 	Clock = time2.NewRealClock()
 )
 
+const timeoutFlagName = "timeout"
+
+var stopTimeoutFlag = flag.DurationFlag{
+	Name:  timeoutFlagName,
+	Value: "0",
+	Usage: "Overrides the stopTimeout configured for every process, unless 0",
+}
+
 var stopCliCommand = cli.Command{
 	Name: "stop",
 	Usage: `
 Ensures the service defined by the static and custom configurations are service/bin/launcher-static.yml and
 var/conf/launcher-custom.yml is not running. If successful, exits 0, otherwise exits 1 and writes an error message to
-stderr and var/log/startup.log. Waits for at least 240 seconds for any processes to stop before sending a SIGKILL.`,
-	Action: executeWithLoggers(stop, NewAlwaysAppending()),
+stderr and var/log/startup.log. Runs the preStop action configured for each process, if any, logging rather than
+failing if it fails or does not finish within its timeout. Then sends each process its configured stopSignal, SIGTERM
+by default, and waits for its configured stopTimeout, 240 seconds by default, for it to stop before sending a SIGKILL.
+Unless disabled, java processes are sent a SIGQUIT 5 seconds before the SIGKILL so that a thread dump is written to
+their log.
+Exits 150 if another go-init command holds the lock for longer than --lock-timeout.`,
+	Flags:  []flag.Flag{stopTimeoutFlag},
+	Action: executeWithLock(executeWithLoggers(stop, NewAlwaysAppending())),
 }
 
 func stop(ctx cli.Context, loggers launchlib.ServiceLoggers) error {
+	timeout := ctx.Duration(timeoutFlagName)
+	if timeout < 0 {
+		return logErrorAndReturnWithExitCode(ctx, errors.Errorf("timeout must not be negative, found %s", timeout), 2)
+	}
+
 	cmds, err := getConfiguredCommands(ctx, loggers)
 	if err != nil {
 		return logErrorAndReturnWithExitCode(ctx,
 			errors.Wrap(err, "failed to get commands from static and custom configuration files"), 1)
 	}
+	if timeout > 0 {
+		for name, cmd := range cmds {
+			cmd.Stop.Timeout = timeout
+			cmds[name] = cmd
+		}
+	}
 
-	runningProcs := map[string]*os.Process{}
+	runningProcs := map[string]*trackedProcess{}
 	for name := range cmds {
-		_, proc, err := getCmdProcess(name)
+		_, proc, err := getCmdProcess(cmds[name])
 		if err != nil {
 			return logErrorAndReturnWithExitCode(ctx, errors.Wrap(err, "failed to determine process status"), 1)
 		}
@@ -60,50 +88,87 @@ func stop(ctx cli.Context, loggers launchlib.ServiceLoggers) error {
 		}
 	}
 
-	if err := stopService(ctx, runningProcs); err != nil {
+	notifySystemd(ctx, launchlib.SdNotifyStopping)
+	if err := stopService(ctx, runningProcs, cmds); err != nil {
 		return logErrorAndReturnWithExitCode(ctx, errors.Wrap(err, "failed to stop service"), 1)
 	}
 
+	if err := removePidfiles(ctx, cmds); err != nil {
+		return logErrorAndReturnWithExitCode(ctx, err, 1)
+	}
+	return nil
+}
+
+func removePidfiles(ctx cli.Context, cmds map[string]CommandContext) error {
 	var errs bool
-	for name := range cmds {
-		if err := os.Remove(fmt.Sprintf(pidfileFormat, name)); err != nil && !os.IsNotExist(err) {
+	for name, cmd := range cmds {
+		if err := os.Remove(cmd.Pidfile); err != nil && !os.IsNotExist(err) {
 			_, _ = fmt.Fprintf(ctx.App.Stderr, "failed to remove stopped process pidfile for '%s'\n", name)
 			errs = true
 		}
 	}
 
 	if errs {
-		return logErrorAndReturnWithExitCode(ctx, errors.New("error removing stopped service pidfiles"), 1)
+		return errors.New("error removing stopped service pidfiles")
 	}
 	return nil
 }
 
-func stopService(ctx cli.Context, procs map[string]*os.Process) error {
+// stopService runs the preStop actions configured for the commands of the given processes, and then stops them.
+func stopService(ctx cli.Context, procs map[string]*trackedProcess, cmds map[string]CommandContext) error {
+	preStops := make(map[string]*launchlib.PreStopConfig)
+	var dir string
+	for name := range procs {
+		if preStop := cmds[name].Stop.PreStop; preStop != nil {
+			preStops[name] = preStop
+			// Every command of the service is run in its service root.
+			dir = cmds[name].Command.Dir
+		}
+	}
+	launchlib.RunPreStopActions(preStops, dir, ctx.App.Stdout)
+	return stopProcesses(ctx, procs, cmds)
+}
+
+// stopProcesses sends each of the given processes the stop signal configured for its command, recording in its history
+// that it was stopped, and waits for them to stop.
+func stopProcesses(ctx cli.Context, procs map[string]*trackedProcess, cmds map[string]CommandContext) error {
 	for name, proc := range procs {
-		if err := proc.Signal(syscall.SIGTERM); err != nil && !strings.Contains(err.Error(),
-			"os: process already finished") {
+		err := proc.Signal(cmds[name].Stop.Signal)
+		if err != nil && !strings.Contains(err.Error(), "os: process already finished") {
 			return errors.Wrapf(err, "failed to stop '%s' process", name)
 		}
+		// A process that had already exited by itself was not stopped by go-init.
+		if err == nil {
+			recordSignalSent(ctx, name, proc, cmds[name], cmds[name].Stop.Signal, "stop")
+			updateHistory(ctx, name, cmds[name], func(history *startHistory) bool {
+				return history.recordStopped(proc.Pid)
+			})
+		}
 	}
 
-	if err := waitForServiceToStop(ctx, procs); err != nil {
+	if err := waitForServiceToStop(ctx, procs, cmds); err != nil {
 		return errors.Wrap(err, "failed to stop at least one process")
 	}
 
 	return nil
 }
 
-func waitForServiceToStop(ctx cli.Context, procs map[string]*os.Process) error {
-	const numSecondsToWait = 240
-	timer := Clock.NewTimer(numSecondsToWait * time.Second)
-	defer timer.Stop()
+func waitForServiceToStop(ctx cli.Context, procs map[string]*trackedProcess, cmds map[string]CommandContext) error {
+	timeouts := make(map[time.Duration]struct{})
+	for name := range procs {
+		timeouts[cmds[name].Stop.Timeout] = struct{}{}
+	}
+	expired, stopTimers := newDeadlineTimers(timeouts)
+	defer stopTimers()
 
+	start := Clock.Now()
 	ticker := Clock.NewTicker(time.Second)
 	defer ticker.Stop()
 
+	dumpedProcs := make(map[string]struct{})
 	for {
 		select {
-		case <-ticker.Chan():
+		case now := <-ticker.Chan():
 			for name, remainingProc := range procs {
 				running, err := isProcRunning(remainingProc)
 				if err != nil {
@@ -116,9 +181,13 @@ func waitForServiceToStop(ctx cli.Context, procs map[string]*os.Process) error {
 			if len(procs) == 0 {
 				return nil
 			}
-		case <-timer.Chan():
+			sendThreadDumpSignals(ctx, procs, cmds, now.Sub(start), dumpedProcs)
+		case timeout := <-expired:
 			killedProcs := make([]string, 0, len(procs))
 			for name, remainingProc := range procs {
+				if cmds[name].Stop.Timeout != timeout {
+					continue
+				}
 				running, err := isProcRunning(remainingProc)
 				if err != nil {
 					return err
@@ -130,12 +199,68 @@ func waitForServiceToStop(ctx cli.Context, procs map[string]*os.Process) error {
 						return errors.Wrapf(err, "failed to kill process with pid %d",
 							remainingProc.Pid)
 					}
+					recordEvent(ctx, cmds[name].EventLogFile, launchlib.Event{
+						Type:    launchlib.EventKillEscalated,
+						Process: name,
+						Pid:     remainingProc.Pid,
+						Signal:  int(syscall.SIGKILL),
+						Message: fmt.Sprintf("did not stop within %s", timeout),
+					})
 					killedProcs = append(killedProcs, name)
 				}
+				delete(procs, name)
+			}
+			if len(killedProcs) > 0 {
+				sort.Strings(killedProcs)
+				_, _ = fmt.Fprintf(ctx.App.Stdout, "processes '%v' did not stop within %s seconds, so a SIGKILL was "+
+					"sent\n", killedProcs, strconv.FormatFloat(timeout.Seconds(), 'f', -1, 64))
+			}
+			if len(procs) == 0 {
+				return nil
 			}
-			_, _ = fmt.Fprintf(ctx.App.Stdout, "processes '%v' did not stop within %d seconds, so a SIGKILL was "+
-				"sent", killedProcs, numSecondsToWait)
-			return nil
 		}
 	}
 }
+
+// recordSignalSent records in the event log that the given signal was sent to the given process of the given command
+// for the given purpose, e.g. to stop it, if known.
+func recordSignalSent(ctx cli.Context, name string, proc *trackedProcess, cmd CommandContext, sig syscall.Signal,
+	purpose string) {
+	recordEvent(ctx, cmd.EventLogFile, launchlib.Event{
+		Type:    launchlib.EventSignalSent,
+		Process: name,
+		Pid:     proc.Pid,
+		Signal:  int(sig),
+		Message: purpose,
+	})
+}
+
+// sendThreadDumpSignals sends a SIGQUIT to each of the given processes that is due to have a thread dump captured
+// after the given time has elapsed since it was sent its stop signal, so that the JVM writes a thread dump to its log
+// before it is sent a SIGKILL. Processes are added to dumpedProcs so that they are only sent a SIGQUIT once.
+func sendThreadDumpSignals(ctx cli.Context, procs map[string]*trackedProcess, cmds map[string]CommandContext,
+	elapsed time.Duration, dumpedProcs map[string]struct{}) {
+	var signalledProcs []string
+	for name, proc := range procs {
+		if _, ok := dumpedProcs[name]; ok {
+			continue
+		}
+		dumpAfter, ok := cmds[name].Stop.ThreadDumpAfter()
+		if !ok || elapsed < dumpAfter {
+			continue
+		}
+		dumpedProcs[name] = struct{}{}
+		if err := proc.Signal(syscall.SIGQUIT); err != nil {
+			_, _ = fmt.Fprintf(ctx.App.Stdout, "failed to send SIGQUIT to '%s' process to capture a thread dump: %v\n",
+				name, err)
+			continue
+		}
+		recordSignalSent(ctx, name, proc, cmds[name], syscall.SIGQUIT, "thread dump")
+		signalledProcs = append(signalledProcs, name)
+	}
+	if len(signalledProcs) > 0 {
+		sort.Strings(signalledProcs)
+		_, _ = fmt.Fprintf(ctx.App.Stdout, "processes '%v' have not stopped, so a SIGQUIT was sent to capture a thread "+
+			"dump before sending a SIGKILL\n", signalledProcs)
+	}
+}
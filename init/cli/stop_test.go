@@ -23,5 +23,9 @@ import (
 
 // To prevent accidental changes to parameter default values
 func TestInitStop_DefaultParameters(t *testing.T) {
-	assert.Equal(t, []flag.Flag(nil), stopCliCommand.Flags)
+	assert.Equal(t, []flag.Flag{flag.DurationFlag{
+		Name:  "timeout",
+		Value: "0",
+		Usage: "Overrides the stopTimeout configured for every process, unless 0",
+	}}, stopCliCommand.Flags)
 }
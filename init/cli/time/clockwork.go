@@ -144,10 +144,11 @@ func (ft *fakeTimer) Stop() {
 		// Close the channel. There are weird concurrency issues if we close it before locking.
 		// The 'Advance' logic won't block sending to the channel, since we signalled ft.sleeper.stop.
 		close(ft.c)
-		// Remove the sleeper (if it exists) and notify any blockers
+		// Remove the sleeper (if it exists) and notify any blockers. Periodic sleepers are replaced each time they
+		// fire, so they are identified by their channel rather than by the sleeper itself.
 		newSleepers := make([]*sleeper, 0)
 		for _, s := range fc.sleepers {
-			if s != ft.sleeper {
+			if s.done != ft.c {
 				newSleepers = append(newSleepers, s)
 			}
 		}
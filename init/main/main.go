@@ -21,5 +21,5 @@ import (
 )
 
 func main() {
-	os.Exit(cli.App().Run(os.Args))
+	os.Exit(cli.Run(cli.App(), os.Args))
 }
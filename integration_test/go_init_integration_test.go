@@ -15,14 +15,21 @@
 package integration_test
 
 import (
+	"bufio"
 	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"flag"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
+	"net/http"
 	"os"
 	"os/exec"
 	"os/signal"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
@@ -44,6 +51,8 @@ const (
 	outputLogFile      = "startup.log"
 	pidfolder          = "var/run"
 	pidfileFormat      = pidfolder + "/%s.pid"
+	exitFileFormat     = pidfolder + "/%s.exit"
+	lockFile           = pidfolder + "/go-init.lock"
 )
 
 var staticSingle, _, _ = launchlib.GetConfigsFromFiles("testdata/launcher-static.yml", "testdata/launcher-custom.yml",
@@ -83,6 +92,12 @@ func setupMultiProcess(t *testing.T) {
 	require.NoError(t, os.Link("testdata/launcher-custom-multiprocess.yml", launcherCustomFile))
 }
 
+func setupWithConfigs(t *testing.T, staticFile, customFile string) {
+	setup(t)
+	require.NoError(t, os.Link(staticFile, launcherStaticFile))
+	require.NoError(t, os.Link(customFile, launcherCustomFile))
+}
+
 func setup(t *testing.T) {
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, syscall.SIGHUP, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGTERM)
@@ -93,6 +108,7 @@ func setup(t *testing.T) {
 	for _, file := range files {
 		require.NoError(t, os.MkdirAll(filepath.Dir(file), 0755))
 	}
+	cli2.SelfCommand = initHelperCommand
 }
 
 func teardown(t *testing.T) {
@@ -220,7 +236,7 @@ func TestInitStart_CreatesDirs(t *testing.T) {
 	pids := readPids(t)
 	require.Len(t, pids, 1)
 	// grep for testdata since it will be on the classpath
-	assert.Equal(t, pgrepSinglePid(t, "testdata", os.Getpid()), pids["primary"])
+	assert.Equal(t, pgrepStartedPid(t, "testdata"), pids["primary"])
 	proc, _ := os.FindProcess(pids["primary"])
 	require.NoError(t, proc.Signal(syscall.SIGKILL))
 }
@@ -240,514 +256,1921 @@ func TestInitStart_OneConfiguredZeroWrittenZeroRunning(t *testing.T) {
 	assert.Empty(t, result.stderr)
 	pids := readPids(t)
 	require.Len(t, pids, 1)
-	assert.Equal(t, pgrepSinglePid(t, "testdata", os.Getpid()), pids[singleProcessPrimaryName])
+	assert.Equal(t, pgrepStartedPid(t, "testdata"), pids[singleProcessPrimaryName])
+
+	record := readPidRecord(t, fmt.Sprintf(pidfileFormat, singleProcessPrimaryName))
+	assert.NotZero(t, record.StartTime)
+	assert.NotEmpty(t, record.Executable)
+	assert.NotEmpty(t, record.ConfigHash)
+	assert.Contains(t, record.Args, "-Xmx1g")
+	assert.Equal(t, 0, runInit(t, "status").exitCode)
 
 	proc, _ := os.FindProcess(pids[singleProcessPrimaryName])
 	require.NoError(t, proc.Signal(syscall.SIGKILL))
 }
 
-// (1, 1, 0)
-func TestInitStart_OneConfiguredOneWrittenZeroRunning(t *testing.T) {
-	setupSingleProcess(t)
+func TestInitStart_RollsBackWhenProcessExitsDuringConfirmation(t *testing.T) {
+	setupWithConfigs(t, "testdata/launcher-static-multiprocess-crashing-sidecar.yml",
+		"testdata/launcher-custom-multiprocess.yml")
 	defer teardown(t)
 
-	writePids(t, servicePids{singleProcessPrimaryName: 99999})
 	result := runInit(t, "start")
 
-	assert.Equal(t, 0, result.exitCode)
-	time.Sleep(time.Second)
-	startupLog := readStartupLog(t)
-	assert.Contains(t, startupLog, "Using JAVA_HOME")
-	assert.Contains(t, startupLog, "main method")
-	assert.Empty(t, result.stderr)
-	pids := readPids(t)
-	require.Len(t, pids, 1)
-	assert.Equal(t, pgrepSinglePid(t, "testdata", os.Getpid()), pids[singleProcessPrimaryName])
-
-	proc, _ := os.FindProcess(pids[singleProcessPrimaryName])
-	require.NoError(t, proc.Signal(syscall.SIGKILL))
+	assert.Equal(t, 1, result.exitCode)
+	assert.Contains(t, result.stderr, fmt.Sprintf("command '%s' exited within 1s of starting",
+		multiProcessSubProcessName))
+	assert.Contains(t, result.stderr, fmt.Sprintf("Last lines of the log of the '%s' process at %s",
+		multiProcessSubProcessName, subProcessOutputFile))
+	assert.Contains(t, result.stderr, "main method")
+	assert.Contains(t, result.startupLog, fmt.Sprintf("stopping commands '[%s %s]' started by this invocation",
+		multiProcessPrimaryName, multiProcessSubProcessName))
+	assert.Empty(t, readPids(t))
 }
 
-// (2, 0, 0)
-func TestInitStart_TwoConfiguredZeroWrittenZeroRunning(t *testing.T) {
-	setupMultiProcess(t)
+func TestInitStart_FailsForConflictingGarbageCollectorsInStrictMode(t *testing.T) {
+	setupWithConfigs(t, "testdata/launcher-static-strict-jvm-opts.yml", "testdata/launcher-custom-parallel-gc.yml")
 	defer teardown(t)
 
 	result := runInit(t, "start")
 
-	assert.Equal(t, 0, result.exitCode)
-	time.Sleep(time.Second)
-	startupLog := readStartupLog(t)
-	assert.Contains(t, startupLog, "Using JAVA_HOME")
-	assert.Contains(t, startupLog, "main method")
-	sidecarStartupLogBytes, err := ioutil.ReadFile(subProcessOutputFile)
-	require.NoError(t, err)
-	sidecarStartupLog := string(sidecarStartupLogBytes)
-	assert.Contains(t, sidecarStartupLog, "Using JAVA_HOME")
-	assert.Contains(t, sidecarStartupLog, "main method")
-	assert.Empty(t, result.stderr)
-	pids := readPids(t)
-	require.Len(t, pids, 2)
-	assertContainSameElements(t, pgrepMultiPids(t, "testdata", os.Getpid()),
-		[]int{pids[multiProcessPrimaryName], pids[multiProcessSubProcessName]})
-
-	proc, _ := os.FindProcess(pids[multiProcessPrimaryName])
-	require.NoError(t, proc.Signal(syscall.SIGKILL))
-	proc, _ = os.FindProcess(pids[multiProcessSubProcessName])
-	require.NoError(t, proc.Signal(syscall.SIGKILL))
+	assert.Equal(t, 1, result.exitCode)
+	assert.Contains(t, result.stderr, "jvmOpts would fail to start the JVM: conflicting garbage collectors are "+
+		"selected: -XX:+UseG1GC, -XX:+UseParallelGC")
+	assert.Empty(t, readPids(t))
 }
 
-// (2, 1, 0)
-func TestInitStart_TwoConfiguredOneWrittenZeroRunning(t *testing.T) {
-	setupMultiProcess(t)
+func TestInitStart_ExitsWithinConfiguredConfirmationPeriod(t *testing.T) {
+	setupWithConfigs(t, "testdata/launcher-static-confirmation-period.yml", "testdata/launcher-custom.yml")
 	defer teardown(t)
 
-	writePids(t, servicePids{multiProcessPrimaryName: 99999})
 	result := runInit(t, "start")
 
-	assert.Equal(t, 0, result.exitCode)
-	time.Sleep(time.Second)
-	startupLog := readStartupLog(t)
-	assert.Contains(t, startupLog, "Using JAVA_HOME")
-	assert.Contains(t, startupLog, "main method")
-	sidecarStartupLogBytes, err := ioutil.ReadFile(subProcessOutputFile)
-	require.NoError(t, err)
-	sidecarStartupLog := string(sidecarStartupLogBytes)
-	assert.Contains(t, sidecarStartupLog, "Using JAVA_HOME")
-	assert.Contains(t, sidecarStartupLog, "main method")
-	assert.Empty(t, result.stderr)
-	pids := readPids(t)
-	require.Len(t, pids, 2)
-	assertContainSameElements(t, pgrepMultiPids(t, "testdata", os.Getpid()),
-		[]int{pids[multiProcessPrimaryName], pids[multiProcessSubProcessName]})
-
-	proc, _ := os.FindProcess(pids[multiProcessPrimaryName])
-	require.NoError(t, proc.Signal(syscall.SIGKILL))
-	proc, _ = os.FindProcess(pids[multiProcessSubProcessName])
-	require.NoError(t, proc.Signal(syscall.SIGKILL))
+	assert.Equal(t, 1, result.exitCode)
+	assert.Contains(t, result.stderr, fmt.Sprintf("command '%s' exited within 3s of starting: exit status 0",
+		singleProcessPrimaryName))
+	assert.Contains(t, result.stderr, "main method")
+	assert.Empty(t, readPids(t))
 }
 
-// (2, 1, 1)
-func TestInitStart_TwoConfiguredOneWrittenOneRunning(t *testing.T) {
-	setupMultiProcess(t)
+func TestInitStart_WaitsForReadiness(t *testing.T) {
+	setupWithConfigs(t, "testdata/launcher-static-readiness-file.yml", "testdata/launcher-custom.yml")
 	defer teardown(t)
 
-	writePids(t, servicePids{multiProcessPrimaryName: os.Getpid()})
+	go func() {
+		time.Sleep(1500 * time.Millisecond)
+		_ = ioutil.WriteFile("var/ready", nil, 0644)
+	}()
 	result := runInit(t, "start")
 
 	assert.Equal(t, 0, result.exitCode)
-	time.Sleep(time.Second)
-	sidecarStartupLogBytes, err := ioutil.ReadFile(subProcessOutputFile)
-	require.NoError(t, err)
-	sidecarStartupLog := string(sidecarStartupLogBytes)
-	assert.Contains(t, sidecarStartupLog, "Using JAVA_HOME")
-	assert.Contains(t, sidecarStartupLog, "main method")
 	assert.Empty(t, result.stderr)
+	assert.Contains(t, result.startupLog, fmt.Sprintf("Command '%s' is ready", singleProcessPrimaryName))
 	pids := readPids(t)
-	require.Len(t, pids, 2)
-	assert.Equal(t, os.Getpid(), pids[multiProcessPrimaryName])
-	assert.Equal(t, pgrepSinglePid(t, "testdata", os.Getpid()), pids[multiProcessSubProcessName])
+	require.Len(t, pids, 1)
 
-	proc, _ := os.FindProcess(pids[multiProcessSubProcessName])
+	proc, _ := os.FindProcess(pids[singleProcessPrimaryName])
 	require.NoError(t, proc.Signal(syscall.SIGKILL))
 }
 
-// (2, 2, 0)
-func TestInitStart_TwoConfiguredTwoWrittenZeroRunning(t *testing.T) {
-	setupMultiProcess(t)
+func TestInitStart_NotReady(t *testing.T) {
+	setupWithConfigs(t, "testdata/launcher-static-readiness-file.yml", "testdata/launcher-custom.yml")
 	defer teardown(t)
 
-	writePids(t, servicePids{multiProcessPrimaryName: 99998, multiProcessSubProcessName: 99999})
 	result := runInit(t, "start")
 
-	assert.Equal(t, 0, result.exitCode)
-	time.Sleep(time.Second)
-	startupLog := readStartupLog(t)
-	assert.Contains(t, startupLog, "Using JAVA_HOME")
-	assert.Contains(t, startupLog, "main method")
-	sidecarStartupLogBytes, err := ioutil.ReadFile(subProcessOutputFile)
-	require.NoError(t, err)
-	sidecarStartupLog := string(sidecarStartupLogBytes)
-	assert.Contains(t, sidecarStartupLog, "Using JAVA_HOME")
-	assert.Contains(t, sidecarStartupLog, "main method")
-	assert.Empty(t, result.stderr)
-	pids := readPids(t)
-	require.Len(t, pids, 2)
-	assertContainSameElements(t, pgrepMultiPids(t, "testdata", os.Getpid()),
-		[]int{pids[multiProcessPrimaryName], pids[multiProcessSubProcessName]})
+	assert.Equal(t, 1, result.exitCode)
+	assert.Contains(t, result.stderr, fmt.Sprintf("command '%s' did not become ready within 3s waiting for file "+
+		"'var/ready' to exist", singleProcessPrimaryName))
+	assert.Contains(t, result.stderr, "main method")
+	assert.Empty(t, readPids(t))
+}
 
-	proc, _ := os.FindProcess(pids[multiProcessPrimaryName])
-	require.NoError(t, proc.Signal(syscall.SIGKILL))
-	proc, _ = os.FindProcess(pids[multiProcessSubProcessName])
-	require.NoError(t, proc.Signal(syscall.SIGKILL))
+func TestInitStart_RemovesStaleReadinessFile(t *testing.T) {
+	setupWithConfigs(t, "testdata/launcher-static-readiness-file.yml", "testdata/launcher-custom.yml")
+	defer teardown(t)
+	require.NoError(t, os.MkdirAll("var", 0755))
+	require.NoError(t, ioutil.WriteFile("var/ready", nil, 0644))
+
+	result := runInit(t, "start")
+
+	assert.Equal(t, 1, result.exitCode)
+	assert.Contains(t, result.stderr, fmt.Sprintf("command '%s' did not become ready within 3s waiting for file "+
+		"'var/ready' to exist", singleProcessPrimaryName))
+	assert.NoFileExists(t, "var/ready")
+	assert.Empty(t, readPids(t))
 }
 
-// (2, 2, 1)
-func TestInitStart_TwoConfiguredTwoWrittenOneRunning(t *testing.T) {
-	setupMultiProcess(t)
+func TestInitStart_Daemonizes(t *testing.T) {
+	setupWithConfigs(t, "testdata/launcher-static-umask.yml", "testdata/launcher-custom.yml")
 	defer teardown(t)
 
-	writePids(t, servicePids{multiProcessPrimaryName: os.Getpid(), multiProcessSubProcessName: 99999})
 	result := runInit(t, "start")
 
-	assert.Equal(t, 0, result.exitCode)
-	time.Sleep(time.Second)
-	sidecarStartupLogBytes, err := ioutil.ReadFile(subProcessOutputFile)
+	require.Equal(t, 0, result.exitCode)
+	pid := readPids(t)[singleProcessPrimaryName]
+	defer func() {
+		proc, _ := os.FindProcess(pid)
+		require.NoError(t, proc.Signal(syscall.SIGKILL))
+	}()
+	stat, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
 	require.NoError(t, err)
-	sidecarStartupLog := string(sidecarStartupLogBytes)
-	assert.Contains(t, sidecarStartupLog, "Using JAVA_HOME")
-	assert.Contains(t, sidecarStartupLog, "main method")
-	assert.Empty(t, result.stderr)
-	pids := readPids(t)
-	require.Len(t, pids, 2)
-	assert.Equal(t, os.Getpid(), pids[multiProcessPrimaryName])
-	assert.Equal(t, pgrepSinglePid(t, "testdata", os.Getpid()), pids[multiProcessSubProcessName])
+	// The session id is the 6th field, the 4th after the parenthesised command name.
+	statFields := strings.Fields(string(stat[bytes.LastIndexByte(stat, ')')+1:]))
+	assert.Equal(t, strconv.Itoa(pid), statFields[3], "process should lead its own session")
+	status, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/status", pid))
+	require.NoError(t, err)
+	assert.Regexp(t, `(?m)^Umask:\s+0077$`, string(status))
+	stdin, err := os.Readlink(fmt.Sprintf("/proc/%d/fd/0", pid))
+	require.NoError(t, err)
+	assert.Equal(t, os.DevNull, stdin)
+	workingDir, err := os.Getwd()
+	require.NoError(t, err)
+	cwd, err := os.Readlink(fmt.Sprintf("/proc/%d/cwd", pid))
+	require.NoError(t, err)
+	assert.Equal(t, workingDir, cwd)
+}
 
-	proc, _ := os.FindProcess(pids[multiProcessSubProcessName])
+func TestInitStart_SurvivesHangupOfParentSession(t *testing.T) {
+	setupSingleProcess(t)
+	defer teardown(t)
+
+	// Run go-init in a session of its own, as it would be from the shell of an operator, and keep the session alive.
+	session, exitCode := startInitHelperProcess(t, "start")
+	require.Equal(t, 0, <-exitCode)
+	pid := readPids(t)[singleProcessPrimaryName]
+
+	// Hang up the session as a terminal would, signalling its whole process group.
+	require.NoError(t, syscall.Kill(-session.Process.Pid, syscall.SIGHUP))
+	assertTerminatedBySignal(t, session, syscall.SIGHUP)
+
+	time.Sleep(500 * time.Millisecond)
+	proc, _ := os.FindProcess(pid)
+	assert.NoError(t, proc.Signal(syscall.Signal(0)), "service should have survived the hangup")
+	assert.Equal(t, 0, runInit(t, "status").exitCode)
 	require.NoError(t, proc.Signal(syscall.SIGKILL))
 }
 
-/*
- * Same states apply to status.
- */
-
-func TestInitStatus_DoesNotTruncateStartupLogFile(t *testing.T) {
-	setup(t)
-	defer teardown(t)
+// TestInitHelperProcess is not a real test: it runs go-init as a separate process for tests that need one, printing
+// its exit code and then waiting to be signalled.
+func TestInitHelperProcess(t *testing.T) {
+	if os.Getenv("GO_INIT_HELPER_SELF_COMMAND") != "" {
+		os.Exit(cli2.Run(cli2.App(), append([]string{"go-init"}, flag.Args()...)))
+	}
+	command := os.Getenv("GO_INIT_HELPER_PROCESS")
+	if command == "" {
+		return
+	}
+	cli2.SelfCommand = initHelperCommand
+	exitCode := cli2.App().Run([]string{"", command})
+	fmt.Printf("go-init exited %d\n", exitCode)
+	time.Sleep(time.Minute)
+}
 
-	stringThatShouldRemain := "this should remain in the log file after running"
+// initHelperCommand returns a command that runs go-init with the given arguments, and exits with its exit code, in
+// place of the go-init binary, since go-init is run in-process by the tests.
+func initHelperCommand(args ...string) *exec.Cmd {
+	helper := exec.Command(os.Args[0], append([]string{"-test.run=^TestInitHelperProcess$", "--"}, args...)...)
+	helper.Env = append(os.Environ(), "GO_INIT_HELPER_SELF_COMMAND=true")
+	return helper
+}
 
-	require.NoError(t, os.MkdirAll(filepath.Dir(primaryOutputFile), 0755))
-	require.NoError(t, ioutil.WriteFile(primaryOutputFile, []byte(stringThatShouldRemain), 0644))
-	result := runInit(t, "status")
+// startInitHelperProcess runs the given go-init command in a separate process in a session of its own, and returns the
+// process along with a channel that receives the exit code of go-init. The process keeps running after go-init exits.
+func startInitHelperProcess(t *testing.T, command string) (*exec.Cmd, <-chan int) {
+	helper := exec.Command(os.Args[0], "-test.run=^TestInitHelperProcess$")
+	helper.Env = append(os.Environ(), "GO_INIT_HELPER_PROCESS="+command)
+	helper.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	helper.Stderr = os.Stderr
+	stdout, err := helper.StdoutPipe()
+	require.NoError(t, err)
+	require.NoError(t, helper.Start())
 
-	assert.Contains(t, result.startupLog, stringThatShouldRemain)
+	exitCode := make(chan int, 1)
+	go func() {
+		// If go-init did not exit, e.g. because it was killed, -1 is sent.
+		code := -1
+		if line, err := bufio.NewReader(stdout).ReadString('\n'); err == nil {
+			_, _ = fmt.Sscanf(line, "go-init exited %d\n", &code)
+		}
+		exitCode <- code
+	}()
+	return helper, exitCode
 }
 
-// (0, 0, 0)
-func TestInitStatus_NoConfig(t *testing.T) {
-	setup(t)
+func TestInitRun_StopsServiceOnSIGTERM(t *testing.T) {
+	setupWithConfigs(t, "testdata/launcher-static-multiprocess-long-running.yml",
+		"testdata/launcher-custom-multiprocess.yml")
 	defer teardown(t)
 
-	result := runInit(t, "status")
+	helper, exitCode := startInitHelperProcess(t, "run")
+	defer func() {
+		_ = helper.Process.Kill()
+		_ = helper.Wait()
+	}()
+	pids := waitForPids(t, 2)
 
-	assert.Equal(t, 4, result.exitCode)
-	assert.Contains(t, result.stderr, "failed to determine service status")
-	assert.Contains(t, result.startupLog, "failed to determine service status")
+	// The processes are children of go-init, and can be seen by go-init commands run from elsewhere.
+	assertContainSameElements(t, []int{pids[multiProcessPrimaryName], pids[multiProcessSubProcessName]},
+		pgrepMultiPids(t, "testdata", helper.Process.Pid))
+	assert.Equal(t, 0, runInit(t, "status").exitCode)
+
+	require.NoError(t, helper.Process.Signal(syscall.SIGTERM))
+	assert.Equal(t, 0, <-exitCode)
+	assert.Empty(t, readPids(t))
+	for _, pid := range pids {
+		assert.False(t, isRunning(pid), "process %d should have been stopped", pid)
+	}
+	startupLog := readStartupLog(t)
+	assert.Contains(t, startupLog, fmt.Sprintf("Running commands '[%s %s]' in the foreground",
+		multiProcessPrimaryName, multiProcessSubProcessName))
+	assert.Contains(t, startupLog, "Received terminated, so stopping the service")
 }
 
-// (0, 0, 0)
-func TestInitStatus_BadConfig(t *testing.T) {
-	setupBadConfig(t)
+func TestInitRun_ForwardsSignals(t *testing.T) {
+	setupWithConfigs(t, "testdata/launcher-static-multiprocess-long-running.yml",
+		"testdata/launcher-custom-multiprocess.yml")
 	defer teardown(t)
 
-	result := runInit(t, "status")
+	helper, exitCode := startInitHelperProcess(t, "run")
+	defer func() {
+		_ = helper.Process.Kill()
+		_ = helper.Wait()
+	}()
+	pids := waitForPids(t, 2)
 
-	assert.Equal(t, 4, result.exitCode)
-	assert.Contains(t, result.stderr, "failed to determine service status")
-	assert.Contains(t, result.startupLog, "failed to determine service status")
+	// The processes do not handle SIGHUP, so exit once it is forwarded to them, which stops the service.
+	require.NoError(t, helper.Process.Signal(syscall.SIGHUP))
+	assert.Equal(t, 1, <-exitCode)
+	assert.Empty(t, readPids(t))
+	startupLog := readStartupLog(t)
+	for name, pid := range pids {
+		assert.Contains(t, startupLog, fmt.Sprintf("Sent signal hangup to '%s' process with pid %d", name, pid))
+	}
+	assert.Regexp(t, "command '(primary|sidecar)' exited: signal: hangup", startupLog)
 }
 
-// (1, 0, 0)
-func TestInitStatus_OneConfiguredZeroWrittenZeroRunning(t *testing.T) {
-	setupSingleProcess(t)
+func TestInitRun_StopsServiceWhenProcessExits(t *testing.T) {
+	setupWithConfigs(t, "testdata/launcher-static-multiprocess.yml",
+		"testdata/launcher-custom-multiprocess-long-sub-process.yml")
 	defer teardown(t)
 
-	result := runInit(t, "status")
+	helper, exitCode := startInitHelperProcess(t, "run")
+	defer func() {
+		_ = helper.Process.Kill()
+		_ = helper.Wait()
+	}()
+	pids := waitForPids(t, 2)
 
-	assert.Equal(t, 3, result.exitCode)
-	assert.Contains(t, result.stderr, fmt.Sprintf("commands '[%s]' are not running", singleProcessPrimaryName))
-	assert.Contains(t, result.startupLog, fmt.Sprintf("commands '[%s]' are not running", singleProcessPrimaryName))
+	// The primary process exits by itself after 5 seconds.
+	assert.Equal(t, 1, <-exitCode)
+	assert.Empty(t, readPids(t))
+	assert.False(t, isRunning(pids[multiProcessSubProcessName]), "sidecar should have been stopped")
+	assert.Contains(t, readStartupLog(t), fmt.Sprintf("command '%s' exited: exit status 0",
+		multiProcessPrimaryName))
+	// go-init run waits for the processes itself, so records how they exited without shims.
+	exit := readExitRecord(t, multiProcessPrimaryName)
+	assert.Equal(t, pids[multiProcessPrimaryName], exit.Pid)
+	assert.Equal(t, 0, exit.ExitCode)
 }
 
-// (1, 1, 0)
-func TestInitStatus_OneConfiguredOneWrittenZeroRunning(t *testing.T) {
-	setupSingleProcess(t)
+func TestInitRun_StoppedByAnotherCommand(t *testing.T) {
+	setupWithConfigs(t, "testdata/launcher-static-multiprocess-long-running.yml",
+		"testdata/launcher-custom-multiprocess.yml")
 	defer teardown(t)
 
-	writePids(t, servicePids{singleProcessPrimaryName: 99999})
-	result := runInit(t, "status")
+	helper, exitCode := startInitHelperProcess(t, "run")
+	defer func() {
+		_ = helper.Process.Kill()
+		_ = helper.Wait()
+	}()
+	waitForPids(t, 2)
 
-	assert.Equal(t, 1, result.exitCode)
-	assert.Contains(t, result.stderr, fmt.Sprintf("commands '[%s]' are not running", singleProcessPrimaryName))
-	assert.Contains(t, result.startupLog, fmt.Sprintf("commands '[%s]' are not running", singleProcessPrimaryName))
+	assert.Equal(t, 0, runInit(t, "stop").exitCode)
+	assert.Equal(t, 0, <-exitCode)
+	assert.Empty(t, readPids(t))
+	assert.Regexp(t, "Command '(primary|sidecar)' was stopped by another go-init command", readStartupLog(t))
 }
 
-// (1, 1, 1)
-func TestInitStatus_OneConfiguredOneWrittenOneRunning(t *testing.T) {
+func TestInitRun_AlreadyRunning(t *testing.T) {
 	setupSingleProcess(t)
 	defer teardown(t)
 
 	writePids(t, servicePids{singleProcessPrimaryName: os.Getpid()})
-	result := runInit(t, "status")
+	result := runInit(t, "run")
 
-	assert.Equal(t, 0, result.exitCode)
-	assert.Empty(t, result.stderr)
+	assert.Equal(t, 1, result.exitCode)
+	assert.Contains(t, result.stderr, fmt.Sprintf("commands '[%s]' are already running", singleProcessPrimaryName))
 }
 
-// (2, 0, 0)
-func TestInitStatus_TwoConfiguredZeroWrittenZeroRunning(t *testing.T) {
-	setupMultiProcess(t)
+func TestInitStart_NotifiesSystemd(t *testing.T) {
+	setupWithConfigs(t, "testdata/launcher-static-multiprocess-long-running.yml",
+		"testdata/launcher-custom-multiprocess.yml")
 	defer teardown(t)
+	notifications := listenNotifySocket(t)
 
-	result := runInit(t, "status")
+	assert.Equal(t, 0, runInit(t, "start").exitCode)
+	pids := readPids(t)
+	assert.Equal(t, fmt.Sprintf("MAINPID=%d\nREADY=1", pids[multiProcessPrimaryName]), <-notifications)
 
-	assert.Equal(t, 3, result.exitCode)
-	assert.Contains(t, result.stderr, "commands")
-	assert.Contains(t, result.stderr, multiProcessPrimaryName)
-	assert.Contains(t, result.stderr, multiProcessSubProcessName)
-	assert.Contains(t, result.stderr, "are not running")
-	assert.Contains(t, result.startupLog, "commands")
-	assert.Contains(t, result.startupLog, multiProcessPrimaryName)
-	assert.Contains(t, result.startupLog, multiProcessSubProcessName)
-	assert.Contains(t, result.startupLog, "are not running")
+	assert.Equal(t, 0, runInit(t, "stop").exitCode)
+	assert.Equal(t, "STOPPING=1", <-notifications)
 }
 
-// (2, 1, 0)
-func TestInitStatus_TwoConfiguredOneWrittenZeroRunning(t *testing.T) {
-	setupMultiProcess(t)
+func TestInitRun_NotifiesSystemd(t *testing.T) {
+	setupWithConfigs(t, "testdata/launcher-static-multiprocess-long-running.yml",
+		"testdata/launcher-custom-multiprocess.yml")
 	defer teardown(t)
+	notifications := listenNotifySocket(t)
+	t.Setenv(launchlib.WatchdogUsecEnvVar, "200000")
 
-	writePids(t, servicePids{multiProcessPrimaryName: 99999})
-	result := runInit(t, "status")
+	helper, exitCode := startInitHelperProcess(t, "run")
+	defer func() {
+		_ = helper.Process.Kill()
+		_ = helper.Wait()
+	}()
 
-	assert.Equal(t, 1, result.exitCode)
-	assert.Contains(t, result.stderr, "commands")
-	assert.Contains(t, result.stderr, multiProcessPrimaryName)
-	assert.Contains(t, result.stderr, multiProcessSubProcessName)
-	assert.Contains(t, result.stderr, "are not running")
-	assert.Contains(t, result.startupLog, "commands")
-	assert.Contains(t, result.startupLog, multiProcessPrimaryName)
-	assert.Contains(t, result.startupLog, multiProcessSubProcessName)
-	assert.Contains(t, result.startupLog, "are not running")
+	assert.Equal(t, "READY=1", <-notifications)
+	// The watchdog is notified every half of the watchdog timeout while the primary process is running.
+	assert.Equal(t, "WATCHDOG=1", <-notifications)
+	assert.Equal(t, "WATCHDOG=1", <-notifications)
+
+	require.NoError(t, helper.Process.Signal(syscall.SIGTERM))
+	for notification := range notifications {
+		if notification != "WATCHDOG=1" {
+			assert.Equal(t, "STOPPING=1", notification)
+			break
+		}
+	}
+	assert.Equal(t, 0, <-exitCode)
 }
 
-// (2, 1, 1)
-func TestInitStatus_TwoConfiguredOneWrittenOneRunning(t *testing.T) {
-	setupMultiProcess(t)
+func TestInitStart_RotatesLogs(t *testing.T) {
+	setupWithConfigs(t, "testdata/launcher-static-log-rotation.yml", "testdata/launcher-custom.yml")
 	defer teardown(t)
 
-	writePids(t, servicePids{multiProcessPrimaryName: os.Getpid()})
+	assert.Equal(t, 0, runInit(t, "start").exitCode)
+	pids := readPids(t)
+	require.Len(t, pids, 1)
+
+	// The output of go-init itself fills the log file, so it is rotated before the output of the primary process is
+	// written.
+	startupLog := filepath.Join(logDir, outputLogFile)
+	require.Eventually(t, func() bool {
+		content, err := ioutil.ReadFile(startupLog)
+		return err == nil && strings.Contains(string(content), "main method")
+	}, 10*time.Second, 100*time.Millisecond)
+	rotated, err := os.Open(startupLog + ".0.gz")
+	require.NoError(t, err)
+	defer func() {
+		_ = rotated.Close()
+	}()
+	reader, err := gzip.NewReader(rotated)
+	require.NoError(t, err)
+	content, err := ioutil.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "Argument list to executable binary")
+
+	// The log-writer exits once the process it writes the output of has stopped.
+	absStartupLog, err := filepath.Abs(startupLog)
+	require.NoError(t, err)
+	logWriterPattern := "[l]og-writer --file " + regexp.QuoteMeta(absStartupLog)
+	assert.Len(t, pgrepPids(t, logWriterPattern), 1)
+	assert.Equal(t, 0, runInit(t, "stop").exitCode)
+	assert.Eventually(t, func() bool {
+		return len(pgrepPids(t, logWriterPattern)) == 0
+	}, 10*time.Second, 100*time.Millisecond)
+}
+
+func TestInitStart_RecordsExitStatus(t *testing.T) {
+	setupSingleProcess(t)
+	defer teardown(t)
+
+	require.Equal(t, 0, runInit(t, "start").exitCode)
+	pid := readPids(t)[singleProcessPrimaryName]
+	proc, _ := os.FindProcess(pid)
+	require.NoError(t, proc.Signal(syscall.SIGKILL))
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(fmt.Sprintf(exitFileFormat, singleProcessPrimaryName))
+		return err == nil
+	}, 5*time.Second, 100*time.Millisecond)
+
+	exit := readExitRecord(t, singleProcessPrimaryName)
+	assert.Equal(t, pid, exit.Pid)
+	assert.Equal(t, -1, exit.ExitCode)
+	assert.Equal(t, int(syscall.SIGKILL), exit.Signal)
+	assert.False(t, exit.StartedAt.IsZero())
+	assert.False(t, exit.ExitedAt.Before(exit.StartedAt))
+
 	result := runInit(t, "status")
+	assert.Equal(t, 1, result.exitCode)
+	assert.Contains(t, result.stderr, fmt.Sprintf("command '%s' killed by signal 9 (killed) at",
+		singleProcessPrimaryName))
+	result, stdout := runInitCapturingStdout(t, "status", "--format", "json")
+	assert.Equal(t, 1, result.exitCode)
+	assert.Contains(t, stdout, `"lastExitReason": "killed by signal 9 (killed) at `)
+}
+
+func TestInitStart_RunsInitTasksBeforeStarting(t *testing.T) {
+	setupWithConfigs(t, "testdata/launcher-static-init-tasks.yml", "testdata/launcher-custom.yml")
+	defer teardown(t)
+
+	result := runInit(t, "start")
+	require.Equal(t, 0, result.exitCode, result.stderr)
+	defer func() {
+		assert.Equal(t, 0, runInit(t, "stop").exitCode)
+	}()
+	assert.DirExists(t, "var/data/migrations")
+	taskLog, err := ioutil.ReadFile(filepath.Join(logDir, "migrate-startup.log"))
+	require.NoError(t, err)
+	assert.Contains(t, string(taskLog), "main method")
+	assert.Contains(t, readStartupLog(t), "Running initTask 'migrate'")
+	assert.Contains(t, readPids(t), singleProcessPrimaryName)
+}
 
+func TestInitStart_FailingInitTaskAbortsStart(t *testing.T) {
+	setupWithConfigs(t, "testdata/launcher-static-init-tasks-failing.yml", "testdata/launcher-custom.yml")
+	defer teardown(t)
+
+	result := runInit(t, "start")
 	assert.Equal(t, 1, result.exitCode)
-	assert.Contains(t, result.stderr, fmt.Sprintf("commands '[%s]' are not running", multiProcessSubProcessName))
-	assert.Contains(t, result.startupLog, fmt.Sprintf("commands '[%s]' are not running",
-		multiProcessSubProcessName))
+	assert.Contains(t, result.stderr, "initTask 'migrate' failed: exit status 3")
+	assert.Contains(t, result.stderr, "Last lines of the log of initTask 'migrate' at var/log/migrate-startup.log:")
+	assert.Empty(t, readPids(t))
 }
 
-// (2, 2, 0)
-func TestInitStatus_TwoConfiguredTwoWrittenZeroRunning(t *testing.T) {
-	setupMultiProcess(t)
+func TestInitExec_RunsEntrypointWithExtraArgs(t *testing.T) {
+	setupWithConfigs(t, "testdata/launcher-static-entrypoints.yml", "testdata/launcher-custom.yml")
 	defer teardown(t)
 
-	writePids(t, servicePids{multiProcessPrimaryName: os.Getpid(), multiProcessSubProcessName: 99999})
-	result := runInit(t, "status")
+	result, stdout := runInitCapturingStdout(t, "exec", "repair", "--", "--dry-run", "extra")
+	require.Equal(t, 0, result.exitCode, result.stderr)
+	assert.Equal(t, "\nmain method\n", stdout)
+	assert.Regexp(t, `Argument list to executable binary: \[.+/bin/java -Xmx1g -classpath .+/testdata Repair `+
+		`--table users --dry-run extra\]`, result.stderr)
+	assert.DirExists(t, "var/data/tmp")
+	assert.Empty(t, readPids(t), "exec should not start the service")
+}
+
+func TestInitExec_FailsForUnknownEntrypoint(t *testing.T) {
+	setupWithConfigs(t, "testdata/launcher-static-entrypoints.yml", "testdata/launcher-custom.yml")
+	defer teardown(t)
 
+	result := runInit(t, "exec", "backfill")
 	assert.Equal(t, 1, result.exitCode)
-	assert.Contains(t, result.stderr, "commands")
-	assert.Contains(t, result.stderr, multiProcessPrimaryName)
-	assert.Contains(t, result.stderr, multiProcessSubProcessName)
-	assert.Contains(t, result.stderr, "are not running")
-	assert.Contains(t, result.startupLog, "commands")
-	assert.Contains(t, result.startupLog, multiProcessPrimaryName)
-	assert.Contains(t, result.startupLog, multiProcessSubProcessName)
-	assert.Contains(t, result.startupLog, "are not running")
+	assert.Contains(t, result.stderr, "no entrypoint named 'backfill' is configured, found: [repair]")
+
+	result = runInit(t, "status", "--", "extra")
+	assert.Equal(t, 1, result.exitCode)
+	assert.Contains(t, result.stderr, "only exec accepts arguments after --")
 }
 
-// (2, 2, 1)
-func TestInitStatus_TwoConfiguredTwoWrittenOneRunning(t *testing.T) {
+func TestInitStart_DryRunPrintsCommandsWithoutStarting(t *testing.T) {
 	setupMultiProcess(t)
 	defer teardown(t)
 
-	writePids(t, servicePids{multiProcessPrimaryName: os.Getpid(), multiProcessSubProcessName: 99999})
-	result := runInit(t, "status")
+	result, stdout := runInitCapturingStdout(t, "start", "--dry-run")
+	require.Equal(t, 0, result.exitCode, result.stderr)
+	var descriptions []launchlib.CommandDescription
+	require.NoError(t, json.Unmarshal([]byte(stdout), &descriptions), stdout)
+	require.Len(t, descriptions, 2)
+	assert.Equal(t, singleProcessPrimaryName, descriptions[0].Name)
+	assert.Equal(t, "primary", descriptions[0].Type)
+	assert.Equal(t, []string{"-Xmx1g", "-classpath"}, descriptions[0].Args[1:3])
+	assert.Equal(t, []string{"Main", "arg1"}, descriptions[0].Args[4:])
+	assert.NotNil(t, descriptions[0].Heap)
+	assert.Equal(t, "sidecar", descriptions[1].Name)
+	assert.Equal(t, "subProcess", descriptions[1].Type)
+
+	result, stdout = runInitCapturingStdout(t, "start", "--dry-run", "--format", "shell")
+	require.Equal(t, 0, result.exitCode, result.stderr)
+	assert.Regexp(t, `# primary \(primary\)\n(#.*\n)*cd .+ && .+/bin/java -Xmx1g -classpath .+ Main arg1\n`, stdout)
+
+	assert.Empty(t, readPids(t))
+	assert.NoFileExists(t, primaryOutputFile)
+}
+
+func TestInitExplain_ListsArgOrigins(t *testing.T) {
+	setupMultiProcess(t)
+	defer teardown(t)
+
+	result, stdout := runInitCapturingStdout(t, "explain", "--format", "json")
+	require.Equal(t, 0, result.exitCode, result.stderr)
+	var explanations []launchlib.JvmArgsExplanation
+	require.NoError(t, json.Unmarshal([]byte(stdout), &explanations), stdout)
+	require.Len(t, explanations, 2)
+	assert.Equal(t, singleProcessPrimaryName, explanations[0].Name)
+	assert.Equal(t, launchlib.ExplainedArg{Arg: "-Xmx1g", Origin: "custom jvmOpts"}, explanations[0].Args[0])
+	assert.Equal(t, []launchlib.ExplainedArg{
+		{Arg: "-Xmx4M", Origin: "static jvmOpts", Reason: "overridden by -Xmx1g"},
+	}, explanations[0].Dropped)
+	assert.Equal(t, []string{"-Xmx4M is overridden by -Xmx1g"}, explanations[0].Warnings)
+	assert.Equal(t, "sidecar", explanations[1].Name)
+
+	t.Setenv("CONTAINER", "")
+	result, stdout = runInitCapturingStdout(t, "explain")
+	require.Equal(t, 0, result.exitCode, result.stderr)
+	assert.Regexp(t, `(?m)^DROPPED +ORIGIN +REASON\n-Xmx4M +static jvmOpts +.+\n-Xmx1g +custom jvmOpts +.+$`, stdout)
 
+	assert.Empty(t, readPids(t))
+}
+
+func TestInitMetrics_WritesTextfile(t *testing.T) {
+	setupSingleProcess(t)
+	defer teardown(t)
+
+	require.Equal(t, 0, runInit(t, "start").exitCode)
+	proc, _ := os.FindProcess(readPids(t)[singleProcessPrimaryName])
+	require.NoError(t, proc.Signal(syscall.SIGKILL))
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(fmt.Sprintf(exitFileFormat, singleProcessPrimaryName))
+		return err == nil
+	}, 5*time.Second, 100*time.Millisecond)
+	require.Equal(t, 0, runInit(t, "start").exitCode)
+	defer func() {
+		assert.Equal(t, 0, runInit(t, "stop").exitCode)
+	}()
+
+	output := "var/service.prom"
+	result := runInit(t, "metrics", "--output", output)
+	require.Equal(t, 0, result.exitCode, result.stderr)
+	metricsBytes, err := ioutil.ReadFile(output)
+	require.NoError(t, err)
+	metrics := string(metricsBytes)
+	label := fmt.Sprintf(`{process="%s"}`, singleProcessPrimaryName)
+	assert.Contains(t, metrics, "go_init_process_up"+label+" 1\n")
+	assert.Contains(t, metrics, "go_init_process_starts"+label+" 2\n")
+	assert.Contains(t, metrics, "go_init_process_last_exit_code"+label+" 137\n")
+	for _, name := range []string{"go_init_process_start_time_seconds", "go_init_process_resident_memory_bytes",
+		"go_init_process_cpu_seconds_total", "go_init_process_open_fds"} {
+		assert.Regexp(t, fmt.Sprintf(`(?m)^%s%s [0-9.e+]+$`, name, regexp.QuoteMeta(label)), metrics)
+	}
+	matches, err := filepath.Glob(output + ".*")
+	require.NoError(t, err)
+	assert.Empty(t, matches, "no temporary files should be left behind")
+}
+
+func TestInitStart_RefusesToStartCrashLoopingProcess(t *testing.T) {
+	setupWithConfigs(t, "testdata/launcher-static-crash-loop.yml", "testdata/launcher-custom.yml")
+	defer teardown(t)
+
+	for i := 0; i < 2; i++ {
+		result := runInit(t, "start")
+		require.Equal(t, 1, result.exitCode)
+		assert.Contains(t, result.stderr, fmt.Sprintf("command '%s' exited within 1s of starting",
+			singleProcessPrimaryName))
+	}
+
+	result := runInit(t, "start")
+	assert.Equal(t, 151, result.exitCode)
+	assert.Contains(t, result.stderr, fmt.Sprintf("refusing to start crash looping commands unless --force is "+
+		"given: command '%s' exited by itself 2 times within 1h0m0s, most recently exited with code 0 at ",
+		singleProcessPrimaryName))
+	assert.NotContains(t, result.stderr, "main method")
+
+	result = runInit(t, "start", "--force")
 	assert.Equal(t, 1, result.exitCode)
-	assert.Contains(t, result.stderr, fmt.Sprintf("commands '[%s]' are not running", multiProcessSubProcessName))
-	assert.Contains(t, result.startupLog, fmt.Sprintf("commands '[%s]' are not running",
-		multiProcessSubProcessName))
+	assert.Contains(t, result.stderr, fmt.Sprintf("command '%s' exited within 1s of starting",
+		singleProcessPrimaryName))
 }
 
-// (2, 2, 2)
-func TestInitStatus_TwoConfiguredTwoWrittenTwoRunning(t *testing.T) {
+func TestInitStartAndStop_RecordLifecycleEvents(t *testing.T) {
+	setupSingleProcess(t)
+	defer teardown(t)
+
+	require.Equal(t, 0, runInit(t, "start").exitCode)
+	pid := readPids(t)[singleProcessPrimaryName]
+	configHash := readPidRecord(t, fmt.Sprintf(pidfileFormat, singleProcessPrimaryName)).ConfigHash
+	require.Equal(t, 0, runInit(t, "stop").exitCode)
+
+	var events []launchlib.Event
+	require.Eventually(t, func() bool {
+		events = readEvents(t)
+		return len(events) == 4
+	}, 5*time.Second, 100*time.Millisecond, "the shim should record the exit of the process")
+	for i, event := range events {
+		assert.Equal(t, "go-init", event.Source)
+		assert.Equal(t, singleProcessPrimaryName, event.Process)
+		assert.False(t, event.Time.IsZero())
+		events[i].Time = time.Time{}
+	}
+	// The shim records the exit of the process concurrently with go-init recording that it sent the stop signal.
+	if events[2].Type == launchlib.EventExitObserved {
+		events[2], events[3] = events[3], events[2]
+	}
+	exit := events[3]
+	assert.Equal(t, []launchlib.Event{
+		{Type: launchlib.EventStartRequested, Source: "go-init", Process: singleProcessPrimaryName},
+		{
+			Type:        launchlib.EventProcessStarted,
+			Source:      "go-init",
+			Process:     singleProcessPrimaryName,
+			Pid:         pid,
+			CommandHash: configHash,
+		},
+		{
+			Type:    launchlib.EventSignalSent,
+			Source:  "go-init",
+			Process: singleProcessPrimaryName,
+			Pid:     pid,
+			Signal:  int(syscall.SIGTERM),
+			Message: "stop",
+		},
+		{
+			Type:    launchlib.EventExitObserved,
+			Source:  "go-init",
+			Process: singleProcessPrimaryName,
+			Pid:     pid,
+			Signal:  int(syscall.SIGTERM),
+			Message: exit.Message,
+		},
+	}, events)
+	assert.Contains(t, exit.Message, "killed by signal 15 (terminated) at ")
+}
+
+func TestInitLogs_InterleavesProcesses(t *testing.T) {
 	setupMultiProcess(t)
 	defer teardown(t)
+	require.NoError(t, os.MkdirAll(logDir, 0755))
+	require.NoError(t, ioutil.WriteFile(primaryOutputFile, []byte("first\nsecond\nthird\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(subProcessOutputFile, []byte("sidecar\n"), 0644))
 
-	cmd := exec.Command("/bin/sleep", "10")
-	require.NoError(t, cmd.Start())
+	result, stdout := runInitCapturingStdout(t, "logs", "--lines", "2")
+	assert.Equal(t, 0, result.exitCode)
+	assert.Equal(t, fmt.Sprintf("[%[1]s] second\n[%[1]s] third\n[%[2]s] sidecar\n", multiProcessPrimaryName,
+		multiProcessSubProcessName), stdout)
+
+	result, stdout = runInitCapturingStdout(t, "logs", "--lines", "1", multiProcessPrimaryName)
+	assert.Equal(t, 0, result.exitCode)
+	assert.Equal(t, "third\n", stdout)
+
+	result = runInit(t, "logs", "unknown")
+	assert.Equal(t, 2, result.exitCode)
+	assert.Contains(t, result.stderr, "process 'unknown' is not configured")
+}
+
+func TestInitLogs_FollowsAcrossRotation(t *testing.T) {
+	setupSingleProcess(t)
+	defer teardown(t)
+	require.NoError(t, os.MkdirAll(logDir, 0755))
+	require.NoError(t, ioutil.WriteFile(primaryOutputFile, []byte("before\n"), 0644))
+
+	follow := initHelperCommand("logs", "--follow")
+	stdout, err := follow.StdoutPipe()
+	require.NoError(t, err)
+	require.NoError(t, follow.Start())
 	defer func() {
-		require.NoError(t, cmd.Process.Signal(syscall.SIGKILL))
+		_ = follow.Process.Kill()
 	}()
-	writePids(t, servicePids{multiProcessPrimaryName: os.Getpid(), multiProcessSubProcessName: cmd.Process.Pid})
-	result := runInit(t, "status")
+	lines := bufio.NewReader(stdout)
+	line, err := lines.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "before\n", line)
+
+	appendLine := func(content string) {
+		file, err := os.OpenFile(primaryOutputFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		require.NoError(t, err)
+		_, err = file.WriteString(content)
+		require.NoError(t, err)
+		require.NoError(t, file.Close())
+	}
+	appendLine("appended\n")
+	line, err = lines.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "appended\n", line)
+
+	require.NoError(t, os.Rename(primaryOutputFile, primaryOutputFile+".0"))
+	appendLine("rotated\n")
+	line, err = lines.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "rotated\n", line)
+
+	require.NoError(t, follow.Process.Signal(syscall.SIGTERM))
+	assert.NoError(t, follow.Wait())
+}
+
+// (1, 1, 0)
+func TestInitStart_OneConfiguredOneWrittenZeroRunning(t *testing.T) {
+	setupSingleProcess(t)
+	defer teardown(t)
+
+	writePids(t, servicePids{singleProcessPrimaryName: 99999})
+	result := runInit(t, "start")
 
 	assert.Equal(t, 0, result.exitCode)
+	time.Sleep(time.Second)
+	startupLog := readStartupLog(t)
+	assert.Contains(t, startupLog, "Using JAVA_HOME")
+	assert.Contains(t, startupLog, "main method")
 	assert.Empty(t, result.stderr)
+	pids := readPids(t)
+	require.Len(t, pids, 1)
+	assert.Equal(t, pgrepStartedPid(t, "testdata"), pids[singleProcessPrimaryName])
+
+	proc, _ := os.FindProcess(pids[singleProcessPrimaryName])
+	require.NoError(t, proc.Signal(syscall.SIGKILL))
 }
 
-func TestInitStop_DoesNotTruncateStartupLogFile(t *testing.T) {
-	setup(t)
+// (2, 0, 0)
+func TestInitStart_TwoConfiguredZeroWrittenZeroRunning(t *testing.T) {
+	setupMultiProcess(t)
 	defer teardown(t)
 
-	stringThatShouldRemain := "this should remain in the log file after running"
+	result := runInit(t, "start")
 
-	require.NoError(t, os.MkdirAll(filepath.Dir(primaryOutputFile), 0755))
-	require.NoError(t, ioutil.WriteFile(primaryOutputFile, []byte(stringThatShouldRemain), 0644))
-	result := runInit(t, "stop")
+	assert.Equal(t, 0, result.exitCode)
+	time.Sleep(time.Second)
+	startupLog := readStartupLog(t)
+	assert.Contains(t, startupLog, "Using JAVA_HOME")
+	assert.Contains(t, startupLog, "main method")
+	sidecarStartupLogBytes, err := ioutil.ReadFile(subProcessOutputFile)
+	require.NoError(t, err)
+	sidecarStartupLog := string(sidecarStartupLogBytes)
+	assert.Contains(t, sidecarStartupLog, "Using JAVA_HOME")
+	assert.Contains(t, sidecarStartupLog, "main method")
+	assert.Empty(t, result.stderr)
+	pids := readPids(t)
+	require.Len(t, pids, 2)
+	assertContainSameElements(t, pgrepStartedPids(t, "testdata"),
+		[]int{pids[multiProcessPrimaryName], pids[multiProcessSubProcessName]})
 
-	assert.Contains(t, result.startupLog, stringThatShouldRemain)
+	proc, _ := os.FindProcess(pids[multiProcessPrimaryName])
+	require.NoError(t, proc.Signal(syscall.SIGKILL))
+	proc, _ = os.FindProcess(pids[multiProcessSubProcessName])
+	require.NoError(t, proc.Signal(syscall.SIGKILL))
 }
 
-/*
- * Prior states for stop are defined by pairs of the following: (number of processes written to pidfiles, number of
- * processes running). As above, for (a, b), only a >= b is a valid input.
- */
+// (2, 1, 0)
+func TestInitStart_TwoConfiguredOneWrittenZeroRunning(t *testing.T) {
+	setupMultiProcess(t)
+	defer teardown(t)
+
+	writePids(t, servicePids{multiProcessPrimaryName: 99999})
+	result := runInit(t, "start")
+
+	assert.Equal(t, 0, result.exitCode)
+	time.Sleep(time.Second)
+	startupLog := readStartupLog(t)
+	assert.Contains(t, startupLog, "Using JAVA_HOME")
+	assert.Contains(t, startupLog, "main method")
+	sidecarStartupLogBytes, err := ioutil.ReadFile(subProcessOutputFile)
+	require.NoError(t, err)
+	sidecarStartupLog := string(sidecarStartupLogBytes)
+	assert.Contains(t, sidecarStartupLog, "Using JAVA_HOME")
+	assert.Contains(t, sidecarStartupLog, "main method")
+	assert.Empty(t, result.stderr)
+	pids := readPids(t)
+	require.Len(t, pids, 2)
+	assertContainSameElements(t, pgrepStartedPids(t, "testdata"),
+		[]int{pids[multiProcessPrimaryName], pids[multiProcessSubProcessName]})
+
+	proc, _ := os.FindProcess(pids[multiProcessPrimaryName])
+	require.NoError(t, proc.Signal(syscall.SIGKILL))
+	proc, _ = os.FindProcess(pids[multiProcessSubProcessName])
+	require.NoError(t, proc.Signal(syscall.SIGKILL))
+}
+
+// (2, 1, 1)
+func TestInitStart_TwoConfiguredOneWrittenOneRunning(t *testing.T) {
+	setupMultiProcess(t)
+	defer teardown(t)
+
+	writePids(t, servicePids{multiProcessPrimaryName: os.Getpid()})
+	result := runInit(t, "start")
+
+	assert.Equal(t, 0, result.exitCode)
+	time.Sleep(time.Second)
+	sidecarStartupLogBytes, err := ioutil.ReadFile(subProcessOutputFile)
+	require.NoError(t, err)
+	sidecarStartupLog := string(sidecarStartupLogBytes)
+	assert.Contains(t, sidecarStartupLog, "Using JAVA_HOME")
+	assert.Contains(t, sidecarStartupLog, "main method")
+	assert.Empty(t, result.stderr)
+	pids := readPids(t)
+	require.Len(t, pids, 2)
+	assert.Equal(t, os.Getpid(), pids[multiProcessPrimaryName])
+	assert.Equal(t, pgrepStartedPid(t, "testdata"), pids[multiProcessSubProcessName])
+
+	proc, _ := os.FindProcess(pids[multiProcessSubProcessName])
+	require.NoError(t, proc.Signal(syscall.SIGKILL))
+}
+
+// (2, 2, 0)
+func TestInitStart_TwoConfiguredTwoWrittenZeroRunning(t *testing.T) {
+	setupMultiProcess(t)
+	defer teardown(t)
+
+	writePids(t, servicePids{multiProcessPrimaryName: 99998, multiProcessSubProcessName: 99999})
+	result := runInit(t, "start")
+
+	assert.Equal(t, 0, result.exitCode)
+	time.Sleep(time.Second)
+	startupLog := readStartupLog(t)
+	assert.Contains(t, startupLog, "Using JAVA_HOME")
+	assert.Contains(t, startupLog, "main method")
+	sidecarStartupLogBytes, err := ioutil.ReadFile(subProcessOutputFile)
+	require.NoError(t, err)
+	sidecarStartupLog := string(sidecarStartupLogBytes)
+	assert.Contains(t, sidecarStartupLog, "Using JAVA_HOME")
+	assert.Contains(t, sidecarStartupLog, "main method")
+	assert.Empty(t, result.stderr)
+	pids := readPids(t)
+	require.Len(t, pids, 2)
+	assertContainSameElements(t, pgrepStartedPids(t, "testdata"),
+		[]int{pids[multiProcessPrimaryName], pids[multiProcessSubProcessName]})
+
+	proc, _ := os.FindProcess(pids[multiProcessPrimaryName])
+	require.NoError(t, proc.Signal(syscall.SIGKILL))
+	proc, _ = os.FindProcess(pids[multiProcessSubProcessName])
+	require.NoError(t, proc.Signal(syscall.SIGKILL))
+}
+
+// (2, 2, 1)
+func TestInitStart_TwoConfiguredTwoWrittenOneRunning(t *testing.T) {
+	setupMultiProcess(t)
+	defer teardown(t)
+
+	writePids(t, servicePids{multiProcessPrimaryName: os.Getpid(), multiProcessSubProcessName: 99999})
+	result := runInit(t, "start")
+
+	assert.Equal(t, 0, result.exitCode)
+	time.Sleep(time.Second)
+	sidecarStartupLogBytes, err := ioutil.ReadFile(subProcessOutputFile)
+	require.NoError(t, err)
+	sidecarStartupLog := string(sidecarStartupLogBytes)
+	assert.Contains(t, sidecarStartupLog, "Using JAVA_HOME")
+	assert.Contains(t, sidecarStartupLog, "main method")
+	assert.Empty(t, result.stderr)
+	pids := readPids(t)
+	require.Len(t, pids, 2)
+	assert.Equal(t, os.Getpid(), pids[multiProcessPrimaryName])
+	assert.Equal(t, pgrepStartedPid(t, "testdata"), pids[multiProcessSubProcessName])
+
+	proc, _ := os.FindProcess(pids[multiProcessSubProcessName])
+	require.NoError(t, proc.Signal(syscall.SIGKILL))
+}
+
+/*
+ * Same states apply to status.
+ */
+
+func TestInitStatus_DoesNotTruncateStartupLogFile(t *testing.T) {
+	setup(t)
+	defer teardown(t)
+
+	stringThatShouldRemain := "this should remain in the log file after running"
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(primaryOutputFile), 0755))
+	require.NoError(t, ioutil.WriteFile(primaryOutputFile, []byte(stringThatShouldRemain), 0644))
+	result := runInit(t, "status")
+
+	assert.Contains(t, result.startupLog, stringThatShouldRemain)
+}
+
+// (0, 0, 0)
+func TestInitStatus_NoConfig(t *testing.T) {
+	setup(t)
+	defer teardown(t)
+
+	result := runInit(t, "status")
+
+	assert.Equal(t, 4, result.exitCode)
+	assert.Contains(t, result.stderr, "failed to determine service status")
+	assert.Contains(t, result.startupLog, "failed to determine service status")
+}
+
+// (0, 0, 0)
+func TestInitStatus_BadConfig(t *testing.T) {
+	setupBadConfig(t)
+	defer teardown(t)
+
+	result := runInit(t, "status")
+
+	assert.Equal(t, 4, result.exitCode)
+	assert.Contains(t, result.stderr, "failed to determine service status")
+	assert.Contains(t, result.startupLog, "failed to determine service status")
+}
+
+// (1, 0, 0)
+func TestInitStatus_OneConfiguredZeroWrittenZeroRunning(t *testing.T) {
+	setupSingleProcess(t)
+	defer teardown(t)
+
+	result := runInit(t, "status")
+
+	assert.Equal(t, 3, result.exitCode)
+	assert.Contains(t, result.stderr, fmt.Sprintf("commands '[%s]' are not running", singleProcessPrimaryName))
+	assert.Contains(t, result.startupLog, fmt.Sprintf("commands '[%s]' are not running", singleProcessPrimaryName))
+}
+
+// (1, 1, 0)
+func TestInitStatus_OneConfiguredOneWrittenZeroRunning(t *testing.T) {
+	setupSingleProcess(t)
+	defer teardown(t)
+
+	writePids(t, servicePids{singleProcessPrimaryName: 99999})
+	result := runInit(t, "status")
+
+	assert.Equal(t, 1, result.exitCode)
+	assert.Contains(t, result.stderr, fmt.Sprintf("commands '[%s]' are not running", singleProcessPrimaryName))
+	assert.Contains(t, result.startupLog, fmt.Sprintf("commands '[%s]' are not running", singleProcessPrimaryName))
+}
+
+// (1, 1, 1)
+func TestInitStatus_OneConfiguredOneWrittenOneRunning(t *testing.T) {
+	setupSingleProcess(t)
+	defer teardown(t)
+
+	writePids(t, servicePids{singleProcessPrimaryName: os.Getpid()})
+	result := runInit(t, "status")
+
+	assert.Equal(t, 0, result.exitCode)
+	assert.Empty(t, result.stderr)
+}
+
+// (2, 0, 0)
+func TestInitStatus_TwoConfiguredZeroWrittenZeroRunning(t *testing.T) {
+	setupMultiProcess(t)
+	defer teardown(t)
+
+	result := runInit(t, "status")
+
+	assert.Equal(t, 3, result.exitCode)
+	assert.Contains(t, result.stderr, "commands")
+	assert.Contains(t, result.stderr, multiProcessPrimaryName)
+	assert.Contains(t, result.stderr, multiProcessSubProcessName)
+	assert.Contains(t, result.stderr, "are not running")
+	assert.Contains(t, result.startupLog, "commands")
+	assert.Contains(t, result.startupLog, multiProcessPrimaryName)
+	assert.Contains(t, result.startupLog, multiProcessSubProcessName)
+	assert.Contains(t, result.startupLog, "are not running")
+}
+
+// (2, 1, 0)
+func TestInitStatus_TwoConfiguredOneWrittenZeroRunning(t *testing.T) {
+	setupMultiProcess(t)
+	defer teardown(t)
+
+	writePids(t, servicePids{multiProcessPrimaryName: 99999})
+	result := runInit(t, "status")
+
+	assert.Equal(t, 1, result.exitCode)
+	assert.Contains(t, result.stderr, "commands")
+	assert.Contains(t, result.stderr, multiProcessPrimaryName)
+	assert.Contains(t, result.stderr, multiProcessSubProcessName)
+	assert.Contains(t, result.stderr, "are not running")
+	assert.Contains(t, result.startupLog, "commands")
+	assert.Contains(t, result.startupLog, multiProcessPrimaryName)
+	assert.Contains(t, result.startupLog, multiProcessSubProcessName)
+	assert.Contains(t, result.startupLog, "are not running")
+}
+
+// (2, 1, 1)
+func TestInitStatus_TwoConfiguredOneWrittenOneRunning(t *testing.T) {
+	setupMultiProcess(t)
+	defer teardown(t)
+
+	writePids(t, servicePids{multiProcessPrimaryName: os.Getpid()})
+	result := runInit(t, "status")
+
+	assert.Equal(t, 1, result.exitCode)
+	assert.Contains(t, result.stderr, fmt.Sprintf("commands '[%s]' are not running", multiProcessSubProcessName))
+	assert.Contains(t, result.startupLog, fmt.Sprintf("commands '[%s]' are not running",
+		multiProcessSubProcessName))
+}
+
+// (2, 2, 0)
+func TestInitStatus_TwoConfiguredTwoWrittenZeroRunning(t *testing.T) {
+	setupMultiProcess(t)
+	defer teardown(t)
+
+	writePids(t, servicePids{multiProcessPrimaryName: os.Getpid(), multiProcessSubProcessName: 99999})
+	result := runInit(t, "status")
+
+	assert.Equal(t, 1, result.exitCode)
+	assert.Contains(t, result.stderr, "commands")
+	assert.Contains(t, result.stderr, multiProcessPrimaryName)
+	assert.Contains(t, result.stderr, multiProcessSubProcessName)
+	assert.Contains(t, result.stderr, "are not running")
+	assert.Contains(t, result.startupLog, "commands")
+	assert.Contains(t, result.startupLog, multiProcessPrimaryName)
+	assert.Contains(t, result.startupLog, multiProcessSubProcessName)
+	assert.Contains(t, result.startupLog, "are not running")
+}
+
+// (2, 2, 1)
+func TestInitStatus_TwoConfiguredTwoWrittenOneRunning(t *testing.T) {
+	setupMultiProcess(t)
+	defer teardown(t)
+
+	writePids(t, servicePids{multiProcessPrimaryName: os.Getpid(), multiProcessSubProcessName: 99999})
+	result := runInit(t, "status")
+
+	assert.Equal(t, 1, result.exitCode)
+	assert.Contains(t, result.stderr, fmt.Sprintf("commands '[%s]' are not running", multiProcessSubProcessName))
+	assert.Contains(t, result.startupLog, fmt.Sprintf("commands '[%s]' are not running",
+		multiProcessSubProcessName))
+}
+
+// (2, 2, 2)
+func TestInitStatus_TwoConfiguredTwoWrittenTwoRunning(t *testing.T) {
+	setupMultiProcess(t)
+	defer teardown(t)
+
+	cmd := exec.Command("/bin/sleep", "10")
+	require.NoError(t, cmd.Start())
+	defer func() {
+		require.NoError(t, cmd.Process.Signal(syscall.SIGKILL))
+	}()
+	writePids(t, servicePids{multiProcessPrimaryName: os.Getpid(), multiProcessSubProcessName: cmd.Process.Pid})
+	result := runInit(t, "status")
+
+	assert.Equal(t, 0, result.exitCode)
+	assert.Empty(t, result.stderr)
+}
+
+func TestInitStatus_FormatJSON(t *testing.T) {
+	setupMultiProcess(t)
+	defer teardown(t)
+
+	writePidRecords(t, map[string]pidRecord{multiProcessPrimaryName: {Pid: os.Getpid(), ConfigHash: "abc123"}})
+	result, stdout := runInitCapturingStdout(t, "status", "--format", "json")
+
+	assert.Equal(t, 1, result.exitCode)
+	var report struct {
+		Status    string `json:"status"`
+		Processes []struct {
+			Name           string  `json:"name"`
+			Pid            int     `json:"pid"`
+			Running        bool    `json:"running"`
+			UptimeSeconds  float64 `json:"uptimeSeconds"`
+			RSSBytes       uint64  `json:"rssBytes"`
+			CPUTimeSeconds float64 `json:"cpuTimeSeconds"`
+			Pidfile        string  `json:"pidfile"`
+			ConfigHash     string  `json:"configHash"`
+		} `json:"processes"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &report), stdout)
+	assert.Equal(t, cli2.Dead.Description, report.Status)
+	require.Len(t, report.Processes, 2)
+
+	primary := report.Processes[0]
+	assert.Equal(t, multiProcessPrimaryName, primary.Name)
+	assert.Equal(t, os.Getpid(), primary.Pid)
+	assert.True(t, primary.Running)
+	assert.True(t, primary.UptimeSeconds > 0)
+	assert.True(t, primary.RSSBytes > 0)
+	assert.True(t, primary.CPUTimeSeconds > 0)
+	assert.Equal(t, fmt.Sprintf(pidfileFormat, multiProcessPrimaryName), primary.Pidfile)
+	assert.Equal(t, "abc123", primary.ConfigHash)
+
+	subProcess := report.Processes[1]
+	assert.Equal(t, multiProcessSubProcessName, subProcess.Name)
+	assert.Equal(t, 0, subProcess.Pid)
+	assert.False(t, subProcess.Running)
+	assert.Equal(t, fmt.Sprintf(pidfileFormat, multiProcessSubProcessName), subProcess.Pidfile)
+}
+
+func TestInitStatus_FormatTable(t *testing.T) {
+	setupSingleProcess(t)
+	defer teardown(t)
+
+	writePids(t, servicePids{singleProcessPrimaryName: os.Getpid()})
+	result, stdout := runInitCapturingStdout(t, "status", "--format", "table")
+
+	assert.Equal(t, 0, result.exitCode)
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	require.Len(t, lines, 2, stdout)
+	assert.Regexp(t, "^NAME +PID +RUNNING +UPTIME +RSS +CPU TIME +PIDFILE +LAST EXIT +CONFIG HASH$", lines[0])
+	assert.Regexp(t, fmt.Sprintf("^%s +%d +true ", singleProcessPrimaryName, os.Getpid()), lines[1])
+}
+
+func TestInitStatus_RestartRequiredAfterConfigChange(t *testing.T) {
+	setupSingleProcess(t)
+	defer teardown(t)
+
+	require.Equal(t, 0, runInit(t, "start").exitCode)
+	pid := readPids(t)[singleProcessPrimaryName]
+	defer func() {
+		proc, _ := os.FindProcess(pid)
+		_ = proc.Signal(syscall.SIGKILL)
+	}()
+
+	result, stdout := runInitCapturingStdout(t, "status", "--verbose")
+	assert.Equal(t, 0, result.exitCode)
+	assert.Equal(t, cli2.Running.Description+"\n", stdout)
+
+	// The custom configuration is a link to the testdata, so it is replaced rather than written to.
+	require.NoError(t, os.Remove(launcherCustomFile))
+	require.NoError(t, ioutil.WriteFile(launcherCustomFile, []byte(`configType: java
+configVersion: 1
+jvmOpts:
+  - '-Xmx1g'
+  - '-Dfoo=bar'
+`), 0644))
+	result, stdout = runInitCapturingStdout(t, "status", "--verbose")
+
+	assert.Equal(t, 0, result.exitCode)
+	assert.Equal(t, fmt.Sprintf("%s\nCommand '%s' was started with arguments other than those it is now configured "+
+		"with\n  + -Dfoo=bar\n", cli2.RestartRequired.Description, singleProcessPrimaryName), stdout)
+
+	result, stdout = runInitCapturingStdout(t, "status", "--format", "json")
+	assert.Equal(t, 0, result.exitCode)
+	assert.Contains(t, stdout, `"restartRequired": true`)
+}
+
+func TestInit_ConfigurablePaths(t *testing.T) {
+	serviceRoot, err := ioutil.TempDir("", "go-init-service")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(serviceRoot))
+	}()
+	logDir, err := ioutil.TempDir("", "go-init-logs")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(logDir))
+	}()
+	copyFile(t, "testdata/launcher-static.yml", filepath.Join(serviceRoot, "conf/static.yml"))
+	copyFile(t, "testdata/launcher-custom.yml", filepath.Join(serviceRoot, "conf/custom.yml"))
+	require.NoError(t, os.Setenv("GO_INIT_CUSTOM_CONFIG", "conf/custom.yml"))
+	defer func() {
+		require.NoError(t, os.Unsetenv("GO_INIT_CUSTOM_CONFIG"))
+	}()
+	args := []string{"--service-root", serviceRoot, "--static-config", "conf/static.yml", "--pid-dir", "run",
+		"--log-dir", logDir}
+	startupLog := filepath.Join(logDir, outputLogFile)
+
+	result := <-runInitWithStartupLog(t, time2.NewRealClock(), startupLog, append(args, "start")...)
+
+	require.Equal(t, 0, result.exitCode, result.startupLog)
+	time.Sleep(time.Second)
+	assert.Contains(t, readFile(t, startupLog), "main method")
+	assert.NoFileExists(t, primaryOutputFile)
+	record := readPidRecord(t, filepath.Join(serviceRoot, "run", singleProcessPrimaryName+".pid"))
+	defer func() {
+		proc, _ := os.FindProcess(record.Pid)
+		_ = proc.Signal(syscall.SIGKILL)
+	}()
+	assert.FileExists(t, filepath.Join(serviceRoot, "run", "go-init.lock"))
+	processDir, err := os.Readlink(fmt.Sprintf("/proc/%d/cwd", record.Pid))
+	require.NoError(t, err)
+	assert.Equal(t, serviceRoot, processDir)
+
+	result = <-runInitWithStartupLog(t, time2.NewRealClock(), startupLog, append(args, "status")...)
+	assert.Equal(t, 0, result.exitCode)
+
+	result = <-runInitWithStartupLog(t, time2.NewRealClock(), startupLog, append(args, "stop")...)
+	assert.Equal(t, 0, result.exitCode)
+	assert.NoFileExists(t, filepath.Join(serviceRoot, "run", singleProcessPrimaryName+".pid"))
+}
+
+func TestInitStatus_InvalidFormat(t *testing.T) {
+	setupSingleProcess(t)
+	defer teardown(t)
+
+	result := runInit(t, "status", "--format", "xml")
+
+	assert.Equal(t, 4, result.exitCode)
+	assert.Contains(t, result.stderr, "invalid format 'xml'")
+}
+
+func TestInitStop_DoesNotTruncateStartupLogFile(t *testing.T) {
+	setup(t)
+	defer teardown(t)
+
+	stringThatShouldRemain := "this should remain in the log file after running"
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(primaryOutputFile), 0755))
+	require.NoError(t, ioutil.WriteFile(primaryOutputFile, []byte(stringThatShouldRemain), 0644))
+	result := runInit(t, "stop")
+
+	assert.Contains(t, result.startupLog, stringThatShouldRemain)
+}
+
+/*
+ * Prior states for stop are defined by pairs of the following: (number of processes written to pidfiles, number of
+ * processes running). As above, for (a, b), only a >= b is a valid input.
+ */
+
+/*
+ * In these tests, stop should exit 0 and do nothing.
+ */
+
+// (0, 0)
+func TestInitStop_ZeroWrittenZeroRunning(t *testing.T) {
+	setupSingleProcess(t)
+	defer teardown(t)
+
+	result := runInit(t, "stop")
+
+	assert.Equal(t, 0, result.exitCode)
+	assert.Empty(t, result.stderr)
+	assert.Empty(t, readPids(t))
+}
+
+// (1, 0)
+func TestInitStop_OneWrittenZeroRunning(t *testing.T) {
+	setupSingleProcess(t)
+	defer teardown(t)
+
+	writePids(t, servicePids{singleProcessPrimaryName: 99999})
+	result := runInit(t, "stop")
+
+	assert.Equal(t, 0, result.exitCode)
+	assert.Empty(t, result.stderr)
+	assert.Empty(t, readPids(t))
+}
+
+// (2, 0)
+func TestInitStop_TwoWrittenZeroRunning(t *testing.T) {
+	setupMultiProcess(t)
+	defer teardown(t)
+
+	writePids(t, servicePids{multiProcessPrimaryName: 99998, multiProcessSubProcessName: 99999})
+	result := runInit(t, "stop")
+
+	assert.Equal(t, 0, result.exitCode)
+	assert.Empty(t, result.stderr)
+	assert.Empty(t, readPids(t))
+}
+
+/*
+ * In these tests, another go-init command holds the lock.
+ */
+
+func TestInitStart_LockContended(t *testing.T) {
+	setupSingleProcess(t)
+	defer teardown(t)
+
+	release := holdLock(t)
+	defer release()
+	result := runInit(t, "--lock-timeout", "500ms", "start")
+
+	assert.Equal(t, 150, result.exitCode)
+	assert.Contains(t, result.stderr, "another go-init command is acting on the service")
+	assert.Contains(t, result.startupLog, "another go-init command is acting on the service")
+	assert.Empty(t, readPids(t))
+}
+
+func TestInitStop_WaitsForLock(t *testing.T) {
+	setupSingleProcess(t)
+	defer teardown(t)
+
+	release := holdLock(t)
+	go func() {
+		time.Sleep(500 * time.Millisecond)
+		release()
+	}()
+	result := runInit(t, "--lock-timeout", "10s", "stop")
+
+	assert.Equal(t, 0, result.exitCode)
+	assert.Empty(t, result.stderr)
+}
+
+func TestInitStatus_DoesNotTakeLock(t *testing.T) {
+	setupSingleProcess(t)
+	defer teardown(t)
+
+	release := holdLock(t)
+	defer release()
+	result := runInit(t, "--lock-timeout", "0s", "status")
+
+	assert.Equal(t, 3, result.exitCode)
+}
+
+/*
+ * In these tests, the pidfile identifies a process other than the one running with its pid.
+ */
+
+func TestInitStatus_ReusedPid(t *testing.T) {
+	setupSingleProcess(t)
+	defer teardown(t)
+
+	writePidRecords(t, map[string]pidRecord{singleProcessPrimaryName: {Pid: os.Getpid(), StartTime: 1}})
+	result := runInit(t, "status")
+
+	assert.Equal(t, 1, result.exitCode)
+	assert.Contains(t, result.stderr, fmt.Sprintf("commands '[%s]' are not running", singleProcessPrimaryName))
+}
+
+func TestInitStop_ReusedPidIsNotSignalled(t *testing.T) {
+	defer teardown(t)
+	setupSingleProcess(t)
+
+	pid, killer := forkUnkillableSleep(t)
+	defer killer()
+	writePidRecords(t, map[string]pidRecord{singleProcessPrimaryName: {Pid: pid, Executable: "/bin/not-sh"}})
+	result := runInit(t, "stop")
+
+	assert.Equal(t, 0, result.exitCode)
+	assert.Empty(t, result.stderr)
+	assert.Empty(t, readPids(t))
+	proc, _ := os.FindProcess(pid)
+	assert.NoError(t, proc.Signal(syscall.Signal(0)), "process reusing the pid should not have been stopped")
+}
+
+/*
+ * In these tests, stop should actually stop something.
+ */
+
+// (1, 1)
+func TestInitStop_Stoppable_OneWrittenOneRunning(t *testing.T) {
+	defer teardown(t)
+	setupSingleProcess(t)
+
+	pid, killer := forkKillableSleep(t)
+	defer killer()
+	writePids(t, servicePids{singleProcessPrimaryName: pid})
+	result := runInit(t, "stop")
+
+	assert.Equal(t, 0, result.exitCode)
+	assert.Empty(t, result.stderr)
+	assert.Empty(t, readPids(t))
+}
+
+func TestInitStop_RunsPreStopActionBeforeSignalling(t *testing.T) {
+	setupWithConfigs(t, "testdata/launcher-static-pre-stop.yml", "testdata/launcher-custom.yml")
+	defer teardown(t)
+
+	require.Equal(t, 0, runInit(t, "start").exitCode)
+	pid := readPids(t)[singleProcessPrimaryName]
+	drained := make(chan bool, 1)
+	listener, err := net.Listen("tcp", "127.0.0.1:47613")
+	require.NoError(t, err)
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/drain", r.URL.Path)
+		drained <- launchlib.IsPidAlive(pid)
+	})}
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer func() {
+		_ = server.Close()
+	}()
+
+	result := runInit(t, "stop")
+	assert.Equal(t, 0, result.exitCode, result.stderr)
+	select {
+	case alive := <-drained:
+		assert.True(t, alive, "the process should not have been signalled before its preStop action")
+	default:
+		assert.Fail(t, "the preStop action was not run")
+	}
+	assert.False(t, launchlib.IsPidAlive(pid))
+}
+
+func TestInitStop_StopsWhenPreStopActionFails(t *testing.T) {
+	setupWithConfigs(t, "testdata/launcher-static-pre-stop.yml", "testdata/launcher-custom.yml")
+	defer teardown(t)
+
+	require.Equal(t, 0, runInit(t, "start").exitCode)
+	pid := readPids(t)[singleProcessPrimaryName]
+
+	result := runInit(t, "stop")
+	assert.Equal(t, 0, result.exitCode, result.stderr)
+	assert.False(t, launchlib.IsPidAlive(pid))
+	assert.Contains(t, readStartupLog(t), fmt.Sprintf("preStop action of '%s' failed, stopping it regardless",
+		singleProcessPrimaryName))
+}
+
+// (2, 1)
+func TestInitStop_Stoppable_TwoWrittenOneRunning(t *testing.T) {
+	defer teardown(t)
+	setupMultiProcess(t)
+
+	pid, killer := forkKillableSleep(t)
+	defer killer()
+	writePids(t, servicePids{multiProcessPrimaryName: pid})
+	result := runInit(t, "stop")
+
+	assert.Equal(t, 0, result.exitCode)
+	assert.Empty(t, result.stderr)
+	assert.Empty(t, readPids(t))
+}
+
+// (2, 2)
+func TestInitStop_Stoppable_TwoWrittenTwoRunning(t *testing.T) {
+	defer teardown(t)
+	setupMultiProcess(t)
+
+	pid1, killer1 := forkKillableSleep(t)
+	defer killer1()
+	pid2, killer2 := forkKillableSleep(t)
+	defer killer2()
+
+	writePids(t, servicePids{multiProcessPrimaryName: pid1, multiProcessSubProcessName: pid2})
+	result := runInit(t, "stop")
+
+	assert.Equal(t, 0, result.exitCode)
+	assert.Empty(t, result.stderr)
+	assert.Empty(t, readPids(t))
+}
+
+// (1, 1)
+func TestInitStop_Unstoppable_OneWrittenOneRunning(t *testing.T) {
+	defer teardown(t)
+	setupSingleProcess(t)
+
+	pid, killer := forkUnkillableSleep(t)
+	defer killer()
+	writePids(t, servicePids{singleProcessPrimaryName: pid})
+
+	result := runStopAssertTimesOut(t)
+
+	assert.Equal(t, 0, result.exitCode)
+	assert.Empty(t, result.stderr)
+	assert.Contains(t, result.startupLog, "processes")
+	assert.Contains(t, result.startupLog, "did not stop within 240 seconds, so a SIGKILL was sent")
+
+	events := readEvents(t)
+	require.NotEmpty(t, events)
+	assert.Equal(t, launchlib.Event{
+		Time:    events[len(events)-1].Time,
+		Type:    launchlib.EventKillEscalated,
+		Source:  "go-init",
+		Process: singleProcessPrimaryName,
+		Pid:     pid,
+		Signal:  int(syscall.SIGKILL),
+		Message: "did not stop within 4m0s",
+	}, events[len(events)-1])
+}
+
+// (2, 1)
+func TestInitStop_Unstoppable_TwoWrittenOneRunning(t *testing.T) {
+	defer teardown(t)
+	setupMultiProcess(t)
+
+	pid, killer := forkUnkillableSleep(t)
+	defer killer()
+	writePids(t, servicePids{multiProcessPrimaryName: pid, multiProcessSubProcessName: 99999})
+	result := runStopAssertTimesOut(t)
+
+	assert.Equal(t, 0, result.exitCode)
+	assert.Empty(t, result.stderr)
+	assert.Contains(t, result.startupLog, "processes")
+	assert.Contains(t, result.startupLog, "did not stop within 240 seconds, so a SIGKILL was sent")
+}
+
+// (2, 2)
+func TestInitStop_Unstoppable_TwoWrittenTwoRunning(t *testing.T) {
+	defer teardown(t)
+	setupMultiProcess(t)
+
+	pid1, killer1 := forkUnkillableSleep(t)
+	defer killer1()
+	pid2, killer2 := forkUnkillableSleep(t)
+	defer killer2()
+
+	writePids(t, servicePids{multiProcessPrimaryName: pid1, multiProcessSubProcessName: pid2})
+	result := runStopAssertTimesOut(t)
+
+	assert.Equal(t, 0, result.exitCode)
+	assert.Empty(t, result.stderr)
+	assert.Contains(t, result.startupLog, "processes")
+	assert.Contains(t, result.startupLog, "did not stop within 240 seconds, so a SIGKILL was sent")
+}
+
+/*
+ * In these tests, stop should respect the configured stop timeouts and signals.
+ */
+
+func TestInitStop_Unstoppable_StaticStopTimeout(t *testing.T) {
+	defer teardown(t)
+	setupWithConfigs(t, "testdata/launcher-static-stop-timeout.yml", "testdata/launcher-custom.yml")
+
+	pid, killer := forkUnkillableSleep(t)
+	defer killer()
+	writePids(t, servicePids{singleProcessPrimaryName: pid})
+
+	result := runStopAssertTimesOutAfter(t, 10*time.Second)
+
+	assert.Equal(t, 0, result.exitCode)
+	assert.Empty(t, result.stderr)
+	assert.Contains(t, result.startupLog,
+		fmt.Sprintf("processes '[%s]' did not stop within 10 seconds, so a SIGKILL was sent", singleProcessPrimaryName))
+}
+
+func TestInitStop_Unstoppable_CustomStopTimeoutOverridesStatic(t *testing.T) {
+	defer teardown(t)
+	setupWithConfigs(t, "testdata/launcher-static-stop-timeout.yml", "testdata/launcher-custom-stop-timeout.yml")
+
+	pid, killer := forkUnkillableSleep(t)
+	defer killer()
+	writePids(t, servicePids{singleProcessPrimaryName: pid})
+
+	result := runStopAssertTimesOutAfter(t, 20*time.Second)
+
+	assert.Equal(t, 0, result.exitCode)
+	assert.Contains(t, result.startupLog, "did not stop within 20 seconds, so a SIGKILL was sent")
+}
+
+func TestInitStop_Unstoppable_TimeoutFlagOverridesConfig(t *testing.T) {
+	defer teardown(t)
+	setupWithConfigs(t, "testdata/launcher-static-stop-timeout.yml", "testdata/launcher-custom-stop-timeout.yml")
+
+	pid, killer := forkUnkillableSleep(t)
+	defer killer()
+	writePids(t, servicePids{singleProcessPrimaryName: pid})
+
+	result := runStopAssertTimesOutAfter(t, 30*time.Second, "--timeout", "30s")
+
+	assert.Equal(t, 0, result.exitCode)
+	assert.Contains(t, result.startupLog, "did not stop within 30 seconds, so a SIGKILL was sent")
+}
+
+func TestInitStop_Unstoppable_PerProcessStopTimeouts(t *testing.T) {
+	defer teardown(t)
+	setupWithConfigs(t, "testdata/launcher-static-multiprocess-stop-timeout.yml",
+		"testdata/launcher-custom-multiprocess.yml")
+
+	pid1, killer1 := forkUnkillableSleep(t)
+	defer killer1()
+	pid2, killer2 := forkUnkillableSleep(t)
+	defer killer2()
+	writePids(t, servicePids{multiProcessPrimaryName: pid1, multiProcessSubProcessName: pid2})
+
+	clock := time2.NewFakeClock()
+	initChan := runInitWithClock(t, clock, "stop")
+	clock.BlockUntil(3) // wait for both timers and the ticker to attach
+	clock.Advance(10 * time.Second)
+	require.Nil(t, readFromChannel(initChan, 1*time.Second), "Expected `stop` to wait for the subProcess")
+	clock.Advance(230 * time.Second)
+	result := readFromChannel(initChan, 1*time.Second)
+	require.NotNil(t, result, "Expected `stop` to finish after 240 seconds")
+
+	assert.Equal(t, 0, result.exitCode)
+	assert.Contains(t, result.startupLog, fmt.Sprintf(
+		"processes '[%s]' did not stop within 10 seconds, so a SIGKILL was sent", multiProcessPrimaryName))
+	assert.Contains(t, result.startupLog, fmt.Sprintf(
+		"processes '[%s]' did not stop within 240 seconds, so a SIGKILL was sent", multiProcessSubProcessName))
+}
+
+func TestInitStop_Unstoppable_ThreadDumpBeforeKill(t *testing.T) {
+	defer teardown(t)
+	setupWithConfigs(t, "testdata/launcher-static-stop-timeout.yml", "testdata/launcher-custom.yml")
+
+	pid, killer := forkUnkillableSleep(t)
+	defer killer()
+	writePids(t, servicePids{singleProcessPrimaryName: pid})
+
+	const threadDumpMessage = "have not stopped, so a SIGQUIT was sent to capture a thread dump before sending a SIGKILL"
+	clock := time2.NewFakeClock()
+	initChan := runInitWithClock(t, clock, "stop")
+	clock.BlockUntil(2) // wait for timer and ticker to attach
+	clock.Advance(4 * time.Second)
+	assert.NotContains(t, readStartupLog(t), threadDumpMessage)
+
+	// The thread dump is captured 5 seconds before the 10 second stop timeout
+	clock.Advance(1 * time.Second)
+	assert.Eventually(t, func() bool {
+		return strings.Contains(readStartupLog(t), threadDumpMessage)
+	}, 5*time.Second, 100*time.Millisecond)
+	require.Nil(t, readFromChannel(initChan, 1*time.Second), "Expected `stop` to still wait after 5 seconds")
+
+	clock.Advance(5 * time.Second)
+	result := readFromChannel(initChan, 1*time.Second)
+	require.NotNil(t, result, "Expected `stop` to finish after 10 seconds")
+	assert.Equal(t, 0, result.exitCode)
+	assert.Contains(t, result.startupLog, "did not stop within 10 seconds, so a SIGKILL was sent")
+}
+
+func TestInitStop_Unstoppable_ThreadDumpDisabled(t *testing.T) {
+	defer teardown(t)
+	setupWithConfigs(t, "testdata/launcher-static-stop-timeout.yml",
+		"testdata/launcher-custom-disable-thread-dump.yml")
+
+	pid, killer := forkUnkillableSleep(t)
+	defer killer()
+	writePids(t, servicePids{singleProcessPrimaryName: pid})
+
+	result := runStopAssertTimesOutAfter(t, 10*time.Second)
+
+	assert.Equal(t, 0, result.exitCode)
+	assert.NotContains(t, result.startupLog, "SIGQUIT")
+	assert.Contains(t, result.startupLog, "did not stop within 10 seconds, so a SIGKILL was sent")
+}
+
+func TestInitStop_ConfiguredStopSignal(t *testing.T) {
+	defer teardown(t)
+	setupWithConfigs(t, "testdata/launcher-static-stop-signal.yml", "testdata/launcher-custom.yml")
+
+	cmd := exec.Command("/bin/sleep", "10")
+	require.NoError(t, cmd.Start())
+	waitErr := make(chan error, 1)
+	go func() {
+		// Reap the process as soon as it exits so that stop does not observe a zombie
+		waitErr <- cmd.Wait()
+	}()
+	writePids(t, servicePids{singleProcessPrimaryName: cmd.Process.Pid})
+
+	result := runInitAdvancingClock(t, "stop")
+
+	assert.Equal(t, 0, result.exitCode)
+	assert.Empty(t, result.stderr)
+	assert.Empty(t, readPids(t))
+	exitErr, ok := (<-waitErr).(*exec.ExitError)
+	require.True(t, ok, "expected sleep to be terminated by a signal")
+	assert.Equal(t, syscall.SIGINT, exitErr.Sys().(syscall.WaitStatus).Signal())
+}
+
+/*
+ * Restart stops whatever is running and starts every configured process.
+ */
+
+// (1, 0)
+func TestInitRestart_ZeroWrittenZeroRunning(t *testing.T) {
+	setupSingleProcess(t)
+	defer teardown(t)
+
+	result := runInit(t, "restart")
+
+	assert.Equal(t, 0, result.exitCode)
+	assert.Empty(t, result.stderr)
+	pids := readPids(t)
+	require.Len(t, pids, 1)
+	assert.Equal(t, pgrepStartedPid(t, "testdata"), pids[singleProcessPrimaryName])
+
+	proc, _ := os.FindProcess(pids[singleProcessPrimaryName])
+	require.NoError(t, proc.Signal(syscall.SIGKILL))
+}
+
+// (1, 1)
+func TestInitRestart_Unstoppable_OneWrittenOneRunning(t *testing.T) {
+	defer teardown(t)
+	setupSingleProcess(t)
+
+	pid, killer := forkUnkillableSleep(t)
+	defer killer()
+	writePids(t, servicePids{singleProcessPrimaryName: pid})
+
+	clock := time2.NewFakeClock()
+	initChan := runInitWithClock(t, clock, "restart")
+	clock.BlockUntil(2)
+	clock.Advance(240 * time.Second)
+	// The restarted process is then watched for the startup confirmation window.
+	result := advanceClockUntilFinished(t, clock, initChan)
+
+	assert.Equal(t, 0, result.exitCode)
+	assert.Empty(t, result.stderr)
+	assert.Contains(t, result.startupLog, "did not stop within 240 seconds, so a SIGKILL was sent")
+	pids := readPids(t)
+	require.Len(t, pids, 1)
+	assert.NotEqual(t, pid, pids[singleProcessPrimaryName])
+	assert.Equal(t, pgrepStartedPid(t, "testdata"), pids[singleProcessPrimaryName])
 
-/*
- * In these tests, stop should exit 0 and do nothing.
- */
+	proc, _ := os.FindProcess(pids[singleProcessPrimaryName])
+	require.NoError(t, proc.Signal(syscall.SIGKILL))
+}
 
-// (0, 0)
-func TestInitStop_ZeroWrittenZeroRunning(t *testing.T) {
-	setupSingleProcess(t)
+// (2, 1)
+func TestInitRestart_Stoppable_TwoWrittenOneRunning(t *testing.T) {
 	defer teardown(t)
+	setupMultiProcess(t)
 
-	result := runInit(t, "stop")
+	pid, killer := forkKillableSleep(t)
+	defer killer()
+	writePids(t, servicePids{multiProcessPrimaryName: pid})
+
+	result := runInitAdvancingClock(t, "restart")
 
 	assert.Equal(t, 0, result.exitCode)
 	assert.Empty(t, result.stderr)
-	assert.Empty(t, readPids(t))
+	pids := readPids(t)
+	require.Len(t, pids, 2)
+	assert.NotEqual(t, pid, pids[multiProcessPrimaryName])
+	assertContainSameElements(t, pgrepStartedPids(t, "testdata"),
+		[]int{pids[multiProcessPrimaryName], pids[multiProcessSubProcessName]})
+
+	proc, _ := os.FindProcess(pids[multiProcessPrimaryName])
+	require.NoError(t, proc.Signal(syscall.SIGKILL))
+	proc, _ = os.FindProcess(pids[multiProcessSubProcessName])
+	require.NoError(t, proc.Signal(syscall.SIGKILL))
 }
 
-// (1, 0)
-func TestInitStop_OneWrittenZeroRunning(t *testing.T) {
+// (0, 0)
+func TestInitTryRestart_ZeroWrittenZeroRunning(t *testing.T) {
 	setupSingleProcess(t)
 	defer teardown(t)
 
-	writePids(t, servicePids{singleProcessPrimaryName: 99999})
-	result := runInit(t, "stop")
+	result := runInit(t, "try-restart")
 
 	assert.Equal(t, 0, result.exitCode)
 	assert.Empty(t, result.stderr)
+	assert.Contains(t, result.startupLog, "Service not running, so it will not be restarted")
 	assert.Empty(t, readPids(t))
 }
 
-// (2, 0)
-func TestInitStop_TwoWrittenZeroRunning(t *testing.T) {
-	setupMultiProcess(t)
+// (1, 0)
+func TestInitTryRestart_OneWrittenZeroRunning(t *testing.T) {
+	setupSingleProcess(t)
 	defer teardown(t)
 
-	writePids(t, servicePids{multiProcessPrimaryName: 99998, multiProcessSubProcessName: 99999})
-	result := runInit(t, "stop")
+	pid := exitedPid(t)
+	writePids(t, servicePids{singleProcessPrimaryName: pid})
+	result := runInit(t, "try-restart")
 
 	assert.Equal(t, 0, result.exitCode)
 	assert.Empty(t, result.stderr)
-	assert.Empty(t, readPids(t))
+	assert.Contains(t, result.startupLog, "Service not running, so it will not be restarted")
+	assert.Equal(t, servicePids{singleProcessPrimaryName: pid}, readPids(t))
 }
 
-/*
- * In these tests, stop should actually stop something.
- */
-
 // (1, 1)
-func TestInitStop_Stoppable_OneWrittenOneRunning(t *testing.T) {
+func TestInitTryRestart_Stoppable_OneWrittenOneRunning(t *testing.T) {
 	defer teardown(t)
 	setupSingleProcess(t)
 
 	pid, killer := forkKillableSleep(t)
 	defer killer()
 	writePids(t, servicePids{singleProcessPrimaryName: pid})
-	result := runInit(t, "stop")
+
+	result := runInitAdvancingClock(t, "try-restart")
 
 	assert.Equal(t, 0, result.exitCode)
 	assert.Empty(t, result.stderr)
-	assert.Empty(t, readPids(t))
+	pids := readPids(t)
+	require.Len(t, pids, 1)
+	assert.NotEqual(t, pid, pids[singleProcessPrimaryName])
+	assert.Equal(t, pgrepStartedPid(t, "testdata"), pids[singleProcessPrimaryName])
+
+	proc, _ := os.FindProcess(pids[singleProcessPrimaryName])
+	require.NoError(t, proc.Signal(syscall.SIGKILL))
 }
 
 // (2, 1)
-func TestInitStop_Stoppable_TwoWrittenOneRunning(t *testing.T) {
+func TestInitTryRestart_Stoppable_TwoWrittenOneRunning(t *testing.T) {
 	defer teardown(t)
 	setupMultiProcess(t)
 
 	pid, killer := forkKillableSleep(t)
 	defer killer()
 	writePids(t, servicePids{multiProcessPrimaryName: pid})
-	result := runInit(t, "stop")
+
+	result := runInitAdvancingClock(t, "try-restart")
 
 	assert.Equal(t, 0, result.exitCode)
 	assert.Empty(t, result.stderr)
-	assert.Empty(t, readPids(t))
+	pids := readPids(t)
+	require.Len(t, pids, 2)
+	assert.NotEqual(t, pid, pids[multiProcessPrimaryName])
+	assertContainSameElements(t, pgrepStartedPids(t, "testdata"),
+		[]int{pids[multiProcessPrimaryName], pids[multiProcessSubProcessName]})
+
+	proc, _ := os.FindProcess(pids[multiProcessPrimaryName])
+	require.NoError(t, proc.Signal(syscall.SIGKILL))
+	proc, _ = os.FindProcess(pids[multiProcessSubProcessName])
+	require.NoError(t, proc.Signal(syscall.SIGKILL))
 }
 
-// (2, 2)
-func TestInitStop_Stoppable_TwoWrittenTwoRunning(t *testing.T) {
+/*
+ * Reload signals whatever is running, and exits 7 if nothing is.
+ */
+
+// (0, 0)
+func TestInitReload_ZeroWrittenZeroRunning(t *testing.T) {
+	setupSingleProcess(t)
 	defer teardown(t)
-	setupMultiProcess(t)
 
-	pid1, killer1 := forkKillableSleep(t)
-	defer killer1()
-	pid2, killer2 := forkKillableSleep(t)
-	defer killer2()
+	result := runInit(t, "reload")
 
-	writePids(t, servicePids{multiProcessPrimaryName: pid1, multiProcessSubProcessName: pid2})
-	result := runInit(t, "stop")
+	assert.Equal(t, 7, result.exitCode)
+	assert.Contains(t, result.stderr, fmt.Sprintf("commands '[%s]' are not running", singleProcessPrimaryName))
+	assert.Contains(t, result.startupLog, fmt.Sprintf("commands '[%s]' are not running", singleProcessPrimaryName))
+}
+
+// (1, 1)
+func TestInitReload_OneWrittenOneRunning(t *testing.T) {
+	setupSingleProcess(t)
+	defer teardown(t)
+
+	cmd := exec.Command("/bin/sleep", "10")
+	require.NoError(t, cmd.Start())
+	writePids(t, servicePids{singleProcessPrimaryName: cmd.Process.Pid})
+	result := runInit(t, "reload")
 
 	assert.Equal(t, 0, result.exitCode)
 	assert.Empty(t, result.stderr)
-	assert.Empty(t, readPids(t))
+	assertTerminatedBySignal(t, cmd, syscall.SIGHUP)
 }
 
-// (1, 1)
-func TestInitStop_Unstoppable_OneWrittenOneRunning(t *testing.T) {
+// (2, 1)
+func TestInitReload_TwoWrittenOneRunning(t *testing.T) {
+	setupMultiProcess(t)
 	defer teardown(t)
-	setupSingleProcess(t)
 
-	pid, killer := forkUnkillableSleep(t)
-	defer killer()
-	writePids(t, servicePids{singleProcessPrimaryName: pid})
+	cmd := exec.Command("/bin/sleep", "10")
+	require.NoError(t, cmd.Start())
+	exited := exitedPid(t)
+	writePids(t, servicePids{multiProcessPrimaryName: cmd.Process.Pid, multiProcessSubProcessName: exited})
+	result := runInit(t, "reload")
 
-	result := runStopAssertTimesOut(t)
+	assert.Equal(t, 0, result.exitCode)
+	assert.Empty(t, result.stderr)
+	assertTerminatedBySignal(t, cmd, syscall.SIGHUP)
+	assert.Equal(t, servicePids{multiProcessPrimaryName: cmd.Process.Pid, multiProcessSubProcessName: exited},
+		readPids(t))
+}
+
+// (1, 1)
+func TestInitReload_CustomSignal(t *testing.T) {
+	setupSingleProcess(t)
+	defer teardown(t)
+
+	cmd := exec.Command("/bin/sleep", "10")
+	require.NoError(t, cmd.Start())
+	writePids(t, servicePids{singleProcessPrimaryName: cmd.Process.Pid})
+	result := runInit(t, "reload", "--signal", "USR1")
 
 	assert.Equal(t, 0, result.exitCode)
 	assert.Empty(t, result.stderr)
-	assert.Contains(t, result.startupLog, "processes")
-	assert.Contains(t, result.startupLog, "did not stop within 240 seconds, so a SIGKILL was sent")
+	assertTerminatedBySignal(t, cmd, syscall.SIGUSR1)
 }
 
-// (2, 1)
-func TestInitStop_Unstoppable_TwoWrittenOneRunning(t *testing.T) {
+func TestInitReload_InvalidSignal(t *testing.T) {
+	setupSingleProcess(t)
+	defer teardown(t)
+
+	result := runInit(t, "reload", "--signal", "SIGFOO")
+
+	assert.Equal(t, 2, result.exitCode)
+	assert.Contains(t, result.stderr, "unsupported signal 'SIGFOO'")
+}
+
+func TestInitReload_SignalNumberOutOfRange(t *testing.T) {
+	setupSingleProcess(t)
+	defer teardown(t)
+
+	result := runInit(t, "reload", "--signal", "1000")
+
+	assert.Equal(t, 2, result.exitCode)
+	assert.Contains(t, result.stderr, "invalid signal number 1000")
+}
+
+// (0, 0)
+func TestInitForceReload_ZeroWrittenZeroRunning(t *testing.T) {
+	setupMultiProcess(t)
 	defer teardown(t)
+
+	result := runInit(t, "force-reload")
+
+	assert.Equal(t, 7, result.exitCode)
+	assert.Contains(t, result.stderr, "are not running")
+}
+
+// (2, 0)
+func TestInitForceReload_TwoWrittenZeroRunning(t *testing.T) {
 	setupMultiProcess(t)
+	defer teardown(t)
 
-	pid, killer := forkUnkillableSleep(t)
-	defer killer()
-	writePids(t, servicePids{multiProcessPrimaryName: pid, multiProcessSubProcessName: 99999})
-	result := runStopAssertTimesOut(t)
+	writePids(t, servicePids{multiProcessPrimaryName: exitedPid(t), multiProcessSubProcessName: exitedPid(t)})
+	result := runInit(t, "force-reload")
+
+	assert.Equal(t, 7, result.exitCode)
+	assert.Contains(t, result.stderr, "are not running")
+	assert.NotContains(t, result.startupLog, "so the service will be restarted")
+}
+
+// (2, 2)
+func TestInitForceReload_TwoWrittenTwoRunning(t *testing.T) {
+	setupMultiProcess(t)
+	defer teardown(t)
+
+	cmd1 := exec.Command("/bin/sleep", "10")
+	require.NoError(t, cmd1.Start())
+	cmd2 := exec.Command("/bin/sleep", "10")
+	require.NoError(t, cmd2.Start())
+	writePids(t, servicePids{multiProcessPrimaryName: cmd1.Process.Pid, multiProcessSubProcessName: cmd2.Process.Pid})
+	result := runInit(t, "force-reload")
 
 	assert.Equal(t, 0, result.exitCode)
 	assert.Empty(t, result.stderr)
-	assert.Contains(t, result.startupLog, "processes")
-	assert.Contains(t, result.startupLog, "did not stop within 240 seconds, so a SIGKILL was sent")
+	assertTerminatedBySignal(t, cmd1, syscall.SIGHUP)
+	assertTerminatedBySignal(t, cmd2, syscall.SIGHUP)
 }
 
-// (2, 2)
-func TestInitStop_Unstoppable_TwoWrittenTwoRunning(t *testing.T) {
+// (2, 1)
+func TestInitForceReload_Stoppable_TwoWrittenOneRunning(t *testing.T) {
 	defer teardown(t)
 	setupMultiProcess(t)
 
-	pid1, killer1 := forkUnkillableSleep(t)
-	defer killer1()
-	pid2, killer2 := forkUnkillableSleep(t)
-	defer killer2()
+	pid, killer := forkKillableSleep(t)
+	defer killer()
+	writePids(t, servicePids{multiProcessPrimaryName: pid})
 
-	writePids(t, servicePids{multiProcessPrimaryName: pid1, multiProcessSubProcessName: pid2})
-	result := runStopAssertTimesOut(t)
+	result := runInitAdvancingClock(t, "force-reload")
 
 	assert.Equal(t, 0, result.exitCode)
 	assert.Empty(t, result.stderr)
-	assert.Contains(t, result.startupLog, "processes")
-	assert.Contains(t, result.startupLog, "did not stop within 240 seconds, so a SIGKILL was sent")
+	assert.Contains(t, result.startupLog, "so the service will be restarted")
+	pids := readPids(t)
+	require.Len(t, pids, 2)
+	assertContainSameElements(t, pgrepStartedPids(t, "testdata"),
+		[]int{pids[multiProcessPrimaryName], pids[multiProcessSubProcessName]})
+
+	proc, _ := os.FindProcess(pids[multiProcessPrimaryName])
+	require.NoError(t, proc.Signal(syscall.SIGKILL))
+	proc, _ = os.FindProcess(pids[multiProcessSubProcessName])
+	require.NoError(t, proc.Signal(syscall.SIGKILL))
 }
 
 func forkKillableSleep(t *testing.T) (pid int, killer func()) {
@@ -758,6 +2181,13 @@ func forkUnkillableSleep(t *testing.T) (pid int, killer func()) {
 	return forkAndGetPid(t, exec.Command("testdata/unstoppable.sh"), syscall.SIGKILL)
 }
 
+// Returns the pid of a process that has already exited, as left behind in a stale pidfile.
+func exitedPid(t *testing.T) int {
+	cmd := exec.Command("/bin/true")
+	require.NoError(t, cmd.Run())
+	return cmd.Process.Pid
+}
+
 // The returned 'killer' waits for the process to end (after killing), and asserts that it was killed by the
 // expectedSignal.
 func forkAndGetPid(t *testing.T, command *exec.Cmd, expectedSignal syscall.Signal) (pid int, killer func()) {
@@ -843,6 +2273,10 @@ func runInit(t *testing.T, args ...string) initResult {
 }
 
 func runInitWithClock(t *testing.T, clock time2.Clock, args ...string) <-chan initResult {
+	return runInitWithStartupLog(t, clock, primaryOutputFile, args...)
+}
+
+func runInitWithStartupLog(t *testing.T, clock time2.Clock, startupLog string, args ...string) <-chan initResult {
 	var errbuf bytes.Buffer
 	cli2.Clock = clock
 	app := cli2.App()
@@ -851,13 +2285,89 @@ func runInitWithClock(t *testing.T, clock time2.Clock, args ...string) <-chan in
 	out := make(chan initResult)
 	go func() {
 		// Empty string as placeholder for executable path as would be the case in real invocation
-		exitCode := app.Run(append([]string{""}, args...))
+		exitCode := cli2.Run(app, append([]string{""}, args...))
 		stderr := errbuf.String()
-		out <- initResult{exitCode: exitCode, stderr: stderr, startupLog: readStartupLog(t)}
+		// Commands such as exec do not write to the startup log, so may be run before it exists.
+		startupLogBytes, err := ioutil.ReadFile(startupLog)
+		if !os.IsNotExist(err) {
+			require.NoError(t, err)
+		}
+		out <- initResult{exitCode: exitCode, stderr: stderr, startupLog: string(startupLogBytes)}
 	}()
 	return out
 }
 
+// runInitCapturingStdout runs go-init and returns what it printed to stdout along with its result.
+func runInitCapturingStdout(t *testing.T, args ...string) (initResult, string) {
+	stdoutFile, err := ioutil.TempFile("", "go-init-stdout")
+	require.NoError(t, err)
+	defer func() {
+		_ = stdoutFile.Close()
+		_ = os.Remove(stdoutFile.Name())
+	}()
+	stdout := os.Stdout
+	os.Stdout = stdoutFile
+	result := runInit(t, args...)
+	os.Stdout = stdout
+
+	stdoutBytes, err := ioutil.ReadFile(stdoutFile.Name())
+	require.NoError(t, err)
+	return result, string(stdoutBytes)
+}
+
+func readFile(t *testing.T, path string) string {
+	content, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	return string(content)
+}
+
+// waitForPids waits for the given number of pidfiles to be written, and returns their pids.
+func waitForPids(t *testing.T, count int) servicePids {
+	for i := 0; i < 100; i++ {
+		if pids := readPids(t); len(pids) == count {
+			return pids
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	require.Fail(t, "pidfiles were not written", "expected %d pidfiles, found %v", count, readPids(t))
+	return nil
+}
+
+// listenNotifySocket listens on a datagram socket that is set as the systemd notify socket for the rest of the test,
+// and returns a channel that receives each notification sent to it.
+func listenNotifySocket(t *testing.T) <-chan string {
+	socket := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+	t.Setenv(launchlib.NotifySocketEnvVar, socket)
+
+	notifications := make(chan string, 100)
+	go func() {
+		defer close(notifications)
+		buf := make([]byte, 4096)
+		for {
+			n, err := conn.Read(buf)
+			if err != nil {
+				return
+			}
+			notifications <- string(buf[:n])
+		}
+	}()
+	return notifications
+}
+
+func isRunning(pid int) bool {
+	return syscall.Kill(pid, syscall.Signal(0)) == nil
+}
+
+func copyFile(t *testing.T, src, dst string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(dst), 0755))
+	require.NoError(t, ioutil.WriteFile(dst, []byte(readFile(t, src)), 0644))
+}
+
 func readStartupLog(t *testing.T) string {
 	startupLogBytes, err := ioutil.ReadFile(primaryOutputFile)
 	require.NoError(t, err)
@@ -871,6 +2381,26 @@ func writePids(t *testing.T, pids servicePids) {
 	}
 }
 
+func readEvents(t *testing.T) []launchlib.Event {
+	file, err := os.Open(filepath.Join(logDir, launchlib.EventLogFileName))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, file.Close())
+	}()
+
+	var events []launchlib.Event
+	decoder := json.NewDecoder(file)
+	for decoder.More() {
+		var event launchlib.Event
+		require.NoError(t, decoder.Decode(&event))
+		events = append(events, event)
+	}
+	return events
+}
+
 func readPids(t *testing.T) servicePids {
 	pids := servicePids{}
 
@@ -879,7 +2409,8 @@ func readPids(t *testing.T) servicePids {
 			return err
 		}
 
-		if path == pidfolder {
+		if path == pidfolder || path == lockFile || strings.HasSuffix(path, ".exit") ||
+			strings.HasSuffix(path, ".history") {
 			return nil
 		}
 
@@ -887,12 +2418,7 @@ func readPids(t *testing.T) servicePids {
 		require.Len(t, parts, 2, "invalid pidfile format, does not have only a name and extension")
 		require.Equal(t, parts[1], "pid", "invalid pidfile format, does not end with .pid")
 
-		pidBytes, err := ioutil.ReadFile(path)
-		require.NoError(t, err, "failed to read pidfile %s", path)
-		pid, err := strconv.Atoi(string(pidBytes))
-		require.NoError(t, err, "pidfile '%s', did not contain an integer", path)
-
-		pids[parts[0]] = pid
+		pids[parts[0]] = readPidRecord(t, path).Pid
 		return nil
 	})
 
@@ -902,8 +2428,95 @@ func readPids(t *testing.T) servicePids {
 	return pids
 }
 
-func pgrepSinglePid(t *testing.T, key string, ppid int) int {
-	return pgrepMultiPids(t, key, ppid)[0]
+// holdLock takes the lock that go-init commands take, as another go-init command would, and returns a function that
+// releases it.
+func holdLock(t *testing.T) (release func()) {
+	require.NoError(t, os.MkdirAll(pidfolder, 0755))
+	lock, err := os.OpenFile(lockFile, os.O_CREATE|os.O_RDWR, 0644)
+	require.NoError(t, err)
+	require.NoError(t, syscall.Flock(int(lock.Fd()), syscall.LOCK_EX|syscall.LOCK_NB))
+	return func() {
+		_ = lock.Close()
+	}
+}
+
+// pidRecord is the content of a pidfile written by go-init.
+type pidRecord struct {
+	Pid        int      `json:"pid"`
+	StartTime  uint64   `json:"startTime"`
+	Executable string   `json:"executable"`
+	ConfigHash string   `json:"configHash"`
+	Args       []string `json:"args,omitempty"`
+}
+
+// exitRecord is the content of an exit file written by go-init.
+type exitRecord struct {
+	Pid        int       `json:"pid"`
+	ExitCode   int       `json:"exitCode"`
+	Signal     int       `json:"signal"`
+	CoreDumped bool      `json:"coreDumped"`
+	StartedAt  time.Time `json:"startedAt"`
+	ExitedAt   time.Time `json:"exitedAt"`
+}
+
+func readExitRecord(t *testing.T, name string) exitRecord {
+	var record exitRecord
+	require.NoError(t, json.Unmarshal([]byte(readFile(t, fmt.Sprintf(exitFileFormat, name))), &record))
+	return record
+}
+
+func readPidRecord(t *testing.T, path string) pidRecord {
+	pidBytes, err := ioutil.ReadFile(path)
+	require.NoError(t, err, "failed to read pidfile %s", path)
+	if pid, err := strconv.Atoi(string(pidBytes)); err == nil {
+		return pidRecord{Pid: pid}
+	}
+	var record pidRecord
+	require.NoError(t, json.Unmarshal(pidBytes, &record), "pidfile '%s' did not contain an integer or a record",
+		path)
+	return record
+}
+
+func writePidRecords(t *testing.T, records map[string]pidRecord) {
+	require.NoError(t, os.MkdirAll(pidfolder, 0755))
+	for name, record := range records {
+		recordBytes, err := json.Marshal(record)
+		require.NoError(t, err)
+		require.NoError(t, ioutil.WriteFile(fmt.Sprintf(pidfileFormat, name), recordBytes, 0644))
+	}
+}
+
+// pgrepPids returns the pids of the processes whose command line matches the pattern given along with any other pgrep
+// options.
+func pgrepPids(t *testing.T, args ...string) []int {
+	pidBytes, err := exec.Command("pgrep", append([]string{"-f"}, args...)...).Output()
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+		return nil
+	}
+	require.NoError(t, err)
+	var pids []int
+	for _, pidString := range strings.Fields(string(pidBytes)) {
+		pid, err := strconv.Atoi(pidString)
+		require.NoError(t, err)
+		pids = append(pids, pid)
+	}
+	return pids
+}
+
+// pgrepStartedPid returns the pid of the process matching the given key that was started by go-init, which is run
+// in-process, through a shim.
+func pgrepStartedPid(t *testing.T, key string) int {
+	return pgrepStartedPids(t, key)[0]
+}
+
+// pgrepStartedPids returns the pids of the processes matching the given key that were started by go-init, which is run
+// in-process, through shims.
+func pgrepStartedPids(t *testing.T, key string) []int {
+	var pids []int
+	for _, shimPid := range pgrepMultiPids(t, "TestInitHelperProcess.* shim", os.Getpid()) {
+		pids = append(pids, pgrepPids(t, "-P", strconv.Itoa(shimPid), key)...)
+	}
+	return pids
 }
 
 func pgrepMultiPids(t *testing.T, key string, ppid int) []int {
@@ -936,16 +2549,46 @@ func readFromChannel(c <-chan initResult, timeout time.Duration) (result *initRe
 
 // Runs init 'stop' and asserts that it will time out after 240 seconds.
 func runStopAssertTimesOut(t *testing.T) *initResult {
+	return runStopAssertTimesOutAfter(t, 240*time.Second)
+}
+
+// Runs init 'stop' with the given extra args and asserts that it will time out after the given timeout.
+func runStopAssertTimesOutAfter(t *testing.T, timeout time.Duration, args ...string) *initResult {
 	clock := time2.NewFakeClock()
-	initChan := runInitWithClock(t, clock, "stop")
+	initChan := runInitWithClock(t, clock, append([]string{"stop"}, args...)...)
 	clock.BlockUntil(2) // wait for timer and ticker to attach
-	clock.Advance(239 * time.Second)
+	clock.Advance(timeout - time.Second)
 	result := readFromChannel(initChan, 1*time.Second)
-	require.Nil(t, result, "Expected `stop` to still wait after 239 seconds")
+	require.Nil(t, result, "Expected `stop` to still wait after %s", timeout-time.Second)
 
 	clock.Advance(1 * time.Second)
 	result2 := readFromChannel(initChan, 1*time.Second)
-	require.NotNil(t, result2, "Expected `stop` to finish after 240 seconds")
+	require.NotNil(t, result2, "Expected `stop` to finish after %s", timeout)
 
 	return result2
 }
+
+// Runs init with a fake clock, advancing it a second at a time until init finishes.
+func runInitAdvancingClock(t *testing.T, args ...string) initResult {
+	clock := time2.NewFakeClock()
+	return advanceClockUntilFinished(t, clock, runInitWithClock(t, clock, args...))
+}
+
+// Advances the given clock a second at a time until the init run with it finishes.
+func advanceClockUntilFinished(t *testing.T, clock time2.FakeClock, initChan <-chan initResult) initResult {
+	for i := 0; i < 30; i++ {
+		if result := readFromChannel(initChan, 500*time.Millisecond); result != nil {
+			return *result
+		}
+		clock.Advance(time.Second)
+	}
+	require.FailNow(t, "Expected init to finish within 30 fake seconds")
+	return initResult{}
+}
+
+func assertTerminatedBySignal(t *testing.T, cmd *exec.Cmd, expectedSignal syscall.Signal) {
+	err := cmd.Wait()
+	exitErr, ok := err.(*exec.ExitError)
+	require.True(t, ok, "expected process %d to be terminated by a signal, got %v", cmd.Process.Pid, err)
+	assert.Equal(t, expectedSignal, exitErr.Sys().(syscall.WaitStatus).Signal())
+}
@@ -16,7 +16,10 @@ package integration_test
 
 import (
 	"bytes"
+	"fmt"
+	"io/ioutil"
 	"log"
+	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -38,11 +41,113 @@ func TestMainMethod(t *testing.T) {
 	require.NoError(t, err, "failed: %s", output)
 
 	// part of expected output from launcher
-	assert.Regexp(t, `Argument list to executable binary: \[.+/bin/java -Xmx4M -Xmx1g -classpath .+/go-java-launcher/integration_test/testdata Main arg1\]`, output)
+	assert.Regexp(t, `Warning: -Xmx4M is overridden by -Xmx1g`, output)
+	assert.Regexp(t, `Argument list to executable binary: \[.+/bin/java -Xmx1g -classpath `+
+		`.+/go-java-launcher/integration_test/testdata Main arg1\]`, output)
 	// expected output of Java program
 	assert.Regexp(t, `\nmain method\n`, output)
 }
 
+func TestMainMethodRecordsEventsInLogDir(t *testing.T) {
+	logDir := t.TempDir()
+	cmd := mainWithArgs(t, "testdata/launcher-static.yml", "testdata/launcher-custom.yml")
+	cmd.Env = append(os.Environ(), launchlib.LogDirEnvVar+"="+logDir)
+	output, err := cmd.CombinedOutput()
+	require.NoError(t, err, "failed: %s", output)
+
+	events, err := ioutil.ReadFile(filepath.Join(logDir, launchlib.EventLogFileName))
+	require.NoError(t, err)
+	assert.Contains(t, string(events), `"type":"startRequested","source":"go-java-launcher","process":"primary"`)
+
+	// Failing to record events, e.g. to a missing log directory, is not written to the output of the service.
+	missingLogDir := filepath.Join(logDir, "missing")
+	cmd = mainWithArgs(t, "testdata/launcher-static-multiprocess.yml", "testdata/launcher-custom-multiprocess.yml")
+	cmd.Env = append(os.Environ(), launchlib.LogDirEnvVar+"="+missingLogDir)
+	output, err = cmd.CombinedOutput()
+	require.NoError(t, err, "failed: %s", output)
+	assert.NotContains(t, string(output), "event log", "output: %s", output)
+	assert.NoDirExists(t, missingLogDir)
+}
+
+func TestEntrypoint(t *testing.T) {
+	cli, err := products.Bin("go-java-launcher")
+	require.NoError(t, err)
+
+	cmd := exec.Command(cli, "--entrypoint", "repair", "testdata/launcher-static-entrypoints.yml",
+		"testdata/launcher-custom.yml", "--", "--dry-run")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	require.NoError(t, cmd.Run(), "failed: %s", stderr.String())
+
+	// The output of the launcher goes to stderr, leaving stdout to the entrypoint.
+	assert.Regexp(t, `Argument list to executable binary: \[.+/bin/java -Xmx1g -classpath `+
+		`.+/go-java-launcher/integration_test/testdata Repair --table users --dry-run\]`, stderr.String())
+	assert.Equal(t, "\nmain method\n", stdout.String())
+	require.NoError(t, os.RemoveAll("var/data"))
+}
+
+func TestDryRun(t *testing.T) {
+	cli, err := products.Bin("go-java-launcher")
+	require.NoError(t, err)
+
+	cmd := exec.Command(cli, "--dry-run", "--format", "shell", "testdata/launcher-static-multiprocess.yml",
+		"testdata/launcher-custom-multiprocess.yml")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	require.NoError(t, cmd.Run(), "failed: %s", stderr.String())
+
+	assert.Regexp(t, `# primary \(primary\)\n(#.*\n)*cd .+ && .+/bin/java -Xmx1g -classpath .+ Main arg1\n`+
+		`# sidecar \(subProcess\)\n(#.*\n)*cd .+ && .+/bin/java -Xmx1g -classpath .+ Main\n$`, stdout.String())
+	assert.NotContains(t, stdout.String(), "main method", "nothing should be started")
+}
+
+func TestExplain(t *testing.T) {
+	cli, err := products.Bin("go-java-launcher")
+	require.NoError(t, err)
+
+	cmd := exec.Command(cli, "--explain", "testdata/launcher-static-multiprocess.yml",
+		"testdata/launcher-custom-multiprocess.yml")
+	cmd.Env = append(os.Environ(), "CONTAINER=")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	require.NoError(t, cmd.Run(), "failed: %s", stderr.String())
+
+	assert.Regexp(t, `(?m)^primary: .+\nARG +ORIGIN\n`, stdout.String())
+	assert.Regexp(t, `(?m)^-Xmx4M +static jvmOpts +.+\n-Xmx1g +custom jvmOpts +.+$`, stdout.String())
+	assert.Regexp(t, `(?m)^-classpath +static classpath$`, stdout.String())
+	assert.NotContains(t, stdout.String(), "main method", "nothing should be started")
+}
+
+func TestMainMethodNotifiesSystemd(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	require.NoError(t, err)
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	// The primary process must keep running for the default startup confirmation period of 1s to become ready.
+	cmd := mainWithArgs(t, "testdata/launcher-static.yml", "testdata/launcher-custom.yml")
+	cmd.Env = append(os.Environ(), launchlib.NotifySocketEnvVar+"="+socket, "SLEEP_TIME=3")
+	output, err := cmd.CombinedOutput()
+	require.NoError(t, err, "failed: %s", output)
+
+	// The launcher is replaced by the primary process, so reports its own pid as that of the primary process.
+	buf := make([]byte, 1024)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("MAINPID=%d", cmd.Process.Pid), string(buf[:n]))
+
+	// The monitor notifies systemd that the service is ready once the primary process has been confirmed to start.
+	n, err = conn.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "READY=1", string(buf[:n]))
+}
+
 func TestMainMethodContainerSupportEnabled(t *testing.T) {
 	for _, tc := range []struct {
 		name               string
@@ -89,7 +194,7 @@ func TestMainMethodContainerSupportDisabled(t *testing.T) {
 			name:                    "disables container support if explicitly disabled via dangerousDisableContainerSupport",
 			launcherCustom:          "testdata/launcher-custom-dangerous-disable-container-support.yml",
 			containerSupportMessage: "Container support disabled in launcher-custom.yml",
-			expectedJVMArgs:         "-Xmx4M -Xmx1g",
+			expectedJVMArgs:         "-Xmx1g",
 		},
 		{
 			name:                    "disables container support if MaxRAM override present",
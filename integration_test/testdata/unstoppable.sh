@@ -1,5 +1,7 @@
 #!/bin/sh
 trap 'echo "Caught and swallowed SIGTERM"' 15
+# Like the JVM, survive the SIGQUIT sent to capture a thread dump
+trap 'echo "Caught and swallowed SIGQUIT"' 3
 echo "Hello, I am unstoppable $$"
 
 # Close fd 3 to signal we're ready
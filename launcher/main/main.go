@@ -15,10 +15,13 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"syscall"
 
 	"github.com/palantir/go-java-launcher/launchlib"
@@ -26,24 +29,68 @@ import (
 )
 
 const (
-	monitorFlag = "--group-monitor"
+	monitorFlag    = "--group-monitor"
+	preStopFlag    = "--pre-stop"
+	readyFlag      = "--ready"
+	entrypointFlag = "--entrypoint"
+	extraArgsFlag  = "--"
+	dryRunFlag     = "--dry-run"
+	explainFlag    = "--explain"
+	formatFlag     = "--format"
+
+	eventSource = "go-java-launcher"
 )
 
+// eventLog is the event log of the service in its log directory, as given to go-init, relative to the service root
+// the launcher is run in, or nil if events are not recorded.
+var eventLog = newEventLog()
+
+// newEventLog returns the event log in the log directory given to go-init by GO_INIT_LOG_DIR or, if that is unset, in
+// the default log directory if it exists, so that a launcher run outside of go-init does not record events.
+func newEventLog() *launchlib.EventLog {
+	dir := os.Getenv(launchlib.LogDirEnvVar)
+	if dir == "" {
+		if info, err := os.Stat(launchlib.DefaultLogDir); err != nil || !info.IsDir() {
+			return nil
+		}
+		dir = launchlib.DefaultLogDir
+	}
+	return &launchlib.EventLog{Path: filepath.Join(dir, launchlib.EventLogFileName), Source: eventSource}
+}
+
 func Exit1WithMessage(message string) {
 	_, _ = fmt.Fprintf(os.Stderr, message)
 	os.Exit(1)
 }
 
+// CreateMonitorFromArgs creates the monitor of the given processes, each given by its pid or as <name>=<pid>,
+// optionally followed by --pre-stop and the preStop actions of the processes by name as JSON, and by --ready and the
+// check the primary process must pass before systemd is notified that the service is ready as JSON.
 func CreateMonitorFromArgs(primaryPID string, subPIDs []string) (*launchlib.ProcessMonitor, error) {
-	monitor := &launchlib.ProcessMonitor{}
+	monitor := &launchlib.ProcessMonitor{Names: map[int]string{}, EventLog: eventLog}
 
 	var err error
-	if monitor.PrimaryPID, err = strconv.Atoi(primaryPID); err != nil {
+	if monitor.PrimaryPID, err = parseMonitorArg(primaryPID, monitor.Names); err != nil {
 		return nil, errors.Wrapf(err, "error parsing service pid")
 	}
 
-	for _, pidStr := range subPIDs {
-		pid, err := strconv.Atoi(pidStr)
+	for i := 0; i < len(subPIDs); i++ {
+		pidStr := subPIDs[i]
+		if pidStr == preStopFlag || pidStr == readyFlag {
+			if i+1 == len(subPIDs) {
+				return nil, errors.Errorf("%s requires exactly one argument", pidStr)
+			}
+			i++
+			if pidStr == preStopFlag {
+				if err := json.Unmarshal([]byte(subPIDs[i]), &monitor.PreStop); err != nil {
+					return nil, errors.Wrapf(err, "error parsing preStop actions")
+				}
+			} else if err := json.Unmarshal([]byte(subPIDs[i]), &monitor.Ready); err != nil {
+				return nil, errors.Wrapf(err, "error parsing ready check")
+			}
+			continue
+		}
+		pid, err := parseMonitorArg(pidStr, monitor.Names)
 		if err != nil {
 			return nil, errors.Wrapf(err, "error parsing sub-process pid")
 		}
@@ -52,27 +99,226 @@ func CreateMonitorFromArgs(primaryPID string, subPIDs []string) (*launchlib.Proc
 	return monitor, nil
 }
 
+// parseMonitorArg returns the pid of the process given by the given monitor argument, adding its name to the given
+// names if it has one. Process names cannot contain '='.
+func parseMonitorArg(arg string, names map[int]string) (int, error) {
+	name, pidStr, named := strings.Cut(arg, "=")
+	if !named {
+		pidStr = arg
+	}
+	pid, err := strconv.Atoi(pidStr)
+	if err != nil {
+		return 0, err
+	}
+	if named {
+		names[pid] = name
+	}
+	return pid, nil
+}
+
 func GenerateMonitorArgs(monitor *launchlib.ProcessMonitor) []string {
 	args := make([]string, 0, len(monitor.SubProcessPIDs)+2)
-	args = append(args, monitorFlag, strconv.Itoa(monitor.PrimaryPID))
+	args = append(args, monitorFlag, generateMonitorArg(monitor, monitor.PrimaryPID))
 	for _, pid := range monitor.SubProcessPIDs {
-		args = append(args, strconv.Itoa(pid))
+		args = append(args, generateMonitorArg(monitor, pid))
+	}
+	if len(monitor.PreStop) > 0 {
+		// Marshalling a map of structs of strings and durations cannot fail.
+		preStop, _ := json.Marshal(monitor.PreStop)
+		args = append(args, preStopFlag, string(preStop))
+	}
+	if monitor.Ready != nil {
+		// Marshalling a struct of strings, integers and durations cannot fail.
+		ready, _ := json.Marshal(monitor.Ready)
+		args = append(args, readyFlag, string(ready))
 	}
 	return args
 }
 
+func generateMonitorArg(monitor *launchlib.ProcessMonitor, pid int) string {
+	if name, ok := monitor.Names[pid]; ok {
+		return fmt.Sprintf("%s=%d", name, pid)
+	}
+	return strconv.Itoa(pid)
+}
+
+// resolvePreStops returns the preStop actions of the processes of the service by name, for those that have one.
+func resolvePreStops(staticConfig *launchlib.PrimaryStaticLauncherConfig,
+	customConfig *launchlib.PrimaryCustomLauncherConfig) (map[string]*launchlib.PreStopConfig, error) {
+	preStops := make(map[string]*launchlib.PreStopConfig)
+	behavior, err := launchlib.ResolveStopBehavior(&staticConfig.StaticLauncherConfig,
+		&customConfig.CustomLauncherConfig)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to resolve stop configuration for '%s'", staticConfig.ServiceName)
+	}
+	if behavior.PreStop != nil {
+		preStops[staticConfig.ServiceName] = behavior.PreStop
+	}
+	for name, subStatic := range staticConfig.SubProcesses {
+		subCustom := customConfig.SubProcesses[name]
+		behavior, err := launchlib.ResolveStopBehavior(&subStatic, &subCustom)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to resolve stop configuration for '%s'", name)
+		}
+		if behavior.PreStop != nil {
+			preStops[name] = behavior.PreStop
+		}
+	}
+	return preStops, nil
+}
+
+// parseEntrypointArgs returns the static and custom configuration files and the extra args of the entrypoint given by
+// the arguments following --entrypoint <name>, as [<static config> [<custom config>]] [-- <extra args>], defaulting to
+// the given configuration files.
+func parseEntrypointArgs(args []string, staticConfigFile, customConfigFile string) (string, string, []string,
+	error) {
+	configFiles := args
+	var extraArgs []string
+	for i, arg := range args {
+		if arg == extraArgsFlag {
+			configFiles, extraArgs = args[:i], args[i+1:]
+			break
+		}
+	}
+	switch len(configFiles) {
+	case 2:
+		customConfigFile = configFiles[1]
+		fallthrough
+	case 1:
+		staticConfigFile = configFiles[0]
+	case 0:
+	default:
+		return "", "", nil, errors.Errorf("unexpected arguments %v, extra args must follow %s", configFiles[2:],
+			extraArgsFlag)
+	}
+	return staticConfigFile, customConfigFile, extraArgs, nil
+}
+
+// parseFormattedArgs returns the static and custom configuration files and the output format given by the arguments
+// following --dry-run or --explain, as [--format <format>] [<static config> [<custom config>]], defaulting to the given
+// configuration files and the first of the given formats.
+func parseFormattedArgs(args []string, staticConfigFile, customConfigFile string, formats ...string) (string, string,
+	string, error) {
+	format := formats[0]
+	if len(args) > 0 && args[0] == formatFlag {
+		if len(args) < 2 {
+			return "", "", "", errors.Errorf("%s requires exactly one argument", formatFlag)
+		}
+		format, args = args[1], args[2:]
+		if !isOneOf(format, formats) {
+			return "", "", "", errors.Errorf("invalid format '%s', must be one of %v", format, formats)
+		}
+	}
+	switch len(args) {
+	case 2:
+		customConfigFile = args[1]
+		fallthrough
+	case 1:
+		staticConfigFile = args[0]
+	case 0:
+	default:
+		return "", "", "", errors.Errorf("unexpected arguments %v", args[2:])
+	}
+	return staticConfigFile, customConfigFile, format, nil
+}
+
+func isOneOf(value string, values []string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// dryRun prints the commands of the service compiled from the given configuration files in the given format, without
+// creating directories or starting anything. The launcher writes its own output to stderr, leaving stdout to the
+// commands.
+func dryRun(staticConfigFile, customConfigFile, format string) error {
+	staticConfig, customConfig, err := launchlib.GetConfigsFromFiles(staticConfigFile, customConfigFile, os.Stderr)
+	if err != nil {
+		return errors.Wrap(err, "failed to read config files")
+	}
+	cmds, err := launchlib.CompileCmdsFromConfig(&staticConfig, &customConfig, launchlib.NewSimpleWriterLogger(os.Stderr))
+	if err != nil {
+		return errors.Wrap(err, "failed to assemble executable metadata")
+	}
+	workingDir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	return launchlib.WriteDryRun(os.Stdout, launchlib.DescribeServiceCmds(workingDir, &staticConfig, &customConfig,
+		cmds), format)
+}
+
+// explain prints where each JVM arg of each java process of the service comes from, and why any configured jvmOpts are
+// dropped, in the given format. The launcher writes its own output to stderr.
+func explain(staticConfigFile, customConfigFile, format string) error {
+	staticConfig, customConfig, err := launchlib.GetConfigsFromFiles(staticConfigFile, customConfigFile, os.Stderr)
+	if err != nil {
+		return errors.Wrap(err, "failed to read config files")
+	}
+	workingDir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	return launchlib.WriteExplanations(os.Stdout, launchlib.ExplainServiceJvmArgs(workingDir, &staticConfig,
+		&customConfig), format)
+}
+
+// execEntrypoint replaces the launcher with the named entrypoint of the service, followed by the given extra args.
+// Nothing else of the service is launched. The launcher writes its own output to stderr so that the stdout of the
+// entrypoint, such as an admin tool, is only its own.
+func execEntrypoint(name, staticConfigFile, customConfigFile string, extraArgs []string) {
+	staticConfig, customConfig, err := launchlib.GetConfigsFromFiles(staticConfigFile, customConfigFile, os.Stderr)
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, "Failed to read config files", err)
+		panic(err)
+	}
+
+	if err := launchlib.MkDirs(staticConfig.Dirs, os.Stderr); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, "Failed to create directories", err)
+		panic(err)
+	}
+
+	cmd, err := launchlib.CompileEntrypointCmdFromConfig(name, extraArgs, &staticConfig, &customConfig,
+		launchlib.NewSimpleWriterLogger(os.Stderr).PrimaryLogger)
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, "Failed to assemble executable metadata", err)
+		panic(err)
+	}
+
+	execErr := syscall.Exec(cmd.Path, cmd.Args, cmd.Env)
+	if execErr != nil {
+		if os.IsNotExist(execErr) {
+			_, _ = fmt.Fprintln(os.Stderr, "Executable not found at:", cmd.Path)
+		}
+		panic(execErr)
+	}
+}
+
+// recordEvent appends the given event to the event log of the service, if events are recorded. Failing to do so is
+// ignored rather than written to the output of the service.
+func recordEvent(event launchlib.Event) {
+	if eventLog == nil {
+		return
+	}
+	_ = eventLog.Append(event)
+}
+
 func main() {
 	staticConfigFile := "launcher-static.yml"
 	customConfigFile := "launcher-custom.yml"
 	stdout := os.Stdout
 
 	switch numArgs := len(os.Args); {
-	case numArgs > 3 && os.Args[1] == monitorFlag:
+	case numArgs > 2 && os.Args[1] == monitorFlag:
 		monitor, err := CreateMonitorFromArgs(os.Args[2], os.Args[3:])
 
 		if err != nil {
 			fmt.Println("error parsing monitor args", err)
-			Exit1WithMessage(fmt.Sprintf("Usage: go-java-launcher %s <primary pid> <sub-process pids...>", monitorFlag))
+			Exit1WithMessage(fmt.Sprintf("Usage: go-java-launcher %s <primary pid> <sub-process pids...> "+
+				"[%s <preStop actions>]", monitorFlag, preStopFlag))
 		}
 
 		if err = monitor.Run(); err != nil {
@@ -80,6 +326,42 @@ func main() {
 			Exit1WithMessage("process monitor failed")
 		}
 		return
+	case numArgs > 2 && os.Args[1] == entrypointFlag:
+		staticConfigFile, customConfigFile, extraArgs, err := parseEntrypointArgs(os.Args[3:], staticConfigFile,
+			customConfigFile)
+		if err != nil {
+			fmt.Println("error parsing entrypoint args", err)
+			Exit1WithMessage(fmt.Sprintf("Usage: go-java-launcher %s <name> [<path to PrimaryStaticLauncherConfig> "+
+				"[<path to PrimaryCustomLauncherConfig>]] [%s <args...>]", entrypointFlag, extraArgsFlag))
+		}
+		execEntrypoint(os.Args[2], staticConfigFile, customConfigFile, extraArgs)
+		return
+	case numArgs > 1 && os.Args[1] == dryRunFlag:
+		staticConfigFile, customConfigFile, format, err := parseFormattedArgs(os.Args[2:], staticConfigFile,
+			customConfigFile, launchlib.DryRunFormatJSON, launchlib.DryRunFormatShell)
+		if err != nil {
+			_, _ = fmt.Fprintln(os.Stderr, "error parsing dry run args", err)
+			Exit1WithMessage(fmt.Sprintf("Usage: go-java-launcher %s [%s <json|shell>] "+
+				"[<path to PrimaryStaticLauncherConfig> [<path to PrimaryCustomLauncherConfig>]]", dryRunFlag,
+				formatFlag))
+		}
+		if err := dryRun(staticConfigFile, customConfigFile, format); err != nil {
+			Exit1WithMessage(fmt.Sprintf("Dry run failed: %v\n", err))
+		}
+		return
+	case numArgs > 1 && os.Args[1] == explainFlag:
+		staticConfigFile, customConfigFile, format, err := parseFormattedArgs(os.Args[2:], staticConfigFile,
+			customConfigFile, launchlib.ExplainFormatText, launchlib.ExplainFormatJSON)
+		if err != nil {
+			_, _ = fmt.Fprintln(os.Stderr, "error parsing explain args", err)
+			Exit1WithMessage(fmt.Sprintf("Usage: go-java-launcher %s [%s <text|json>] "+
+				"[<path to PrimaryStaticLauncherConfig> [<path to PrimaryCustomLauncherConfig>]]", explainFlag,
+				formatFlag))
+		}
+		if err := explain(staticConfigFile, customConfigFile, format); err != nil {
+			Exit1WithMessage(fmt.Sprintf("Explain failed: %v\n", err))
+		}
+		return
 	case numArgs == 2:
 		staticConfigFile = os.Args[1]
 	case numArgs == 3:
@@ -94,8 +376,11 @@ func main() {
 	staticConfig, customConfig, err := launchlib.GetConfigsFromFiles(staticConfigFile, customConfigFile, stdout)
 	if err != nil {
 		fmt.Println("Failed to read config files", err)
+		recordEvent(launchlib.Event{Type: launchlib.EventConfigError, Message: err.Error()})
 		panic(err)
 	}
+	serviceName := staticConfig.ServiceName
+	recordEvent(launchlib.Event{Type: launchlib.EventStartRequested, Process: serviceName})
 
 	// Create configured directories
 	if err := launchlib.MkDirs(staticConfig.Dirs, stdout); err != nil {
@@ -110,17 +395,75 @@ func main() {
 		}
 	}
 
+	for _, task := range staticConfig.InitTasks {
+		if err := launchlib.MkDirs(task.Dirs, stdout); err != nil {
+			fmt.Println("Failed to create directories for initTask ", task.Name, err)
+			panic(err)
+		}
+	}
+
 	// Compile commands
 	cmds, err := launchlib.CompileCmdsFromConfig(&staticConfig, &customConfig, launchlib.NewSimpleWriterLogger(os.Stdout))
 	if err != nil {
 		fmt.Println("Failed to assemble executable metadata", cmds, err)
+		recordEvent(launchlib.Event{Type: launchlib.EventConfigError, Message: err.Error()})
+		panic(err)
+	}
+	workingDir, err := os.Getwd()
+	if err != nil {
+		panic(err)
+	}
+
+	// Init tasks are run to completion before any long-running process is started.
+	for _, task := range cmds.InitTasks {
+		task.Cmd.Stdout = os.Stdout
+		task.Cmd.Stderr = os.Stderr
+
+		fmt.Println("Running initTask ", task.Name, task.Cmd.Path)
+		if err := task.Run(); err != nil {
+			fmt.Println("Failed to run initTask ", task.Name, err)
+			panic(err)
+		}
+	}
+
+	// The monitor also notifies the systemd watchdog, if enabled, since the primary process is not expected to.
+	watchdogInterval, err := launchlib.SdWatchdogInterval(os.Getpid())
+	if err != nil {
+		fmt.Println("Not notifying the systemd watchdog", err)
+	}
+
+	// The monitor also runs the preStop actions of the processes before forwarding a SIGTERM or SIGINT to them.
+	preStops, err := resolvePreStops(&staticConfig, &customConfig)
+	if err != nil {
+		fmt.Println("Failed to resolve stop configuration", err)
+		recordEvent(launchlib.Event{Type: launchlib.EventConfigError, Message: err.Error()})
 		panic(err)
 	}
 
-	if len(cmds.SubProcesses) != 0 {
+	// The monitor also notifies systemd that the service is ready, if run by systemd as a Type=notify service, once the
+	// primary process has started and passed its readiness check.
+	var ready *launchlib.ReadyCheck
+	if os.Getenv(launchlib.NotifySocketEnvVar) != "" {
+		startBehavior := launchlib.ResolveStartBehavior(&staticConfig.StaticLauncherConfig,
+			&customConfig.CustomLauncherConfig)
+		if err := launchlib.RemoveReadinessFile(startBehavior.Readiness); err != nil {
+			fmt.Println("Failed to remove readiness file", err)
+			panic(err)
+		}
+		ready = &launchlib.ReadyCheck{
+			ConfirmationPeriod: startBehavior.ConfirmationPeriod,
+			Readiness:          startBehavior.Readiness,
+		}
+	}
+
+	if len(cmds.SubProcesses) != 0 || watchdogInterval > 0 || len(preStops) > 0 || ready != nil {
 		monitor := &launchlib.ProcessMonitor{
 			PrimaryPID:     os.Getpid(),
 			SubProcessPIDs: nil,
+			Names:          map[int]string{os.Getpid(): serviceName},
+			EventLog:       eventLog,
+			PreStop:        preStops,
+			Ready:          ready,
 		}
 		// From this point, any errors in the launcher will cause all of the created sub-processes to also die,
 		// once the main process is exec'ed, this defer will no longer apply, and the external monitor assumes
@@ -137,6 +480,7 @@ func main() {
 			subProcess.Stderr = os.Stderr
 
 			fmt.Println("Starting subProcesses ", name, subProcess.Path)
+			recordEvent(launchlib.Event{Type: launchlib.EventStartRequested, Process: name})
 			if execErr := subProcess.Start(); execErr != nil {
 				if os.IsNotExist(execErr) {
 					fmt.Printf("Executable not found for subProcess %s at: %s\n", name, subProcess.Path)
@@ -144,7 +488,16 @@ func main() {
 				panic(execErr)
 			}
 			monitor.SubProcessPIDs = append(monitor.SubProcessPIDs, subProcess.Process.Pid)
+			monitor.Names[subProcess.Process.Pid] = name
 			fmt.Printf("Started subProcess %s under process pid %d\n", name, subProcess.Process.Pid)
+			subStatic, subCustom := staticConfig.SubProcesses[name], customConfig.SubProcesses[name]
+			recordEvent(launchlib.Event{
+				Type:    launchlib.EventProcessStarted,
+				Process: name,
+				Pid:     subProcess.Process.Pid,
+				CommandHash: launchlib.CommandHash(subProcess.Args,
+					launchlib.ConfiguredEnv(&subStatic, &subCustom, workingDir)),
+			})
 		}
 
 		monitorCmd := exec.Command(os.Args[0], GenerateMonitorArgs(monitor)...)
@@ -158,6 +511,19 @@ func main() {
 		}
 	}
 
+	// The primary process replaces the launcher, so has its pid. Whether it is ready is left to the monitor.
+	if _, err := launchlib.SdNotify(launchlib.SdNotifyMainPid(os.Getpid())); err != nil {
+		fmt.Println("Failed to notify systemd of the main pid of the service", err)
+	}
+
+	// The primary process replaces the launcher, so is recorded as started with the pid of the launcher before it is.
+	recordEvent(launchlib.Event{
+		Type:    launchlib.EventProcessStarted,
+		Process: serviceName,
+		Pid:     os.Getpid(),
+		CommandHash: launchlib.CommandHash(cmds.Primary.Args, launchlib.ConfiguredEnv(
+			&staticConfig.StaticLauncherConfig, &customConfig.CustomLauncherConfig, workingDir)),
+	})
 	execErr := syscall.Exec(cmds.Primary.Path, cmds.Primary.Args, cmds.Primary.Env)
 	if execErr != nil {
 		if os.IsNotExist(execErr) {
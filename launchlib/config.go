@@ -18,18 +18,49 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"math"
+	"net/http"
+	"net/url"
 	"path"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/pkg/errors"
 	"gopkg.in/validator.v2"
 	"gopkg.in/yaml.v2"
 )
 
+const (
+	DefaultStopTimeout    = 240 * time.Second
+	DefaultStopSignal     = syscall.SIGTERM
+	DefaultThreadDumpWait = 5 * time.Second
+	DefaultPreStopTimeout = 30 * time.Second
+
+	DefaultStartupConfirmationPeriod = time.Second
+	DefaultReadinessTimeout          = 60 * time.Second
+	DefaultCrashLoopMaxCrashes       = 5
+	DefaultCrashLoopWindow           = 10 * time.Minute
+
+	DefaultLogRotationMaxFiles = 5
+
+	DefaultInitTaskTimeout = 10 * time.Minute
+)
+
 var (
 	processNamePattern = regexp.MustCompile("^[a-z0-9-]+$")
+
+	// localHosts are the hosts a preStop httpUrl may be on.
+	localHosts         = map[string]struct{}{"localhost": {}, "127.0.0.1": {}, "::1": {}}
+	preStopHTTPMethods = map[string]struct{}{
+		http.MethodGet:    {},
+		http.MethodPost:   {},
+		http.MethodPut:    {},
+		http.MethodDelete: {},
+	}
 )
 
 type VersionedConfig struct {
@@ -45,15 +76,124 @@ type JavaConfig struct {
 	MainClass string   `yaml:"mainClass" validate:"nonzero"`
 	JvmOpts   []string `yaml:"jvmOpts"`
 	Classpath []string `yaml:"classpath" validate:"nonzero"`
+	// StrictJvmOpts fails the launch if the static and custom jvmOpts combine into options that the JVM refuses to
+	// start with, e.g. conflicting garbage collectors, rather than warning of them.
+	StrictJvmOpts bool `yaml:"strictJvmOpts"`
+}
+
+// StopConfig configures how a process is stopped: it is sent StopSignal, and is sent a SIGKILL if it has not stopped
+// within StopTimeout. Java processes are additionally sent a SIGQUIT ThreadDumpWait before the SIGKILL so that a thread
+// dump is written to their output, unless DisableThreadDumpBeforeKill is set.
+type StopConfig struct {
+	StopTimeout                 time.Duration `yaml:"stopTimeout"`
+	StopSignal                  string        `yaml:"stopSignal"`
+	ThreadDumpWait              time.Duration `yaml:"threadDumpWait"`
+	DisableThreadDumpBeforeKill bool          `yaml:"disableThreadDumpBeforeKill"`
+}
+
+// StopBehavior is the resolution of the StopConfig of a process from its static and custom configurations.
+type StopBehavior struct {
+	Timeout time.Duration
+	Signal  syscall.Signal
+	// ThreadDumpWait is the time between sending a SIGQUIT and sending a SIGKILL, or 0 if no SIGQUIT is sent.
+	ThreadDumpWait time.Duration
+	// PreStop is nil if no action is run before the process is sent Signal, and otherwise has its Timeout resolved.
+	PreStop *PreStopConfig
+}
+
+// ThreadDumpAfter returns the time after sending the stop signal at which a SIGQUIT should be sent, and whether one
+// should be sent at all. If ThreadDumpWait is not shorter than Timeout, the SIGQUIT is sent halfway through Timeout.
+func (b StopBehavior) ThreadDumpAfter() (time.Duration, bool) {
+	if b.ThreadDumpWait <= 0 {
+		return 0, false
+	}
+	if b.ThreadDumpWait >= b.Timeout {
+		return b.Timeout / 2, true
+	}
+	return b.Timeout - b.ThreadDumpWait, true
+}
+
+// PreStopConfig configures an action that is run before a process is sent its stop signal, e.g. so that load balancers
+// stop sending it requests: a request with HTTPMethod, GET by default, to HTTPURL on localhost, running Command, whose
+// executable must be allowlisted, or sleeping for Sleep. Exactly one must be set. The action is abandoned after
+// Timeout.
+type PreStopConfig struct {
+	HTTPURL    string        `yaml:"httpUrl" json:"httpUrl,omitempty"`
+	HTTPMethod string        `yaml:"httpMethod" json:"httpMethod,omitempty"`
+	Command    []string      `yaml:"command" json:"command,omitempty"`
+	Sleep      time.Duration `yaml:"sleep" json:"sleep,omitempty"`
+	Timeout    time.Duration `yaml:"timeout" json:"timeout,omitempty"`
+}
+
+// StartConfig configures how go-init starts a process and confirms that it has started: the process is started with
+// Umask, given as an octal string, if set, and must keep running for StartupConfirmationPeriod and, if Readiness is
+// set, must become ready within the readiness timeout. If CrashLoop is set, go-init start refuses to start a process
+// that keeps exiting by itself.
+type StartConfig struct {
+	Umask                     string           `yaml:"umask"`
+	StartupConfirmationPeriod time.Duration    `yaml:"startupConfirmationPeriod"`
+	Readiness                 *ReadinessConfig `yaml:"readiness"`
+	CrashLoop                 *CrashLoopConfig `yaml:"crashLoop"`
+}
+
+// ReadinessConfig configures how a process signals that it is ready to go-init: by creating File, by accepting
+// connections on TCPPort on localhost, or by responding to a GET of HTTPURL with a 2xx status. Exactly one must be set.
+type ReadinessConfig struct {
+	File    string        `yaml:"file"`
+	TCPPort int           `yaml:"tcpPort"`
+	HTTPURL string        `yaml:"httpUrl"`
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// CrashLoopConfig configures go-init start to refuse to start a process that has exited by itself, rather than being
+// stopped by go-init, MaxCrashes times within Window, until the earliest of those exits is older than Window.
+type CrashLoopConfig struct {
+	MaxCrashes int           `yaml:"maxCrashes"`
+	Window     time.Duration `yaml:"window"`
+}
+
+// StartBehavior is the resolution of the StartConfig of a process from its static and custom configurations.
+type StartBehavior struct {
+	// Umask is the umask the process is started with, or nil if it inherits the umask of go-init.
+	Umask              *int
+	ConfirmationPeriod time.Duration
+	// Readiness is nil if the process has no readiness check, and otherwise has its Timeout resolved.
+	Readiness *ReadinessConfig
+	// CrashLoop is nil if crash loops of the process are not detected, and otherwise has its defaults resolved.
+	CrashLoop *CrashLoopConfig
+}
+
+// LogRotationConfig configures go-init to write the output of a process through a writer that rotates its log file
+// before it would grow beyond MaxSize, given in bytes or with a K, M or G suffix, keeping at most MaxFiles rotated
+// files and compressing them with gzip if Compress is set. Log files are only rotated by size: rotated files older than
+// PruneRotatedAfter, if set, are removed whenever the log file is opened or rotated.
+type LogRotationConfig struct {
+	MaxSize           string        `yaml:"maxSize"`
+	MaxFiles          int           `yaml:"maxFiles"`
+	PruneRotatedAfter time.Duration `yaml:"pruneRotatedAfter"`
+	Compress          bool          `yaml:"compress"`
+}
+
+// LogRotationBehavior is the resolution of the LogRotationConfig of a process.
+type LogRotationBehavior struct {
+	MaxSize  int64
+	MaxFiles int
+	// PruneRotatedAfter is 0 if rotated files are kept regardless of their age.
+	PruneRotatedAfter time.Duration
+	Compress          bool
 }
 
 type StaticLauncherConfig struct {
 	TypedConfig `yaml:",inline"`
 	JavaConfig  `yaml:",inline"`
-	Env         map[string]string `yaml:"env"`
-	Executable  string            `yaml:"executable,omitempty"`
-	Args        []string          `yaml:"args"`
-	Dirs        []string          `yaml:"dirs"`
+	StopConfig  `yaml:",inline"`
+	StartConfig `yaml:",inline"`
+	Env         map[string]string  `yaml:"env"`
+	Executable  string             `yaml:"executable,omitempty"`
+	Args        []string           `yaml:"args"`
+	Dirs        []string           `yaml:"dirs"`
+	LogRotation *LogRotationConfig `yaml:"logRotation"`
+	PreStop     *PreStopConfig     `yaml:"preStop"`
 }
 
 type PrimaryStaticLauncherConfig struct {
@@ -61,14 +201,36 @@ type PrimaryStaticLauncherConfig struct {
 	ServiceName          string `yaml:"serviceName"`
 	StaticLauncherConfig `yaml:",inline"`
 	SubProcesses         map[string]StaticLauncherConfig `yaml:"subProcesses"`
+	InitTasks            []InitTaskConfig                `yaml:"initTasks"`
+	Entrypoints          map[string]EntrypointConfig     `yaml:"entrypoints"`
+}
+
+// InitTaskConfig configures a task that is run to completion, in the order the tasks are given in, before any process
+// of the service is started. It is configured as a subProcess is, though its stop, start, logRotation and preStop
+// configurations are ignored, and must exit 0 within Timeout for the service to be started.
+type InitTaskConfig struct {
+	Name                 string `yaml:"name"`
+	StaticLauncherConfig `yaml:",inline"`
+	Timeout              time.Duration `yaml:"timeout"`
+}
+
+// EntrypointConfig configures an alternate entrypoint of the service, such as an admin tool, that is launched with the
+// configuration of the primary process but with its own mainClass, or executable, and args. Only one of MainClass and
+// Executable may be set, according to the configType of the service.
+type EntrypointConfig struct {
+	MainClass  string   `yaml:"mainClass"`
+	Executable string   `yaml:"executable"`
+	Args       []string `yaml:"args"`
 }
 
 type CustomLauncherConfig struct {
-	TypedConfig             `yaml:",inline"`
-	JvmOpts                 []string                   `yaml:"jvmOpts"`
-	Env                     map[string]string          `yaml:"env"`
-	Experimental            ExperimentalLauncherConfig `yaml:"experimental"`
-	DisableContainerSupport bool                       `yaml:"dangerousDisableContainerSupport"`
+	TypedConfig               `yaml:",inline"`
+	StopConfig                `yaml:",inline"`
+	StartupConfirmationPeriod time.Duration              `yaml:"startupConfirmationPeriod"`
+	JvmOpts                   []string                   `yaml:"jvmOpts"`
+	Env                       map[string]string          `yaml:"env"`
+	Experimental              ExperimentalLauncherConfig `yaml:"experimental"`
+	DisableContainerSupport   bool                       `yaml:"dangerousDisableContainerSupport"`
 }
 
 type ExperimentalLauncherConfig struct {
@@ -85,6 +247,8 @@ type AllowedLauncherConfigValues struct {
 	ConfigTypes    map[string]struct{}
 	ConfigVersions map[int]struct{}
 	Executables    map[string]struct{}
+	// PreStopExecutables are the executables a preStop command may run.
+	PreStopExecutables map[string]struct{}
 }
 
 var allowedLauncherConfigs = AllowedLauncherConfigValues{
@@ -96,6 +260,10 @@ var allowedLauncherConfigs = AllowedLauncherConfigValues{
 		"influxd":        {},
 		"grafana-server": {},
 		"envoy":          {}},
+	PreStopExecutables: map[string]struct{}{
+		"curl":  {},
+		"wget":  {},
+		"socat": {}},
 }
 
 func GetConfigsFromFiles(
@@ -184,6 +352,47 @@ func parseStaticConfig(yamlString []byte) (PrimaryStaticLauncherConfig, error) {
 				errors.Wrapf(err, "failed to validate subProcess launcher configuration '%s'", name)
 		}
 	}
+
+	taskNames := make(map[string]struct{}, len(config.InitTasks))
+	for _, task := range config.InitTasks {
+		if err := validateProcessName(task.Name); err != nil {
+			return PrimaryStaticLauncherConfig{},
+				errors.Wrapf(err, "invalid initTask name '%s' in static config", task.Name)
+		}
+		if _, ok := config.SubProcesses[task.Name]; ok || task.Name == config.ServiceName {
+			return PrimaryStaticLauncherConfig{},
+				errors.Errorf("initTask name '%s' cannot be the same as ServiceName or a subProcess name", task.Name)
+		}
+		if _, ok := taskNames[task.Name]; ok {
+			return PrimaryStaticLauncherConfig{}, errors.Errorf("initTask name '%s' is given more than once",
+				task.Name)
+		}
+		taskNames[task.Name] = struct{}{}
+
+		if err := validateStaticConfig(&task.StaticLauncherConfig); err != nil {
+			return PrimaryStaticLauncherConfig{},
+				errors.Wrapf(err, "failed to validate initTask launcher configuration '%s'", task.Name)
+		}
+		if task.Timeout < 0 {
+			return PrimaryStaticLauncherConfig{},
+				errors.Errorf("initTask '%s' timeout must not be negative, found %s", task.Name, task.Timeout)
+		}
+		if task.Timeout > 0 && task.Timeout < time.Second {
+			return PrimaryStaticLauncherConfig{}, errors.Errorf("initTask '%s' timeout must be at least 1s, found %s; "+
+				"durations require a unit, e.g. '10m'", task.Name, task.Timeout)
+		}
+	}
+
+	for name, entrypoint := range config.Entrypoints {
+		if err := validateProcessName(name); err != nil {
+			return PrimaryStaticLauncherConfig{},
+				errors.Wrapf(err, "invalid entrypoint name '%s' in static config", name)
+		}
+		if err := entrypoint.validate(config.Type); err != nil {
+			return PrimaryStaticLauncherConfig{},
+				errors.Wrapf(err, "failed to validate entrypoint configuration '%s'", name)
+		}
+	}
 	return config, nil
 }
 
@@ -199,9 +408,74 @@ func validateStaticConfig(config *StaticLauncherConfig) error {
 		}
 	}
 
+	if err := config.StopConfig.validate(); err != nil {
+		return err
+	}
+
+	if err := config.StartConfig.validate(); err != nil {
+		return err
+	}
+
+	if config.LogRotation != nil {
+		if err := config.LogRotation.validate(); err != nil {
+			return err
+		}
+	}
+
+	if config.PreStop != nil {
+		if err := config.PreStop.validate(); err != nil {
+			return err
+		}
+	}
+
 	return validateExecutableConfig(config.Executable)
 }
 
+// validate validates the entrypoint of a service of the given configType.
+func (config *EntrypointConfig) validate(configType string) error {
+	if configType == "java" {
+		if config.MainClass == "" {
+			return errors.New("mainClass is required for an entrypoint of a java service")
+		}
+		if config.Executable != "" {
+			return errors.New("executable cannot be set for an entrypoint of a java service")
+		}
+		return nil
+	}
+	if config.MainClass != "" {
+		return errors.New("mainClass cannot be set for an entrypoint of an executable service")
+	}
+	if config.Executable != "" {
+		return validateExecutableConfig(config.Executable)
+	}
+	return nil
+}
+
+// EntrypointStaticConfig returns the static configuration the named entrypoint of the service is launched with: that
+// of the primary process with the mainClass, or executable, and args of the entrypoint.
+func (config *PrimaryStaticLauncherConfig) EntrypointStaticConfig(name string) (StaticLauncherConfig, error) {
+	entrypoint, ok := config.Entrypoints[name]
+	if !ok {
+		names := make([]string, 0, len(config.Entrypoints))
+		for configured := range config.Entrypoints {
+			names = append(names, configured)
+		}
+		sort.Strings(names)
+		return StaticLauncherConfig{}, errors.Errorf("no entrypoint named '%s' is configured, found: [%s]", name,
+			strings.Join(names, ", "))
+	}
+
+	staticConfig := config.StaticLauncherConfig
+	if entrypoint.MainClass != "" {
+		staticConfig.MainClass = entrypoint.MainClass
+	}
+	if entrypoint.Executable != "" {
+		staticConfig.Executable = entrypoint.Executable
+	}
+	staticConfig.Args = append([]string(nil), entrypoint.Args...)
+	return staticConfig, nil
+}
+
 func getStaticConfigFromFile(staticConfigFile string) (PrimaryStaticLauncherConfig, error) {
 	if staticData, err := ioutil.ReadFile(staticConfigFile); err != nil {
 		return PrimaryStaticLauncherConfig{},
@@ -251,6 +525,14 @@ func parseCustomConfig(yamlString []byte) (PrimaryCustomLauncherConfig, error) {
 		return PrimaryCustomLauncherConfig{}, err
 	}
 
+	if err := config.StopConfig.validate(); err != nil {
+		return PrimaryCustomLauncherConfig{}, err
+	}
+
+	if err := validateStartupConfirmationPeriod(config.StartupConfirmationPeriod); err != nil {
+		return PrimaryCustomLauncherConfig{}, err
+	}
+
 	if err := validateSubProcessLimit(len(config.SubProcesses)); err != nil {
 		return PrimaryCustomLauncherConfig{}, err
 	}
@@ -265,6 +547,16 @@ func parseCustomConfig(yamlString []byte) (PrimaryCustomLauncherConfig, error) {
 			return PrimaryCustomLauncherConfig{}, errors.Wrapf(err, "invalid launch config in custom "+
 				"subProcess config %s", name)
 		}
+
+		if err := subProcess.StopConfig.validate(); err != nil {
+			return PrimaryCustomLauncherConfig{}, errors.Wrapf(err, "invalid stop config in custom "+
+				"subProcess config %s", name)
+		}
+
+		if err := validateStartupConfirmationPeriod(subProcess.StartupConfirmationPeriod); err != nil {
+			return PrimaryCustomLauncherConfig{}, errors.Wrapf(err, "invalid start config in custom "+
+				"subProcess config %s", name)
+		}
 	}
 	return config, nil
 }
@@ -297,6 +589,317 @@ func (config *TypedConfig) validateType(allowedTypes map[string]struct{}) error
 	return nil
 }
 
+func (config *StopConfig) validate() error {
+	if config.StopTimeout < 0 {
+		return errors.Errorf("stopTimeout must not be negative, found %s", config.StopTimeout)
+	}
+	if config.StopTimeout > 0 && config.StopTimeout < time.Second {
+		return errors.Errorf("stopTimeout must be at least 1s, found %s; durations require a unit, e.g. '240s'",
+			config.StopTimeout)
+	}
+	if config.StopSignal != "" {
+		if _, err := ParseSignal(config.StopSignal); err != nil {
+			return errors.Wrap(err, "invalid stopSignal")
+		}
+	}
+	if config.ThreadDumpWait < 0 {
+		return errors.Errorf("threadDumpWait must not be negative, found %s", config.ThreadDumpWait)
+	}
+	return nil
+}
+
+// ResolveStopBehavior returns how to stop a process, preferring values set in its custom configuration over those set
+// in its static configuration, and falling back to DefaultStopTimeout, DefaultStopSignal and DefaultThreadDumpWait.
+// Thread dumps are only captured for processes of type "java".
+func ResolveStopBehavior(staticConfig *StaticLauncherConfig, customConfig *CustomLauncherConfig) (StopBehavior, error) {
+	behavior := StopBehavior{
+		Timeout: DefaultStopTimeout,
+		Signal:  DefaultStopSignal,
+	}
+	if customConfig.StopTimeout > 0 {
+		behavior.Timeout = customConfig.StopTimeout
+	} else if staticConfig.StopTimeout > 0 {
+		behavior.Timeout = staticConfig.StopTimeout
+	}
+
+	signalName := staticConfig.StopSignal
+	if customConfig.StopSignal != "" {
+		signalName = customConfig.StopSignal
+	}
+	if signalName != "" {
+		sig, err := ParseSignal(signalName)
+		if err != nil {
+			return StopBehavior{}, errors.Wrap(err, "invalid stopSignal")
+		}
+		behavior.Signal = sig
+	}
+
+	if staticConfig.Type == "java" && !staticConfig.DisableThreadDumpBeforeKill &&
+		!customConfig.DisableThreadDumpBeforeKill {
+		behavior.ThreadDumpWait = DefaultThreadDumpWait
+		if customConfig.ThreadDumpWait > 0 {
+			behavior.ThreadDumpWait = customConfig.ThreadDumpWait
+		} else if staticConfig.ThreadDumpWait > 0 {
+			behavior.ThreadDumpWait = staticConfig.ThreadDumpWait
+		}
+	}
+
+	if staticConfig.PreStop != nil {
+		preStop := *staticConfig.PreStop
+		if preStop.Timeout == 0 {
+			preStop.Timeout = DefaultPreStopTimeout
+			if preStop.Sleep > preStop.Timeout {
+				preStop.Timeout = preStop.Sleep
+			}
+		}
+		if preStop.HTTPURL != "" && preStop.HTTPMethod == "" {
+			preStop.HTTPMethod = http.MethodGet
+		}
+		behavior.PreStop = &preStop
+	}
+	return behavior, nil
+}
+
+func (config *PreStopConfig) validate() error {
+	var actions int
+	if config.HTTPURL != "" {
+		parsed, err := url.Parse(config.HTTPURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+			return errors.Errorf("preStop httpUrl must be an http or https URL, found '%s'", config.HTTPURL)
+		}
+		if _, ok := localHosts[parsed.Hostname()]; !ok {
+			return errors.Errorf("preStop httpUrl must be on localhost, found '%s'", config.HTTPURL)
+		}
+		actions++
+	}
+	if config.HTTPMethod != "" {
+		if config.HTTPURL == "" {
+			return errors.New("preStop httpMethod requires httpUrl")
+		}
+		if _, ok := preStopHTTPMethods[config.HTTPMethod]; !ok {
+			return errors.Errorf("preStop httpMethod must be one of %s, found '%s'", toString(preStopHTTPMethods),
+				config.HTTPMethod)
+		}
+	}
+	if len(config.Command) > 0 {
+		if _, ok := allowedLauncherConfigs.PreStopExecutables[path.Base(config.Command[0])]; !ok {
+			return errors.Errorf("preStop command can run executable=%v only, found %v",
+				toString(allowedLauncherConfigs.PreStopExecutables), config.Command[0])
+		}
+		actions++
+	}
+	if config.Sleep != 0 {
+		if config.Sleep < time.Second {
+			return errors.Errorf("preStop sleep must be at least 1s, found %s; durations require a unit, e.g. '10s'",
+				config.Sleep)
+		}
+		if config.Timeout > 0 && config.Sleep > config.Timeout {
+			return errors.Errorf("preStop sleep must not be longer than its timeout, found %s and %s", config.Sleep,
+				config.Timeout)
+		}
+		actions++
+	}
+	if actions != 1 {
+		return errors.Errorf("preStop must set exactly one of httpUrl, command and sleep, found %d", actions)
+	}
+	if config.Timeout < 0 {
+		return errors.Errorf("preStop timeout must not be negative, found %s", config.Timeout)
+	}
+	if config.Timeout > 0 && config.Timeout < time.Second {
+		return errors.Errorf("preStop timeout must be at least 1s, found %s; durations require a unit, e.g. '30s'",
+			config.Timeout)
+	}
+	return nil
+}
+
+func (config *StartConfig) validate() error {
+	if config.Umask != "" {
+		if _, err := parseUmask(config.Umask); err != nil {
+			return err
+		}
+	}
+	if err := validateStartupConfirmationPeriod(config.StartupConfirmationPeriod); err != nil {
+		return err
+	}
+	if config.CrashLoop != nil {
+		if err := config.CrashLoop.validate(); err != nil {
+			return err
+		}
+	}
+	if config.Readiness == nil {
+		return nil
+	}
+
+	readiness := config.Readiness
+	var checks int
+	if readiness.File != "" {
+		checks++
+	}
+	if readiness.TCPPort != 0 {
+		if readiness.TCPPort < 1 || readiness.TCPPort > 65535 {
+			return errors.Errorf("readiness tcpPort must be between 1 and 65535, found %d", readiness.TCPPort)
+		}
+		checks++
+	}
+	if readiness.HTTPURL != "" {
+		if parsed, err := url.Parse(readiness.HTTPURL); err != nil || (parsed.Scheme != "http" &&
+			parsed.Scheme != "https") {
+			return errors.Errorf("readiness httpUrl must be an http or https URL, found '%s'", readiness.HTTPURL)
+		}
+		checks++
+	}
+	if checks != 1 {
+		return errors.Errorf("readiness must set exactly one of file, tcpPort and httpUrl, found %d", checks)
+	}
+	if readiness.Timeout < 0 {
+		return errors.Errorf("readiness timeout must not be negative, found %s", readiness.Timeout)
+	}
+	if readiness.Timeout > 0 && readiness.Timeout < time.Second {
+		return errors.Errorf("readiness timeout must be at least 1s, found %s; durations require a unit, e.g. '60s'",
+			readiness.Timeout)
+	}
+	return nil
+}
+
+func (config *CrashLoopConfig) validate() error {
+	if config.MaxCrashes < 0 {
+		return errors.Errorf("crashLoop maxCrashes must not be negative, found %d", config.MaxCrashes)
+	}
+	if config.Window < 0 {
+		return errors.Errorf("crashLoop window must not be negative, found %s", config.Window)
+	}
+	if config.Window > 0 && config.Window < time.Second {
+		return errors.Errorf("crashLoop window must be at least 1s, found %s; durations require a unit, e.g. '10m'",
+			config.Window)
+	}
+	return nil
+}
+
+func parseUmask(umask string) (int, error) {
+	parsed, err := strconv.ParseUint(umask, 8, 32)
+	if err != nil || parsed > 0777 {
+		return 0, errors.Errorf("umask must be an octal string between '0000' and '0777', found '%s'", umask)
+	}
+	return int(parsed), nil
+}
+
+func validateStartupConfirmationPeriod(period time.Duration) error {
+	if period < 0 {
+		return errors.Errorf("startupConfirmationPeriod must not be negative, found %s", period)
+	}
+	if period > 0 && period < time.Millisecond {
+		return errors.Errorf("startupConfirmationPeriod must be at least 1ms, found %s; durations require a unit, "+
+			"e.g. '10s'", period)
+	}
+	return nil
+}
+
+// ResolveStartBehavior returns how to start a process and confirm that it has started, preferring the
+// startupConfirmationPeriod set in its custom configuration over that set in its static configuration, and falling back
+// to DefaultStartupConfirmationPeriod, DefaultReadinessTimeout, DefaultCrashLoopMaxCrashes and DefaultCrashLoopWindow.
+func ResolveStartBehavior(staticConfig *StaticLauncherConfig, customConfig *CustomLauncherConfig) StartBehavior {
+	behavior := StartBehavior{ConfirmationPeriod: DefaultStartupConfirmationPeriod}
+	if staticConfig.Umask != "" {
+		// The umask was validated when the static configuration was parsed.
+		if umask, err := parseUmask(staticConfig.Umask); err == nil {
+			behavior.Umask = &umask
+		}
+	}
+	if customConfig.StartupConfirmationPeriod > 0 {
+		behavior.ConfirmationPeriod = customConfig.StartupConfirmationPeriod
+	} else if staticConfig.StartupConfirmationPeriod > 0 {
+		behavior.ConfirmationPeriod = staticConfig.StartupConfirmationPeriod
+	}
+
+	if staticConfig.Readiness != nil {
+		readiness := *staticConfig.Readiness
+		if readiness.Timeout == 0 {
+			readiness.Timeout = DefaultReadinessTimeout
+		}
+		behavior.Readiness = &readiness
+	}
+	if staticConfig.CrashLoop != nil {
+		crashLoop := *staticConfig.CrashLoop
+		if crashLoop.MaxCrashes == 0 {
+			crashLoop.MaxCrashes = DefaultCrashLoopMaxCrashes
+		}
+		if crashLoop.Window == 0 {
+			crashLoop.Window = DefaultCrashLoopWindow
+		}
+		behavior.CrashLoop = &crashLoop
+	}
+	return behavior
+}
+
+func (config *LogRotationConfig) validate() error {
+	if _, err := parseByteSize(config.MaxSize); err != nil {
+		return errors.Wrap(err, "invalid logRotation maxSize")
+	}
+	if config.MaxFiles < 0 {
+		return errors.Errorf("logRotation maxFiles must not be negative, found %d", config.MaxFiles)
+	}
+	if config.PruneRotatedAfter < 0 {
+		return errors.Errorf("logRotation pruneRotatedAfter must not be negative, found %s", config.PruneRotatedAfter)
+	}
+	if config.PruneRotatedAfter > 0 && config.PruneRotatedAfter < time.Second {
+		return errors.Errorf("logRotation pruneRotatedAfter must be at least 1s, found %s; durations require a unit, "+
+			"e.g. '168h'", config.PruneRotatedAfter)
+	}
+	return nil
+}
+
+// parseByteSize parses a positive number of bytes, given either as a number or with a K, M or G suffix denoting
+// kibibytes, mebibytes or gibibytes.
+func parseByteSize(size string) (int64, error) {
+	multiplier := int64(1)
+	number := size
+	if len(size) > 0 {
+		switch strings.ToUpper(size[len(size)-1:]) {
+		case "K":
+			multiplier = 1 << 10
+		case "M":
+			multiplier = 1 << 20
+		case "G":
+			multiplier = 1 << 30
+		}
+		if multiplier > 1 {
+			number = size[:len(size)-1]
+		}
+	}
+	parsed, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || parsed <= 0 {
+		return 0, errors.Errorf("size must be a positive number of bytes, optionally with a K, M or G suffix, "+
+			"found '%s'", size)
+	}
+	if parsed > math.MaxInt64/multiplier {
+		return 0, errors.Errorf("size must be at most %d bytes, found '%s'", int64(math.MaxInt64), size)
+	}
+	return parsed * multiplier, nil
+}
+
+// ResolveLogRotationBehavior returns how to rotate the log file of a process, or nil if it is not rotated, falling back
+// to DefaultLogRotationMaxFiles.
+func ResolveLogRotationBehavior(staticConfig *StaticLauncherConfig) *LogRotationBehavior {
+	if staticConfig.LogRotation == nil {
+		return nil
+	}
+	// The maxSize was validated when the static configuration was parsed.
+	maxSize, err := parseByteSize(staticConfig.LogRotation.MaxSize)
+	if err != nil {
+		return nil
+	}
+	behavior := &LogRotationBehavior{
+		MaxSize:           maxSize,
+		MaxFiles:          DefaultLogRotationMaxFiles,
+		PruneRotatedAfter: staticConfig.LogRotation.PruneRotatedAfter,
+		Compress:          staticConfig.LogRotation.Compress,
+	}
+	if staticConfig.LogRotation.MaxFiles > 0 {
+		behavior.MaxFiles = staticConfig.LogRotation.MaxFiles
+	}
+	return behavior
+}
+
 func validateExecutableConfig(executable string) error {
 	if executable == "" {
 		return errors.New("Config type \"executable\" requires top-level \"executable:\" value")
@@ -15,9 +15,12 @@
 package launchlib
 
 import (
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestParseStaticConfig(t *testing.T) {
@@ -43,6 +46,7 @@ classpath:
 jvmOpts:
   - jvmOpt1
   - jvmOpt2
+strictJvmOpts: true
 args:
   - arg1
   - arg2
@@ -63,10 +67,11 @@ args:
 					Executable: "java",
 					Args:       []string{"arg1", "arg2"},
 					JavaConfig: JavaConfig{
-						MainClass: "mainClass",
-						JavaHome:  "javaHome",
-						Classpath: []string{"classpath1", "classpath2"},
-						JvmOpts:   []string{"jvmOpt1", "jvmOpt2"},
+						MainClass:     "mainClass",
+						JavaHome:      "javaHome",
+						Classpath:     []string{"classpath1", "classpath2"},
+						JvmOpts:       []string{"jvmOpt1", "jvmOpt2"},
+						StrictJvmOpts: true,
 					},
 				},
 			},
@@ -150,6 +155,264 @@ subProcesses:
 				},
 			},
 		},
+		{
+			name: "with stop config",
+			data: `
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: /usr/bin/postgres
+stopTimeout: 30s
+stopSignal: SIGINT
+subProcesses:
+  envoy:
+    configType: executable
+    executable: /etc/envoy/envoy
+    stopTimeout: 2m
+`,
+			want: PrimaryStaticLauncherConfig{
+				VersionedConfig: VersionedConfig{
+					Version: 1,
+				},
+				ServiceName: "primary",
+				StaticLauncherConfig: StaticLauncherConfig{
+					TypedConfig: TypedConfig{
+						Type: "executable",
+					},
+					StopConfig: StopConfig{
+						StopTimeout: 30 * time.Second,
+						StopSignal:  "SIGINT",
+					},
+					Executable: "/usr/bin/postgres",
+				},
+				SubProcesses: map[string]StaticLauncherConfig{
+					"envoy": {
+						TypedConfig: TypedConfig{
+							Type: "executable",
+						},
+						StopConfig: StopConfig{
+							StopTimeout: 2 * time.Minute,
+						},
+						Executable: "/etc/envoy/envoy",
+					},
+				},
+			},
+		},
+		{
+			name: "with start config",
+			data: `
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: /usr/bin/postgres
+umask: "0027"
+startupConfirmationPeriod: 10s
+readiness:
+  tcpPort: 5432
+  timeout: 2m
+crashLoop:
+  maxCrashes: 3
+  window: 5m
+`,
+			want: PrimaryStaticLauncherConfig{
+				VersionedConfig: VersionedConfig{
+					Version: 1,
+				},
+				ServiceName: "primary",
+				StaticLauncherConfig: StaticLauncherConfig{
+					TypedConfig: TypedConfig{
+						Type: "executable",
+					},
+					StartConfig: StartConfig{
+						Umask:                     "0027",
+						StartupConfirmationPeriod: 10 * time.Second,
+						Readiness: &ReadinessConfig{
+							TCPPort: 5432,
+							Timeout: 2 * time.Minute,
+						},
+						CrashLoop: &CrashLoopConfig{
+							MaxCrashes: 3,
+							Window:     5 * time.Minute,
+						},
+					},
+					Executable: "/usr/bin/postgres",
+				},
+			},
+		},
+		{
+			name: "with log rotation",
+			data: `
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: /usr/bin/postgres
+logRotation:
+  maxSize: 100M
+  maxFiles: 10
+  pruneRotatedAfter: 168h
+  compress: true
+`,
+			want: PrimaryStaticLauncherConfig{
+				VersionedConfig: VersionedConfig{
+					Version: 1,
+				},
+				ServiceName: "primary",
+				StaticLauncherConfig: StaticLauncherConfig{
+					TypedConfig: TypedConfig{
+						Type: "executable",
+					},
+					Executable: "/usr/bin/postgres",
+					LogRotation: &LogRotationConfig{
+						MaxSize:           "100M",
+						MaxFiles:          10,
+						PruneRotatedAfter: 168 * time.Hour,
+						Compress:          true,
+					},
+				},
+			},
+		},
+		{
+			name: "with pre stop",
+			data: `
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: /usr/bin/postgres
+preStop:
+  httpUrl: http://localhost:8080/drain
+  httpMethod: POST
+  timeout: 10s
+subProcesses:
+  sidecar:
+    configType: executable
+    executable: /usr/bin/envoy
+    preStop:
+      command: [curl, -X, POST, http://localhost:9901/drain_listeners]
+`,
+			want: PrimaryStaticLauncherConfig{
+				VersionedConfig: VersionedConfig{
+					Version: 1,
+				},
+				ServiceName: "primary",
+				StaticLauncherConfig: StaticLauncherConfig{
+					TypedConfig: TypedConfig{
+						Type: "executable",
+					},
+					Executable: "/usr/bin/postgres",
+					PreStop: &PreStopConfig{
+						HTTPURL:    "http://localhost:8080/drain",
+						HTTPMethod: "POST",
+						Timeout:    10 * time.Second,
+					},
+				},
+				SubProcesses: map[string]StaticLauncherConfig{
+					"sidecar": {
+						TypedConfig: TypedConfig{
+							Type: "executable",
+						},
+						Executable: "/usr/bin/envoy",
+						PreStop: &PreStopConfig{
+							Command: []string{"curl", "-X", "POST", "http://localhost:9901/drain_listeners"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "with init tasks",
+			data: `
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: /usr/bin/postgres
+initTasks:
+  - name: migrate
+    configType: java
+    mainClass: mainClass
+    classpath:
+      - classpath1
+    env:
+      DATABASE: primary
+    timeout: 5m
+  - name: certs
+    configType: executable
+    executable: /usr/bin/postgres
+    args:
+      - --generate-certs
+`,
+			want: PrimaryStaticLauncherConfig{
+				VersionedConfig: VersionedConfig{
+					Version: 1,
+				},
+				ServiceName: "primary",
+				StaticLauncherConfig: StaticLauncherConfig{
+					TypedConfig: TypedConfig{
+						Type: "executable",
+					},
+					Executable: "/usr/bin/postgres",
+				},
+				InitTasks: []InitTaskConfig{
+					{
+						Name: "migrate",
+						StaticLauncherConfig: StaticLauncherConfig{
+							TypedConfig: TypedConfig{
+								Type: "java",
+							},
+							JavaConfig: JavaConfig{
+								MainClass: "mainClass",
+								Classpath: []string{"classpath1"},
+							},
+							Env: map[string]string{"DATABASE": "primary"},
+						},
+						Timeout: 5 * time.Minute,
+					},
+					{
+						Name: "certs",
+						StaticLauncherConfig: StaticLauncherConfig{
+							TypedConfig: TypedConfig{
+								Type: "executable",
+							},
+							Executable: "/usr/bin/postgres",
+							Args:       []string{"--generate-certs"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "with entrypoints",
+			data: `
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: /usr/bin/postgres
+entrypoints:
+  upgrade:
+    executable: /usr/lib/postgresql/bin/postgres
+    args:
+      - --upgrade
+  repair: {}
+`,
+			want: PrimaryStaticLauncherConfig{
+				VersionedConfig: VersionedConfig{
+					Version: 1,
+				},
+				ServiceName: "primary",
+				StaticLauncherConfig: StaticLauncherConfig{
+					TypedConfig: TypedConfig{
+						Type: "executable",
+					},
+					Executable: "/usr/bin/postgres",
+				},
+				Entrypoints: map[string]EntrypointConfig{
+					"upgrade": {
+						Executable: "/usr/lib/postgresql/bin/postgres",
+						Args:       []string{"--upgrade"},
+					},
+					"repair": {},
+				},
+			},
+		},
 	} {
 		got, _ := parseStaticConfig([]byte(currCase.data))
 		assert.Equal(t, currCase.want, got, "Case %d: %s", i, currCase.name)
@@ -339,6 +602,62 @@ env:
 				},
 			},
 		},
+		{
+			name: "custom config with stop config",
+			data: `
+configType: executable
+configVersion: 1
+stopTimeout: 10s
+subProcesses:
+  envoy:
+    configType: executable
+    stopSignal: SIGQUIT
+`,
+			want: PrimaryCustomLauncherConfig{
+				VersionedConfig: VersionedConfig{
+					Version: 1,
+				},
+				CustomLauncherConfig: CustomLauncherConfig{
+					TypedConfig: TypedConfig{
+						Type: "executable",
+					},
+					StopConfig: StopConfig{
+						StopTimeout: 10 * time.Second,
+					},
+					Experimental: ExperimentalLauncherConfig{},
+				},
+				SubProcesses: map[string]CustomLauncherConfig{
+					"envoy": {
+						TypedConfig: TypedConfig{
+							Type: "executable",
+						},
+						StopConfig: StopConfig{
+							StopSignal: "SIGQUIT",
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "custom config with startup confirmation period",
+			data: `
+configType: executable
+configVersion: 1
+startupConfirmationPeriod: 30s
+`,
+			want: PrimaryCustomLauncherConfig{
+				VersionedConfig: VersionedConfig{
+					Version: 1,
+				},
+				CustomLauncherConfig: CustomLauncherConfig{
+					TypedConfig: TypedConfig{
+						Type: "executable",
+					},
+					StartupConfirmationPeriod: 30 * time.Second,
+					Experimental:              ExperimentalLauncherConfig{},
+				},
+			},
+		},
 	} {
 		got, _ := parseCustomConfig([]byte(currCase.data))
 		assert.Equal(t, currCase.want, got, "Case %d: %s", i, currCase.name)
@@ -502,10 +821,747 @@ subProcesses:
     configType: java
 `,
 		},
-	} {
-		_, err := parseStaticConfig([]byte(currCase.data))
-		assert.NotEqual(t, err, nil, "Case %d: %s had no errors", i, currCase.name)
-		assert.Regexp(t, currCase.msg, err.Error(), "Case %d: %s had the wrong error message", i, currCase.name)
-	}
-
+		{
+			name: "invalid stop signal",
+			msg:  "invalid stopSignal: unsupported signal 'SIGFOO'",
+			data: `
+configType: executable
+configVersion: 1
+executable: postgres
+serviceName: primary
+stopSignal: SIGFOO
+`,
+		},
+		{
+			name: "stop timeout without unit",
+			msg:  "stopTimeout must be at least 1s, found 240ns",
+			data: `
+configType: executable
+configVersion: 1
+executable: postgres
+serviceName: primary
+stopTimeout: 240
+`,
+		},
+		{
+			name: "invalid subProcess stop timeout",
+			msg: "failed to validate subProcess launcher configuration 'envoy': stopTimeout must not be negative, " +
+				"found -1m0s",
+			data: `
+configType: executable
+configVersion: 1
+executable: postgres
+serviceName: primary
+subProcesses:
+  envoy:
+    configType: executable
+    executable: envoy
+    stopTimeout: -1m
+`,
+		},
+		{
+			name: "startup confirmation period without unit",
+			msg:  "startupConfirmationPeriod must be at least 1ms, found 10ns",
+			data: `
+configType: executable
+configVersion: 1
+executable: postgres
+serviceName: primary
+startupConfirmationPeriod: 10
+`,
+		},
+		{
+			name: "invalid umask",
+			msg:  "umask must be an octal string between '0000' and '0777', found '0089'",
+			data: `
+configType: executable
+configVersion: 1
+executable: postgres
+serviceName: primary
+umask: "0089"
+`,
+		},
+		{
+			name: "multiple readiness checks",
+			msg:  "readiness must set exactly one of file, tcpPort and httpUrl, found 2",
+			data: `
+configType: executable
+configVersion: 1
+executable: postgres
+serviceName: primary
+readiness:
+  file: var/run/ready
+  tcpPort: 5432
+`,
+		},
+		{
+			name: "no readiness check",
+			msg:  "readiness must set exactly one of file, tcpPort and httpUrl, found 0",
+			data: `
+configType: executable
+configVersion: 1
+executable: postgres
+serviceName: primary
+readiness:
+  timeout: 10s
+`,
+		},
+		{
+			name: "invalid readiness URL",
+			msg:  "readiness httpUrl must be an http or https URL, found 'localhost:8080'",
+			data: `
+configType: executable
+configVersion: 1
+executable: postgres
+serviceName: primary
+readiness:
+  httpUrl: localhost:8080
+`,
+		},
+		{
+			name: "invalid readiness port",
+			msg:  "readiness tcpPort must be between 1 and 65535, found 70000",
+			data: `
+configType: executable
+configVersion: 1
+executable: postgres
+serviceName: primary
+readiness:
+  tcpPort: 70000
+`,
+		},
+		{
+			name: "negative crash loop max crashes",
+			msg:  "crashLoop maxCrashes must not be negative, found -1",
+			data: `
+configType: executable
+configVersion: 1
+executable: postgres
+serviceName: primary
+crashLoop:
+  maxCrashes: -1
+`,
+		},
+		{
+			name: "crash loop window without unit",
+			msg:  "crashLoop window must be at least 1s, found 600ns",
+			data: `
+configType: executable
+configVersion: 1
+executable: postgres
+serviceName: primary
+crashLoop:
+  window: 600
+`,
+		},
+		{
+			name: "log rotation without max size",
+			msg:  "invalid logRotation maxSize: size must be a positive number of bytes",
+			data: `
+configType: executable
+configVersion: 1
+executable: postgres
+serviceName: primary
+logRotation:
+  maxFiles: 3
+`,
+		},
+		{
+			name: "invalid log rotation max size",
+			msg:  "invalid logRotation maxSize: .* found '10T'",
+			data: `
+configType: executable
+configVersion: 1
+executable: postgres
+serviceName: primary
+logRotation:
+  maxSize: 10T
+`,
+		},
+		{
+			name: "overflowing log rotation max size",
+			msg:  "invalid logRotation maxSize: size must be at most 9223372036854775807 bytes, found '9999999999G'",
+			data: `
+configType: executable
+configVersion: 1
+executable: postgres
+serviceName: primary
+logRotation:
+  maxSize: 9999999999G
+`,
+		},
+		{
+			name: "negative log rotation max files",
+			msg:  "logRotation maxFiles must not be negative, found -1",
+			data: `
+configType: executable
+configVersion: 1
+executable: postgres
+serviceName: primary
+logRotation:
+  maxSize: 10M
+  maxFiles: -1
+`,
+		},
+		{
+			name: "pre stop without action",
+			msg:  "preStop must set exactly one of httpUrl, command and sleep, found 0",
+			data: `
+configType: executable
+configVersion: 1
+executable: postgres
+serviceName: primary
+preStop:
+  timeout: 10s
+`,
+		},
+		{
+			name: "pre stop with two actions",
+			msg:  "preStop must set exactly one of httpUrl, command and sleep, found 2",
+			data: `
+configType: executable
+configVersion: 1
+executable: postgres
+serviceName: primary
+preStop:
+  httpUrl: http://localhost:8080/drain
+  sleep: 10s
+`,
+		},
+		{
+			name: "pre stop http url not on localhost",
+			msg:  "preStop httpUrl must be on localhost, found 'http://example.com/drain'",
+			data: `
+configType: executable
+configVersion: 1
+executable: postgres
+serviceName: primary
+preStop:
+  httpUrl: http://example.com/drain
+`,
+		},
+		{
+			name: "pre stop invalid http method",
+			msg:  "preStop httpMethod must be one of .* found 'PATCH'",
+			data: `
+configType: executable
+configVersion: 1
+executable: postgres
+serviceName: primary
+preStop:
+  httpUrl: http://localhost:8080/drain
+  httpMethod: PATCH
+`,
+		},
+		{
+			name: "pre stop command not allowlisted",
+			msg:  "preStop command can run executable=.* only, found /bin/sh",
+			data: `
+configType: executable
+configVersion: 1
+executable: postgres
+serviceName: primary
+preStop:
+  command: [/bin/sh, -c, "kill 1"]
+`,
+		},
+		{
+			name: "pre stop sleep without unit",
+			msg:  "preStop sleep must be at least 1s, found 10ns",
+			data: `
+configType: executable
+configVersion: 1
+executable: postgres
+serviceName: primary
+preStop:
+  sleep: 10
+`,
+		},
+		{
+			name: "pre stop sleep longer than timeout",
+			msg:  "preStop sleep must not be longer than its timeout, found 1m0s and 30s",
+			data: `
+configType: executable
+configVersion: 1
+executable: postgres
+serviceName: primary
+preStop:
+  sleep: 1m
+  timeout: 30s
+`,
+		},
+		{
+			name: "invalid init task name",
+			msg:  "invalid initTask name 'Migrate' in static config",
+			data: `
+configType: executable
+configVersion: 1
+executable: postgres
+serviceName: primary
+initTasks:
+  - name: Migrate
+    configType: executable
+    executable: postgres
+`,
+		},
+		{
+			name: "init task named as service",
+			msg:  "initTask name 'primary' cannot be the same as ServiceName or a subProcess name",
+			data: `
+configType: executable
+configVersion: 1
+executable: postgres
+serviceName: primary
+initTasks:
+  - name: primary
+    configType: executable
+    executable: postgres
+`,
+		},
+		{
+			name: "duplicate init task names",
+			msg:  "initTask name 'migrate' is given more than once",
+			data: `
+configType: executable
+configVersion: 1
+executable: postgres
+serviceName: primary
+initTasks:
+  - name: migrate
+    configType: executable
+    executable: postgres
+  - name: migrate
+    configType: executable
+    executable: postgres
+`,
+		},
+		{
+			name: "invalid init task configuration",
+			msg:  "failed to validate initTask launcher configuration 'migrate': Can handle executable=.* only, found bash",
+			data: `
+configType: executable
+configVersion: 1
+executable: postgres
+serviceName: primary
+initTasks:
+  - name: migrate
+    configType: executable
+    executable: bash
+`,
+		},
+		{
+			name: "init task timeout without unit",
+			msg:  "initTask 'migrate' timeout must be at least 1s, found 60ns",
+			data: `
+configType: executable
+configVersion: 1
+executable: postgres
+serviceName: primary
+initTasks:
+  - name: migrate
+    configType: executable
+    executable: postgres
+    timeout: 60
+`,
+		},
+		{
+			name: "invalid entrypoint name",
+			msg:  "invalid entrypoint name 'Repair' in static config",
+			data: `
+configType: java
+configVersion: 1
+mainClass: Main
+classpath:
+  - classpath
+serviceName: primary
+entrypoints:
+  Repair:
+    mainClass: Repair
+`,
+		},
+		{
+			name: "java entrypoint without main class",
+			msg: "failed to validate entrypoint configuration 'repair': mainClass is required for an entrypoint of a " +
+				"java service",
+			data: `
+configType: java
+configVersion: 1
+mainClass: Main
+classpath:
+  - classpath
+serviceName: primary
+entrypoints:
+  repair:
+    args:
+      - --all
+`,
+		},
+		{
+			name: "java entrypoint with executable",
+			msg: "failed to validate entrypoint configuration 'repair': executable cannot be set for an entrypoint of " +
+				"a java service",
+			data: `
+configType: java
+configVersion: 1
+mainClass: Main
+classpath:
+  - classpath
+serviceName: primary
+entrypoints:
+  repair:
+    mainClass: Repair
+    executable: postgres
+`,
+		},
+		{
+			name: "executable entrypoint with main class",
+			msg: "failed to validate entrypoint configuration 'repair': mainClass cannot be set for an entrypoint of " +
+				"an executable service",
+			data: `
+configType: executable
+configVersion: 1
+executable: postgres
+serviceName: primary
+entrypoints:
+  repair:
+    mainClass: Repair
+`,
+		},
+		{
+			name: "executable entrypoint with disallowed executable",
+			msg:  "failed to validate entrypoint configuration 'repair': Can handle executable=.* only, found bash",
+			data: `
+configType: executable
+configVersion: 1
+executable: postgres
+serviceName: primary
+entrypoints:
+  repair:
+    executable: bash
+`,
+		},
+		{
+			name: "log rotation max age without unit",
+			msg:  "logRotation pruneRotatedAfter must be at least 1s, found 10ns",
+			data: `
+configType: executable
+configVersion: 1
+executable: postgres
+serviceName: primary
+logRotation:
+  maxSize: 10M
+  pruneRotatedAfter: 10
+`,
+		},
+	} {
+		_, err := parseStaticConfig([]byte(currCase.data))
+		assert.NotEqual(t, err, nil, "Case %d: %s had no errors", i, currCase.name)
+		assert.Regexp(t, currCase.msg, err.Error(), "Case %d: %s had the wrong error message", i, currCase.name)
+	}
+
+}
+
+func TestResolveStopBehavior(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		static   StaticLauncherConfig
+		custom   CustomLauncherConfig
+		expected StopBehavior
+	}{
+		{
+			name:   "executable defaults",
+			static: StaticLauncherConfig{TypedConfig: TypedConfig{Type: "executable"}},
+			expected: StopBehavior{
+				Timeout: DefaultStopTimeout,
+				Signal:  DefaultStopSignal,
+			},
+		},
+		{
+			name:   "java defaults",
+			static: StaticLauncherConfig{TypedConfig: TypedConfig{Type: "java"}},
+			expected: StopBehavior{
+				Timeout:        DefaultStopTimeout,
+				Signal:         DefaultStopSignal,
+				ThreadDumpWait: DefaultThreadDumpWait,
+			},
+		},
+		{
+			name: "static values",
+			static: StaticLauncherConfig{
+				TypedConfig: TypedConfig{Type: "java"},
+				StopConfig: StopConfig{
+					StopTimeout:    10 * time.Second,
+					StopSignal:     "SIGINT",
+					ThreadDumpWait: 2 * time.Second,
+				},
+			},
+			expected: StopBehavior{
+				Timeout:        10 * time.Second,
+				Signal:         syscall.SIGINT,
+				ThreadDumpWait: 2 * time.Second,
+			},
+		},
+		{
+			name: "custom values override static values",
+			static: StaticLauncherConfig{
+				TypedConfig: TypedConfig{Type: "java"},
+				StopConfig: StopConfig{
+					StopTimeout:    10 * time.Second,
+					StopSignal:     "SIGINT",
+					ThreadDumpWait: 2 * time.Second,
+				},
+			},
+			custom: CustomLauncherConfig{
+				StopConfig: StopConfig{
+					StopTimeout:    20 * time.Second,
+					StopSignal:     "SIGQUIT",
+					ThreadDumpWait: 3 * time.Second,
+				},
+			},
+			expected: StopBehavior{
+				Timeout:        20 * time.Second,
+				Signal:         syscall.SIGQUIT,
+				ThreadDumpWait: 3 * time.Second,
+			},
+		},
+		{
+			name: "custom values override defaults independently",
+			static: StaticLauncherConfig{
+				TypedConfig: TypedConfig{Type: "executable"},
+				StopConfig:  StopConfig{StopTimeout: 10 * time.Second},
+			},
+			custom: CustomLauncherConfig{StopConfig: StopConfig{StopSignal: "USR2"}},
+			expected: StopBehavior{
+				Timeout: 10 * time.Second,
+				Signal:  syscall.SIGUSR2,
+			},
+		},
+		{
+			name: "pre stop timeout defaults",
+			static: StaticLauncherConfig{
+				TypedConfig: TypedConfig{Type: "executable"},
+				PreStop:     &PreStopConfig{HTTPURL: "http://localhost:8080/drain"},
+			},
+			expected: StopBehavior{
+				Timeout: DefaultStopTimeout,
+				Signal:  DefaultStopSignal,
+				PreStop: &PreStopConfig{
+					HTTPURL:    "http://localhost:8080/drain",
+					HTTPMethod: "GET",
+					Timeout:    DefaultPreStopTimeout,
+				},
+			},
+		},
+		{
+			name: "pre stop timeout defaults to sleep longer than default",
+			static: StaticLauncherConfig{
+				TypedConfig: TypedConfig{Type: "executable"},
+				PreStop:     &PreStopConfig{Sleep: time.Minute},
+			},
+			expected: StopBehavior{
+				Timeout: DefaultStopTimeout,
+				Signal:  DefaultStopSignal,
+				PreStop: &PreStopConfig{Sleep: time.Minute, Timeout: time.Minute},
+			},
+		},
+		{
+			name:   "thread dump disabled in custom config",
+			static: StaticLauncherConfig{TypedConfig: TypedConfig{Type: "java"}},
+			custom: CustomLauncherConfig{StopConfig: StopConfig{DisableThreadDumpBeforeKill: true}},
+			expected: StopBehavior{
+				Timeout: DefaultStopTimeout,
+				Signal:  DefaultStopSignal,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			behavior, err := ResolveStopBehavior(&tc.static, &tc.custom)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, behavior)
+		})
+	}
+}
+
+func TestResolveStartBehavior(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		static   StaticLauncherConfig
+		custom   CustomLauncherConfig
+		expected StartBehavior
+	}{
+		{
+			name:     "defaults",
+			expected: StartBehavior{ConfirmationPeriod: DefaultStartupConfirmationPeriod},
+		},
+		{
+			name: "static values",
+			static: StaticLauncherConfig{
+				StartConfig: StartConfig{
+					StartupConfirmationPeriod: 10 * time.Second,
+					Readiness:                 &ReadinessConfig{File: "var/run/ready", Timeout: time.Minute},
+				},
+			},
+			expected: StartBehavior{
+				ConfirmationPeriod: 10 * time.Second,
+				Readiness:          &ReadinessConfig{File: "var/run/ready", Timeout: time.Minute},
+			},
+		},
+		{
+			name:   "umask",
+			static: StaticLauncherConfig{StartConfig: StartConfig{Umask: "0027"}},
+			expected: StartBehavior{
+				Umask:              func() *int { umask := 0027; return &umask }(),
+				ConfirmationPeriod: DefaultStartupConfirmationPeriod,
+			},
+		},
+		{
+			name: "custom overrides static and readiness timeout defaults",
+			static: StaticLauncherConfig{
+				StartConfig: StartConfig{
+					StartupConfirmationPeriod: 10 * time.Second,
+					Readiness:                 &ReadinessConfig{TCPPort: 8080},
+				},
+			},
+			custom: CustomLauncherConfig{StartupConfirmationPeriod: 20 * time.Second},
+			expected: StartBehavior{
+				ConfirmationPeriod: 20 * time.Second,
+				Readiness:          &ReadinessConfig{TCPPort: 8080, Timeout: DefaultReadinessTimeout},
+			},
+		},
+		{
+			name:   "crash loop defaults",
+			static: StaticLauncherConfig{StartConfig: StartConfig{CrashLoop: &CrashLoopConfig{}}},
+			expected: StartBehavior{
+				ConfirmationPeriod: DefaultStartupConfirmationPeriod,
+				CrashLoop: &CrashLoopConfig{
+					MaxCrashes: DefaultCrashLoopMaxCrashes,
+					Window:     DefaultCrashLoopWindow,
+				},
+			},
+		},
+		{
+			name: "crash loop static values",
+			static: StaticLauncherConfig{
+				StartConfig: StartConfig{CrashLoop: &CrashLoopConfig{MaxCrashes: 2, Window: time.Hour}},
+			},
+			expected: StartBehavior{
+				ConfirmationPeriod: DefaultStartupConfirmationPeriod,
+				CrashLoop:          &CrashLoopConfig{MaxCrashes: 2, Window: time.Hour},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, ResolveStartBehavior(&tc.static, &tc.custom))
+		})
+	}
+}
+
+func TestResolveLogRotationBehavior(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		static   StaticLauncherConfig
+		expected *LogRotationBehavior
+	}{
+		{
+			name: "not rotated",
+		},
+		{
+			name:   "defaults",
+			static: StaticLauncherConfig{LogRotation: &LogRotationConfig{MaxSize: "1024"}},
+			expected: &LogRotationBehavior{
+				MaxSize:  1024,
+				MaxFiles: DefaultLogRotationMaxFiles,
+			},
+		},
+		{
+			name: "static values",
+			static: StaticLauncherConfig{
+				LogRotation: &LogRotationConfig{MaxSize: "2g", MaxFiles: 3, PruneRotatedAfter: time.Hour, Compress: true},
+			},
+			expected: &LogRotationBehavior{
+				MaxSize:           2 << 30,
+				MaxFiles:          3,
+				PruneRotatedAfter: time.Hour,
+				Compress:          true,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, ResolveLogRotationBehavior(&tc.static))
+		})
+	}
+}
+
+func TestPrimaryStaticLauncherConfig_EntrypointStaticConfig(t *testing.T) {
+	config := PrimaryStaticLauncherConfig{
+		ServiceName: "primary",
+		StaticLauncherConfig: StaticLauncherConfig{
+			TypedConfig: TypedConfig{Type: "java"},
+			JavaConfig: JavaConfig{
+				MainClass: "Main",
+				JvmOpts:   []string{"-Xmx1g"},
+				Classpath: []string{"classpath"},
+			},
+			Env:  map[string]string{"KEY": "value"},
+			Args: []string{"server"},
+		},
+		Entrypoints: map[string]EntrypointConfig{
+			"repair":  {MainClass: "Repair", Args: []string{"--all"}},
+			"migrate": {MainClass: "Migrate"},
+		},
+	}
+
+	entrypoint, err := config.EntrypointStaticConfig("repair")
+	require.NoError(t, err)
+	assert.Equal(t, StaticLauncherConfig{
+		TypedConfig: TypedConfig{Type: "java"},
+		JavaConfig: JavaConfig{
+			MainClass: "Repair",
+			JvmOpts:   []string{"-Xmx1g"},
+			Classpath: []string{"classpath"},
+		},
+		Env:  map[string]string{"KEY": "value"},
+		Args: []string{"--all"},
+	}, entrypoint)
+	assert.Equal(t, "Main", config.MainClass, "the primary configuration should not be modified")
+
+	entrypoint, err = config.EntrypointStaticConfig("migrate")
+	require.NoError(t, err)
+	assert.Equal(t, "Migrate", entrypoint.MainClass)
+	assert.Empty(t, entrypoint.Args)
+
+	_, err = config.EntrypointStaticConfig("backfill")
+	assert.EqualError(t, err, "no entrypoint named 'backfill' is configured, found: [migrate, repair]")
+}
+
+func TestStopBehavior_ThreadDumpAfter(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		behavior      StopBehavior
+		expectedAfter time.Duration
+		expectedOk    bool
+	}{
+		{
+			name:     "disabled",
+			behavior: StopBehavior{Timeout: 10 * time.Second},
+		},
+		{
+			name:          "before timeout",
+			behavior:      StopBehavior{Timeout: 10 * time.Second, ThreadDumpWait: 3 * time.Second},
+			expectedAfter: 7 * time.Second,
+			expectedOk:    true,
+		},
+		{
+			name:          "wait longer than timeout",
+			behavior:      StopBehavior{Timeout: 4 * time.Second, ThreadDumpWait: 5 * time.Second},
+			expectedAfter: 2 * time.Second,
+			expectedOk:    true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			after, ok := tc.behavior.ThreadDumpAfter()
+			assert.Equal(t, tc.expectedOk, ok)
+			assert.Equal(t, tc.expectedAfter, after)
+		})
+	}
 }
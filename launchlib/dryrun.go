@@ -0,0 +1,168 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// DryRunFormatJSON and DryRunFormatShell are the formats WriteDryRun writes descriptions of commands in.
+	DryRunFormatJSON  = "json"
+	DryRunFormatShell = "shell"
+
+	commandTypePrimary    = "primary"
+	commandTypeSubProcess = "subProcess"
+	commandTypeInitTask   = "initTask"
+)
+
+// shellSafePattern matches the arguments that need no quoting in a shell.
+var shellSafePattern = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// CommandDescription describes a compiled command of a service as it would be run, for a dry run.
+type CommandDescription struct {
+	Name       string   `json:"name"`
+	Type       string   `json:"type"`
+	Executable string   `json:"executable"`
+	Args       []string `json:"args"`
+	// Env are the environment variables the command is run with in addition to the environment of the launcher.
+	Env map[string]string `json:"env,omitempty"`
+	Dir string            `json:"dir"`
+	// CgroupsV1 are the cgroups of each controller the command is run in through cgexec.
+	CgroupsV1 map[string]string `json:"cgroupsV1,omitempty"`
+	// Heap is how the heap of the command is sized, and is only set for java commands.
+	Heap *HeapSizing `json:"heap,omitempty"`
+}
+
+// DescribeServiceCmds describes the given commands compiled from the given configurations of the service whose root
+// is the given working directory: the primary command, followed by those of the subProcesses by name and of the
+// initTasks in order.
+func DescribeServiceCmds(workingDir string, staticConfig *PrimaryStaticLauncherConfig,
+	customConfig *PrimaryCustomLauncherConfig, serviceCmds *ServiceCmds) []CommandDescription {
+	descriptions := []CommandDescription{describeCmd(staticConfig.ServiceName, commandTypePrimary, serviceCmds.Primary,
+		workingDir, &staticConfig.StaticLauncherConfig, &customConfig.CustomLauncherConfig, customConfig.CgroupsV1)}
+
+	names := make([]string, 0, len(serviceCmds.SubProcesses))
+	for name := range serviceCmds.SubProcesses {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		subStatic, subCustom := staticConfig.SubProcesses[name], customConfig.SubProcesses[name]
+		descriptions = append(descriptions, describeCmd(name, commandTypeSubProcess, serviceCmds.SubProcesses[name],
+			workingDir, &subStatic, &subCustom, customConfig.CgroupsV1))
+	}
+
+	for i, task := range serviceCmds.InitTasks {
+		taskStatic := staticConfig.InitTasks[i].StaticLauncherConfig
+		taskCustom := CustomLauncherConfig{TypedConfig: taskStatic.TypedConfig}
+		descriptions = append(descriptions, describeCmd(task.Name, commandTypeInitTask, task.Cmd, workingDir,
+			&taskStatic, &taskCustom, customConfig.CgroupsV1))
+	}
+	return descriptions
+}
+
+func describeCmd(name, cmdType string, cmd *exec.Cmd, workingDir string, staticConfig *StaticLauncherConfig,
+	customConfig *CustomLauncherConfig, cgroupsV1 map[string]string) CommandDescription {
+	env := ConfiguredEnv(staticConfig, customConfig, workingDir)
+	if len(env) == 0 {
+		env = nil
+	}
+	return CommandDescription{
+		Name:       name,
+		Type:       cmdType,
+		Executable: cmd.Path,
+		Args:       cmd.Args,
+		Env:        env,
+		Dir:        workingDir,
+		CgroupsV1:  cgroupsV1,
+		Heap:       ResolveHeapSizing(staticConfig, customConfig),
+	}
+}
+
+// WriteDryRun writes the given descriptions of commands to the given writer in the given format: as a JSON array, or
+// as a shell command for each, preceded by comments on how it is run.
+func WriteDryRun(w io.Writer, descriptions []CommandDescription, format string) error {
+	switch format {
+	case DryRunFormatJSON:
+		descriptionsBytes, err := json.MarshalIndent(descriptions, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "failed to serialize commands")
+		}
+		_, err = fmt.Fprintln(w, string(descriptionsBytes))
+		return err
+	case DryRunFormatShell:
+		for _, description := range descriptions {
+			if _, err := fmt.Fprint(w, description.shellCommand()); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return errors.Errorf("invalid format '%s', must be '%s' or '%s'", format, DryRunFormatJSON,
+			DryRunFormatShell)
+	}
+}
+
+// shellCommand returns the description as a shell command that runs the command, preceded by comments on its name,
+// type and heap sizing.
+func (d CommandDescription) shellCommand() string {
+	var b strings.Builder
+	_, _ = fmt.Fprintf(&b, "# %s (%s)\n", d.Name, d.Type)
+	if d.Heap != nil {
+		_, _ = fmt.Fprintf(&b, "# %s\n", d.Heap.Reason)
+		if len(d.Heap.Dropped) > 0 {
+			_, _ = fmt.Fprintf(&b, "# Dropped: %s\n", strings.Join(d.Heap.Dropped, " "))
+		}
+		if len(d.Heap.Added) > 0 {
+			_, _ = fmt.Fprintf(&b, "# Added: %s\n", strings.Join(d.Heap.Added, " "))
+		}
+	}
+
+	words := []string{"cd", shellQuote(d.Dir), "&&"}
+	if len(d.Env) > 0 {
+		keys := make([]string, 0, len(d.Env))
+		for key := range d.Env {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		words = append(words, "env")
+		for _, key := range keys {
+			words = append(words, shellQuote(key+"="+d.Env[key]))
+		}
+	}
+	words = append(words, shellQuote(d.Executable))
+	for _, arg := range d.Args[1:] {
+		words = append(words, shellQuote(arg))
+	}
+	_, _ = fmt.Fprintf(&b, "%s\n", strings.Join(words, " "))
+	return b.String()
+}
+
+// shellQuote returns the given argument quoted so that a POSIX shell reads it as a single word.
+func shellQuote(arg string) string {
+	if shellSafePattern.MatchString(arg) {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
@@ -0,0 +1,147 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"bytes"
+	"encoding/json"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDescribeServiceCmds(t *testing.T) {
+	staticConfig := PrimaryStaticLauncherConfig{
+		ServiceName: "primary",
+		StaticLauncherConfig: StaticLauncherConfig{
+			TypedConfig: TypedConfig{Type: "executable"},
+			Env:         map[string]string{"ROOT": "{{CWD}}/root"},
+		},
+		SubProcesses: map[string]StaticLauncherConfig{
+			"sidecar": {TypedConfig: TypedConfig{Type: "executable"}},
+		},
+		InitTasks: []InitTaskConfig{
+			{Name: "migrate", StaticLauncherConfig: StaticLauncherConfig{TypedConfig: TypedConfig{Type: "executable"}}},
+		},
+	}
+	customConfig := PrimaryCustomLauncherConfig{
+		CustomLauncherConfig: CustomLauncherConfig{Env: map[string]string{"KEY": "value"}},
+		SubProcesses:         map[string]CustomLauncherConfig{"sidecar": {}},
+		CgroupsV1:            map[string]string{"memory": "service"},
+	}
+	serviceCmds := &ServiceCmds{
+		Primary:      &exec.Cmd{Path: CgexecPath, Args: []string{CgexecPath, "-g", "memory:service", "/bin/primary"}},
+		SubProcesses: map[string]*exec.Cmd{"sidecar": {Path: "/bin/sidecar", Args: []string{"/bin/sidecar"}}},
+		InitTasks:    []InitTaskCmd{{Name: "migrate", Cmd: &exec.Cmd{Path: "/bin/migrate", Args: []string{"/bin/migrate"}}}},
+	}
+
+	assert.Equal(t, []CommandDescription{
+		{
+			Name:       "primary",
+			Type:       "primary",
+			Executable: CgexecPath,
+			Args:       []string{CgexecPath, "-g", "memory:service", "/bin/primary"},
+			Env:        map[string]string{"ROOT": "/srv/service/root", "KEY": "value"},
+			Dir:        "/srv/service",
+			CgroupsV1:  map[string]string{"memory": "service"},
+		},
+		{
+			Name:       "sidecar",
+			Type:       "subProcess",
+			Executable: "/bin/sidecar",
+			Args:       []string{"/bin/sidecar"},
+			Dir:        "/srv/service",
+			CgroupsV1:  map[string]string{"memory": "service"},
+		},
+		{
+			Name:       "migrate",
+			Type:       "initTask",
+			Executable: "/bin/migrate",
+			Args:       []string{"/bin/migrate"},
+			Dir:        "/srv/service",
+			CgroupsV1:  map[string]string{"memory": "service"},
+		},
+	}, DescribeServiceCmds("/srv/service", &staticConfig, &customConfig, serviceCmds))
+}
+
+func TestWriteDryRun(t *testing.T) {
+	descriptions := []CommandDescription{
+		{
+			Name:       "primary",
+			Type:       "primary",
+			Executable: "/jdk/bin/java",
+			Args:       []string{"/jdk/bin/java", "-Xms512", "-Xmx512", "-classpath", "/srv/service/lib/*", "Main", "it's"},
+			Env:        map[string]string{"B": "two words", "A": "1"},
+			Dir:        "/srv/service",
+			Heap: &HeapSizing{
+				ContainerSupport: true,
+				Reason:           "Heap sized from the cgroup memory limit and the number of processors",
+				MemoryLimitBytes: 1024,
+				Processors:       2,
+				HeapSizeBytes:    512,
+				Dropped:          []string{"-Xmx1g"},
+				Added:            []string{"-Xms512", "-Xmx512"},
+			},
+		},
+		{
+			Name:       "sidecar",
+			Type:       "subProcess",
+			Executable: "/bin/sidecar",
+			Args:       []string{"/bin/sidecar"},
+			Dir:        "/srv/service",
+		},
+	}
+
+	var shell bytes.Buffer
+	require.NoError(t, WriteDryRun(&shell, descriptions, DryRunFormatShell))
+	assert.Equal(t, `# primary (primary)
+# Heap sized from the cgroup memory limit and the number of processors
+# Dropped: -Xmx1g
+# Added: -Xms512 -Xmx512
+cd /srv/service && env A=1 'B=two words' /jdk/bin/java -Xms512 -Xmx512 -classpath '/srv/service/lib/*' Main 'it'\''s'
+# sidecar (subProcess)
+cd /srv/service && /bin/sidecar
+`, shell.String())
+
+	var jsonOutput bytes.Buffer
+	require.NoError(t, WriteDryRun(&jsonOutput, descriptions, DryRunFormatJSON))
+	var decoded []CommandDescription
+	require.NoError(t, json.Unmarshal(jsonOutput.Bytes(), &decoded))
+	assert.Equal(t, descriptions, decoded)
+	assert.NotContains(t, jsonOutput.String(), `"heap": null`)
+
+	assert.EqualError(t, WriteDryRun(&bytes.Buffer{}, descriptions, "xml"),
+		"invalid format 'xml', must be 'json' or 'shell'")
+}
+
+func TestResolveHeapSizing(t *testing.T) {
+	assert.Nil(t, ResolveHeapSizing(&StaticLauncherConfig{TypedConfig: TypedConfig{Type: "executable"}},
+		&CustomLauncherConfig{}))
+
+	t.Setenv("CONTAINER", "")
+	assert.Equal(t, &HeapSizing{Reason: "Container support disabled in launcher-custom.yml"}, ResolveHeapSizing(
+		&StaticLauncherConfig{TypedConfig: TypedConfig{Type: "java"}, JavaConfig: JavaConfig{JvmOpts: []string{"-Xmx1g"}}},
+		&CustomLauncherConfig{DisableContainerSupport: true}))
+	assert.Equal(t, &HeapSizing{
+		ContainerSupport: true,
+		Reason:           "Heap sized by the -XX:MaxRAMPercentage or -XX:InitialRAMPercentage given",
+		Dropped:          []string{"-Xmx1g", "-Xms1g"},
+		DroppedReason:    ramPercentageDroppedReason,
+	}, ResolveHeapSizing(
+		&StaticLauncherConfig{TypedConfig: TypedConfig{Type: "java"}, JavaConfig: JavaConfig{JvmOpts: []string{"-Xmx1g"}}},
+		&CustomLauncherConfig{JvmOpts: []string{"-Xms1g", "-XX:MaxRAMPercentage=50.0"}}))
+}
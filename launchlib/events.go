@@ -0,0 +1,97 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// EventLogFileName is the name of the file in the log directory of a service that go-init and go-java-launcher
+	// append the lifecycle events of the service to.
+	EventLogFileName = "launcher-events.jsonl"
+	// LogDirEnvVar is the environment variable go-init and go-java-launcher read the log directory of a service from,
+	// which is DefaultLogDir if unset. A relative log directory is relative to the service root.
+	LogDirEnvVar  = "GO_INIT_LOG_DIR"
+	DefaultLogDir = "var/log"
+)
+
+// EventType is the type of a lifecycle event.
+type EventType string
+
+// The types of lifecycle events.
+const (
+	// EventStartRequested is recorded before a process is started.
+	EventStartRequested EventType = "startRequested"
+	// EventProcessStarted is recorded once a process has started, with its pid and command hash.
+	EventProcessStarted EventType = "processStarted"
+	// EventSignalSent is recorded when a process is sent a signal other than SIGKILL.
+	EventSignalSent EventType = "signalSent"
+	// EventKillEscalated is recorded when a process that did not stop within its stop timeout is sent a SIGKILL.
+	EventKillEscalated EventType = "killEscalated"
+	// EventExitObserved is recorded when a process is observed to have exited.
+	EventExitObserved EventType = "exitObserved"
+	// EventConfigError is recorded when the configuration of the service cannot be read or compiled.
+	EventConfigError EventType = "configError"
+)
+
+// Event is a lifecycle event of a service, as appended to its event log as a line of JSON.
+type Event struct {
+	Time   time.Time `json:"time"`
+	Type   EventType `json:"type"`
+	Source string    `json:"source"`
+	// Process is the name of the process the event is of, which is unset for events of the service as a whole.
+	Process     string `json:"process,omitempty"`
+	Pid         int    `json:"pid,omitempty"`
+	CommandHash string `json:"commandHash,omitempty"`
+	Signal      int    `json:"signal,omitempty"`
+	// ExitCode is set for exits observed of processes that were not terminated by a signal.
+	ExitCode *int   `json:"exitCode,omitempty"`
+	Message  string `json:"message,omitempty"`
+}
+
+// EventLog appends the events recorded by Source, e.g. go-init, to the file at Path.
+type EventLog struct {
+	Path   string
+	Source string
+}
+
+// Append appends the given event to the event log as a single line of JSON, setting its source and, if unset, its time.
+// Each event is appended with a single write to a file opened for appending, so that the events of go-init, its shims
+// and the launcher are not interleaved. The directory of the event log is not created, since it is the log directory of
+// the service.
+func (l EventLog) Append(event Event) error {
+	event.Source = l.Source
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	eventBytes, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrap(err, "failed to serialize event")
+	}
+	file, err := os.OpenFile(l.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open event log '%s'", l.Path)
+	}
+	if _, err := file.Write(append(eventBytes, '\n')); err != nil {
+		_ = file.Close()
+		return errors.Wrapf(err, "failed to append to event log '%s'", l.Path)
+	}
+	return errors.Wrapf(file.Close(), "failed to close event log '%s'", l.Path)
+}
@@ -0,0 +1,51 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventLog_Append(t *testing.T) {
+	eventLog := EventLog{Path: filepath.Join(t.TempDir(), EventLogFileName), Source: "go-init"}
+	startedAt := time.Date(2026, time.January, 2, 3, 4, 5, 0, time.UTC)
+	require.NoError(t, eventLog.Append(Event{
+		Time:        startedAt,
+		Type:        EventProcessStarted,
+		Process:     "primary",
+		Pid:         1234,
+		CommandHash: "abc123",
+	}))
+	exitCode := 0
+	require.NoError(t, eventLog.Append(Event{Type: EventExitObserved, Process: "primary", Pid: 1234,
+		ExitCode: &exitCode}))
+
+	content, err := ioutil.ReadFile(eventLog.Path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	require.Len(t, lines, 2)
+	assert.Equal(t, `{"time":"2026-01-02T03:04:05Z","type":"processStarted","source":"go-init","process":"primary",`+
+		`"pid":1234,"commandHash":"abc123"}`, lines[0])
+	assert.Regexp(t, `^\{"time":"[^"]+","type":"exitObserved","source":"go-init","process":"primary","pid":1234,`+
+		`"exitCode":0\}$`, lines[1])
+	assert.NotContains(t, lines[1], `"time":"0001-01-01T00:00:00Z"`, "events should be timestamped if not already")
+}
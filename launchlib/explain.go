@@ -0,0 +1,149 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// ExplainFormatJSON and ExplainFormatText are the formats WriteExplanations writes explanations in.
+	ExplainFormatJSON = "json"
+	ExplainFormatText = "text"
+
+	// The origins of JVM args. The jvmOpts are not expanded as env values are, so no arg originates from an expansion.
+	argOriginStaticJvmOpts    = "static jvmOpts"
+	argOriginCustomJvmOpts    = "custom jvmOpts"
+	argOriginStaticClasspath  = "static classpath"
+	argOriginContainerSupport = "container support"
+)
+
+// JvmArgsExplanation explains where each JVM arg of a java process comes from, and why any of the jvmOpts configured
+// for it are dropped.
+type JvmArgsExplanation struct {
+	Name    string         `json:"name"`
+	Args    []ExplainedArg `json:"args"`
+	Dropped []ExplainedArg `json:"dropped,omitempty"`
+	Heap    HeapSizing     `json:"heap"`
+	// Warnings are the problems found with the jvmOpts, as the launcher warns of them.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// ExplainedArg is a JVM arg along with where it comes from and, if it is dropped, why.
+type ExplainedArg struct {
+	Arg    string `json:"arg"`
+	Origin string `json:"origin"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// ExplainJvmArgs explains the JVM args, those between the java executable and the main class, of the process with the
+// given name and static and custom configurations of a service whose root is the given working directory, along with
+// those dropped by container support or as they are overridden, as the jvmOpts are built when the command of the
+// process is compiled. Returns nil if the process is not a java process.
+func ExplainJvmArgs(name string, staticConfig *StaticLauncherConfig, customConfig *CustomLauncherConfig,
+	workingDir string) *JvmArgsExplanation {
+	if staticConfig.Type != "java" {
+		return nil
+	}
+
+	jvmArgs := createJvmOpts(staticConfig, customConfig, ioutil.Discard)
+	explanation := &JvmArgsExplanation{
+		Name:     name,
+		Args:     jvmArgs.effective,
+		Dropped:  jvmArgs.dropped,
+		Heap:     jvmArgs.sizing,
+		Warnings: jvmArgs.warnings,
+	}
+	classpath := joinClasspathEntries(absolutizeClasspathEntries(workingDir, staticConfig.JavaConfig.Classpath))
+	explanation.Args = append(explanation.Args,
+		ExplainedArg{Arg: "-classpath", Origin: argOriginStaticClasspath},
+		ExplainedArg{Arg: classpath, Origin: argOriginStaticClasspath})
+	return explanation
+}
+
+// ExplainServiceJvmArgs explains the JVM args of each java process of the service whose root is the given working
+// directory: the primary process, followed by the subProcesses by name and the initTasks in order.
+func ExplainServiceJvmArgs(workingDir string, staticConfig *PrimaryStaticLauncherConfig,
+	customConfig *PrimaryCustomLauncherConfig) []JvmArgsExplanation {
+	var explanations []JvmArgsExplanation
+	add := func(explanation *JvmArgsExplanation) {
+		if explanation != nil {
+			explanations = append(explanations, *explanation)
+		}
+	}
+
+	add(ExplainJvmArgs(staticConfig.ServiceName, &staticConfig.StaticLauncherConfig,
+		&customConfig.CustomLauncherConfig, workingDir))
+	names := make([]string, 0, len(staticConfig.SubProcesses))
+	for name := range staticConfig.SubProcesses {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		subStatic, subCustom := staticConfig.SubProcesses[name], customConfig.SubProcesses[name]
+		add(ExplainJvmArgs(name, &subStatic, &subCustom, workingDir))
+	}
+	for _, task := range staticConfig.InitTasks {
+		taskCustom := CustomLauncherConfig{TypedConfig: task.TypedConfig}
+		add(ExplainJvmArgs(task.Name, &task.StaticLauncherConfig, &taskCustom, workingDir))
+	}
+	return explanations
+}
+
+// WriteExplanations writes the given explanations to the given writer in the given format: as a JSON array, or as a
+// table of the args of each process followed by a table of those dropped and any warnings.
+func WriteExplanations(w io.Writer, explanations []JvmArgsExplanation, format string) error {
+	switch format {
+	case ExplainFormatJSON:
+		explanationsBytes, err := json.MarshalIndent(explanations, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "failed to serialize explanations")
+		}
+		_, err = fmt.Fprintln(w, string(explanationsBytes))
+		return err
+	case ExplainFormatText:
+		table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for i, explanation := range explanations {
+			if i > 0 {
+				_, _ = fmt.Fprintln(table)
+			}
+			_, _ = fmt.Fprintf(table, "%s: %s\n", explanation.Name, explanation.Heap.Reason)
+			_, _ = fmt.Fprintln(table, "ARG\tORIGIN")
+			for _, arg := range explanation.Args {
+				_, _ = fmt.Fprintf(table, "%s\t%s\n", arg.Arg, arg.Origin)
+			}
+			if len(explanation.Dropped) > 0 {
+				_, _ = fmt.Fprintln(table, "DROPPED\tORIGIN\tREASON")
+				for _, arg := range explanation.Dropped {
+					_, _ = fmt.Fprintf(table, "%s\t%s\t%s\n", arg.Arg, arg.Origin, arg.Reason)
+				}
+			}
+			for _, warning := range explanation.Warnings {
+				_, _ = fmt.Fprintf(table, "Warning: %s\n", warning)
+			}
+		}
+		return table.Flush()
+	default:
+		return errors.Errorf("invalid format '%s', must be '%s' or '%s'", format, ExplainFormatJSON,
+			ExplainFormatText)
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplainJvmArgs(t *testing.T) {
+	staticConfig := StaticLauncherConfig{
+		TypedConfig: TypedConfig{Type: "java"},
+		JavaConfig: JavaConfig{
+			JvmOpts:   []string{"-Xmx1g", "-Dkey=value"},
+			Classpath: []string{"lib/*"},
+		},
+	}
+
+	assert.Nil(t, ExplainJvmArgs("sidecar", &StaticLauncherConfig{TypedConfig: TypedConfig{Type: "executable"}},
+		&CustomLauncherConfig{}, "/srv/service"))
+
+	t.Setenv("CONTAINER", "")
+	assert.Equal(t, &JvmArgsExplanation{
+		Name: "primary",
+		Args: []ExplainedArg{
+			{Arg: "-Xmx1g", Origin: "static jvmOpts"},
+			{Arg: "-Dkey=value", Origin: "static jvmOpts"},
+			{Arg: "-Xms1g", Origin: "custom jvmOpts"},
+			{Arg: "-classpath", Origin: "static classpath"},
+			{Arg: "/srv/service/lib/*", Origin: "static classpath"},
+		},
+		Heap: HeapSizing{Reason: "Container support disabled in launcher-custom.yml"},
+	}, ExplainJvmArgs("primary", &staticConfig,
+		&CustomLauncherConfig{JvmOpts: []string{"-Xms1g"}, DisableContainerSupport: true}, "/srv/service"))
+
+	assert.Equal(t, &JvmArgsExplanation{
+		Name: "primary",
+		Args: []ExplainedArg{
+			{Arg: "-Xmx1g", Origin: "static jvmOpts"},
+			{Arg: "-Dkey=other", Origin: "custom jvmOpts"},
+			{Arg: "-classpath", Origin: "static classpath"},
+			{Arg: "/srv/service/lib/*", Origin: "static classpath"},
+		},
+		Dropped:  []ExplainedArg{{Arg: "-Dkey=value", Origin: "static jvmOpts", Reason: "overridden by -Dkey=other"}},
+		Heap:     HeapSizing{Reason: "Container support disabled in launcher-custom.yml"},
+		Warnings: []string{"-Dkey=value is overridden by -Dkey=other"},
+	}, ExplainJvmArgs("primary", &staticConfig,
+		&CustomLauncherConfig{JvmOpts: []string{"-Dkey=other"}, DisableContainerSupport: true}, "/srv/service"))
+
+	assert.Equal(t, &JvmArgsExplanation{
+		Name: "primary",
+		Args: []ExplainedArg{
+			{Arg: "-Dkey=value", Origin: "static jvmOpts"},
+			{Arg: "-XX:MaxRAMPercentage=50.0", Origin: "custom jvmOpts"},
+			{Arg: "-classpath", Origin: "static classpath"},
+			{Arg: "/srv/service/lib/*", Origin: "static classpath"},
+		},
+		Dropped: []ExplainedArg{
+			{
+				Arg:    "-Xmx1g",
+				Origin: "static jvmOpts",
+				Reason: "dropped in a container in favour of the -XX:MaxRAMPercentage or -XX:InitialRAMPercentage given",
+			},
+			{
+				Arg:    "-Xms1g",
+				Origin: "custom jvmOpts",
+				Reason: "dropped in a container in favour of the -XX:MaxRAMPercentage or -XX:InitialRAMPercentage given",
+			},
+		},
+		Heap: HeapSizing{
+			ContainerSupport: true,
+			Reason:           "Heap sized by the -XX:MaxRAMPercentage or -XX:InitialRAMPercentage given",
+			Dropped:          []string{"-Xmx1g", "-Xms1g"},
+			DroppedReason:    ramPercentageDroppedReason,
+		},
+	}, ExplainJvmArgs("primary", &staticConfig,
+		&CustomLauncherConfig{JvmOpts: []string{"-Xms1g", "-XX:MaxRAMPercentage=50.0"}}, "/srv/service"))
+}
+
+func TestWriteExplanations(t *testing.T) {
+	explanations := []JvmArgsExplanation{
+		{
+			Name: "primary",
+			Args: []ExplainedArg{
+				{Arg: "-Dkey=value", Origin: "static jvmOpts"},
+				{Arg: "-XX:InitialRAMPercentage=75.0", Origin: "container support"},
+			},
+			Dropped: []ExplainedArg{{Arg: "-Xmx1g", Origin: "custom jvmOpts", Reason: "replaced"}},
+			Heap:    HeapSizing{ContainerSupport: true, Reason: "Heap sized as a percentage"},
+		},
+		{
+			Name:     "sidecar",
+			Args:     []ExplainedArg{{Arg: "-Xmx1g", Origin: "static jvmOpts"}},
+			Heap:     HeapSizing{Reason: "Container support disabled: CONTAINER is not set"},
+			Warnings: []string{"-Xmx4M is overridden by -Xmx1g"},
+		},
+	}
+
+	var text bytes.Buffer
+	require.NoError(t, WriteExplanations(&text, explanations, ExplainFormatText))
+	assert.Equal(t, `primary: Heap sized as a percentage
+ARG                            ORIGIN
+-Dkey=value                    static jvmOpts
+-XX:InitialRAMPercentage=75.0  container support
+DROPPED                        ORIGIN          REASON
+-Xmx1g                         custom jvmOpts  replaced
+
+sidecar: Container support disabled: CONTAINER is not set
+ARG     ORIGIN
+-Xmx1g  static jvmOpts
+Warning: -Xmx4M is overridden by -Xmx1g
+`, text.String())
+
+	var jsonOutput bytes.Buffer
+	require.NoError(t, WriteExplanations(&jsonOutput, explanations, ExplainFormatJSON))
+	var decoded []JvmArgsExplanation
+	require.NoError(t, json.Unmarshal(jsonOutput.Bytes(), &decoded))
+	assert.Equal(t, explanations, decoded)
+
+	assert.EqualError(t, WriteExplanations(&bytes.Buffer{}, explanations, "xml"),
+		"invalid format 'xml', must be 'json' or 'text'")
+}
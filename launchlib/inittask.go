@@ -0,0 +1,55 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"os/exec"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// InitTaskCmd is the compiled command of an init task, along with how long it may run for.
+type InitTaskCmd struct {
+	Name    string
+	Cmd     *exec.Cmd
+	Timeout time.Duration
+}
+
+// Run runs the init task to completion, killing it if it has not exited within its timeout, and returns an error if it
+// did not exit 0.
+func (t InitTaskCmd) Run() error {
+	if err := t.Cmd.Start(); err != nil {
+		return errors.Wrapf(err, "failed to start initTask '%s'", t.Name)
+	}
+	exited := make(chan error, 1)
+	go func() {
+		exited <- t.Cmd.Wait()
+	}()
+
+	timer := time.NewTimer(t.Timeout)
+	defer timer.Stop()
+	select {
+	case err := <-exited:
+		if err != nil {
+			return errors.Wrapf(err, "initTask '%s' failed", t.Name)
+		}
+		return nil
+	case <-timer.C:
+		_ = t.Cmd.Process.Kill()
+		<-exited
+		return errors.Errorf("initTask '%s' did not finish within %s, so was killed", t.Name, t.Timeout)
+	}
+}
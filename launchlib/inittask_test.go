@@ -0,0 +1,41 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInitTaskCmd_Run(t *testing.T) {
+	assert.NoError(t, InitTaskCmd{Name: "succeeds", Cmd: exec.Command("true"), Timeout: time.Second}.Run())
+
+	err := InitTaskCmd{Name: "fails", Cmd: exec.Command("sh", "-c", "exit 3"), Timeout: time.Second}.Run()
+	assert.EqualError(t, err, "initTask 'fails' failed: exit status 3")
+
+	err = InitTaskCmd{Name: "missing", Cmd: exec.Command("/does/not/exist"), Timeout: time.Second}.Run()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to start initTask 'missing'")
+}
+
+func TestInitTaskCmd_RunKillsTaskAfterTimeout(t *testing.T) {
+	start := time.Now()
+	err := InitTaskCmd{Name: "hangs", Cmd: exec.Command("sleep", "10"), Timeout: 100 * time.Millisecond}.Run()
+	assert.EqualError(t, err, "initTask 'hangs' did not finish within 100ms, so was killed")
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
@@ -0,0 +1,203 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// garbageCollectorFlags are the -XX flags that each select a garbage collector, of which the JVM refuses to start
+	// with more than one selected.
+	garbageCollectorFlags = map[string]struct{}{
+		"-XX:UseSerialGC":        {},
+		"-XX:UseParallelGC":      {},
+		"-XX:UseConcMarkSweepGC": {},
+		"-XX:UseG1GC":            {},
+		"-XX:UseZGC":             {},
+		"-XX:UseShenandoahGC":    {},
+		"-XX:UseEpsilonGC":       {},
+	}
+	// accumulatingFlags are the -XX flags whose values accumulate rather than override each other.
+	accumulatingFlags = map[string]struct{}{
+		"-XX:CompileCommand": {},
+		"-XX:CompileOnly":    {},
+	}
+	// sizeOptions are the -X options whose value is a size given directly after the option.
+	sizeOptions = []string{"-Xms", "-Xmx", "-Xmn", "-Xss"}
+	// executionModeOptions are the -X options that select how bytecode is executed, of which the last one given wins.
+	executionModeOptions = map[string]struct{}{"-Xint": {}, "-Xcomp": {}, "-Xmixed": {}}
+
+	jvmSizePattern = regexp.MustCompile(`^([0-9]+)([kKmMgGtT]?)$`)
+)
+
+// jvmOpt is a HotSpot option parsed from an arg of the jvmOpts.
+type jvmOpt struct {
+	// key identifies what the option sets, so that of the options with the same key only the last one is effective.
+	key string
+	// value is what the option sets, "true" or "false" for a -XX:+ or -XX:- flag.
+	value string
+}
+
+// parseJvmOpt parses the given arg as a -XX:+Flag, -XX:-Flag, -XX:Flag=value, -Dproperty=value or -X option, and
+// returns whether it is one. Any other arg, e.g. -javaagent:agent.jar or --add-opens, is not parsed as the launcher
+// cannot tell which of them override each other.
+func parseJvmOpt(arg string) (jvmOpt, bool) {
+	switch {
+	case strings.HasPrefix(arg, "-XX:+") && len(arg) > len("-XX:+"):
+		return jvmOpt{key: "-XX:" + arg[len("-XX:+"):], value: "true"}, true
+	case strings.HasPrefix(arg, "-XX:-") && len(arg) > len("-XX:-"):
+		return jvmOpt{key: "-XX:" + arg[len("-XX:-"):], value: "false"}, true
+	case strings.HasPrefix(arg, "-XX:"):
+		name, value, ok := strings.Cut(arg, "=")
+		if !ok || name == "-XX:" {
+			return jvmOpt{}, false
+		}
+		if _, ok := accumulatingFlags[name]; ok {
+			return jvmOpt{key: arg, value: value}, true
+		}
+		return jvmOpt{key: name, value: value}, true
+	case strings.HasPrefix(arg, "-D") && len(arg) > len("-D"):
+		name, value, _ := strings.Cut(arg, "=")
+		if name == "-D" {
+			return jvmOpt{}, false
+		}
+		return jvmOpt{key: name, value: value}, true
+	case strings.HasPrefix(arg, "-X") && len(arg) > len("-X"):
+		for _, option := range sizeOptions {
+			if strings.HasPrefix(arg, option) {
+				return jvmOpt{key: option, value: arg[len(option):]}, true
+			}
+		}
+		if _, ok := executionModeOptions[arg]; ok {
+			return jvmOpt{key: "-Xint|-Xcomp|-Xmixed", value: arg}, true
+		}
+		if name, value, ok := strings.Cut(arg, ":"); ok && name == "-Xshare" {
+			return jvmOpt{key: name, value: value}, true
+		}
+		// Any other -X option, e.g. -Xlog:gc or -Xrs, is only a duplicate of an identical option.
+		return jvmOpt{key: arg}, true
+	}
+	return jvmOpt{}, false
+}
+
+// jvmOptsNormalization is the effective set of jvmOpts along with the problems found with them.
+type jvmOptsNormalization struct {
+	jvmOpts []string
+	// dropped maps the index of each given arg that is not effective to why it is not.
+	dropped map[int]string
+	// warnings describe every problem found, and fatal those that the JVM refuses to start with.
+	warnings []string
+	fatal    []string
+}
+
+// normalizeJvmOpts dedupes the given args to those that are effective, keeping only the last of the options that set
+// the same thing, as the JVM does. The order of the effective args is otherwise kept. Combinations the JVM refuses to
+// start with, e.g. several garbage collectors, are left in the args and reported as fatal.
+func normalizeJvmOpts(args []string) jvmOptsNormalization {
+	normalization := jvmOptsNormalization{dropped: make(map[int]string)}
+	opts := make([]jvmOpt, len(args))
+	parsed := make([]bool, len(args))
+	last := make(map[string]int)
+	for i, arg := range args {
+		opts[i], parsed[i] = parseJvmOpt(arg)
+		if parsed[i] {
+			last[opts[i].key] = i
+		}
+	}
+
+	var collectors []int
+	for i, arg := range args {
+		if !parsed[i] {
+			continue
+		}
+		if effective := last[opts[i].key]; effective != i {
+			if args[effective] == arg {
+				normalization.dropped[i] = "given again later"
+				normalization.addWarning(fmt.Sprintf("%s is given more than once", arg))
+			} else {
+				normalization.dropped[i] = fmt.Sprintf("overridden by %s", args[effective])
+				normalization.addWarning(fmt.Sprintf("%s is overridden by %s", arg, args[effective]))
+			}
+			continue
+		}
+		if _, ok := garbageCollectorFlags[opts[i].key]; ok && opts[i].value == "true" {
+			collectors = append(collectors, i)
+		}
+	}
+	if len(collectors) > 1 {
+		selected := make([]string, len(collectors))
+		for j, i := range collectors {
+			selected[j] = args[i]
+		}
+		normalization.addFatal(fmt.Sprintf("conflicting garbage collectors are selected: %s",
+			strings.Join(selected, ", ")))
+	}
+
+	effective := make(map[string]string)
+	for i, arg := range args {
+		if _, ok := normalization.dropped[i]; ok {
+			continue
+		}
+		normalization.jvmOpts = append(normalization.jvmOpts, arg)
+		if parsed[i] {
+			effective[opts[i].key] = opts[i].value
+		}
+	}
+	if effective["-XX:UseEpsilonGC"] == "true" && effective["-XX:UnlockExperimentalVMOptions"] != "true" {
+		normalization.addFatal("-XX:+UseEpsilonGC requires -XX:+UnlockExperimentalVMOptions")
+	}
+	initialHeapSize, initialOk := parseJvmSize(effective["-Xms"])
+	maxHeapSize, maxOk := parseJvmSize(effective["-Xmx"])
+	if initialOk && maxOk && initialHeapSize > maxHeapSize {
+		normalization.addFatal(fmt.Sprintf("-Xms%s is larger than -Xmx%s", effective["-Xms"], effective["-Xmx"]))
+	}
+	return normalization
+}
+
+func (n *jvmOptsNormalization) addWarning(warning string) {
+	n.warnings = append(n.warnings, warning)
+}
+
+func (n *jvmOptsNormalization) addFatal(problem string) {
+	n.warnings = append(n.warnings, problem)
+	n.fatal = append(n.fatal, problem)
+}
+
+// parseJvmSize parses a size as given to -Xms or -Xmx, e.g. 512m, in bytes.
+func parseJvmSize(size string) (uint64, bool) {
+	match := jvmSizePattern.FindStringSubmatch(size)
+	if match == nil {
+		return 0, false
+	}
+	value, err := strconv.ParseUint(match[1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	switch strings.ToLower(match[2]) {
+	case "k":
+		value <<= 10
+	case "m":
+		value <<= 20
+	case "g":
+		value <<= 30
+	case "t":
+		value <<= 40
+	}
+	return value, true
+}
@@ -0,0 +1,185 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseJvmOpt(t *testing.T) {
+	for _, tc := range []struct {
+		arg      string
+		expected jvmOpt
+		parsed   bool
+	}{
+		{arg: "-XX:+UseG1GC", expected: jvmOpt{key: "-XX:UseG1GC", value: "true"}, parsed: true},
+		{arg: "-XX:-UseG1GC", expected: jvmOpt{key: "-XX:UseG1GC", value: "false"}, parsed: true},
+		{arg: "-XX:MaxRAMPercentage=75.0", expected: jvmOpt{key: "-XX:MaxRAMPercentage", value: "75.0"}, parsed: true},
+		{
+			arg:      "-XX:CompileCommand=exclude,Foo.bar",
+			expected: jvmOpt{key: "-XX:CompileCommand=exclude,Foo.bar", value: "exclude,Foo.bar"},
+			parsed:   true,
+		},
+		{arg: "-Dkey=value", expected: jvmOpt{key: "-Dkey", value: "value"}, parsed: true},
+		{arg: "-Dkey", expected: jvmOpt{key: "-Dkey"}, parsed: true},
+		{arg: "-Xmx1g", expected: jvmOpt{key: "-Xmx", value: "1g"}, parsed: true},
+		{arg: "-Xss512k", expected: jvmOpt{key: "-Xss", value: "512k"}, parsed: true},
+		{arg: "-Xint", expected: jvmOpt{key: "-Xint|-Xcomp|-Xmixed", value: "-Xint"}, parsed: true},
+		{arg: "-Xshare:off", expected: jvmOpt{key: "-Xshare", value: "off"}, parsed: true},
+		{arg: "-Xlog:gc", expected: jvmOpt{key: "-Xlog:gc"}, parsed: true},
+		{arg: "-XX:Flag"},
+		{arg: "-XX:+"},
+		{arg: "-D"},
+		{arg: "-javaagent:agent.jar"},
+		{arg: "--add-opens"},
+	} {
+		t.Run(tc.arg, func(t *testing.T) {
+			opt, parsed := parseJvmOpt(tc.arg)
+			assert.Equal(t, tc.parsed, parsed)
+			assert.Equal(t, tc.expected, opt)
+		})
+	}
+}
+
+func TestNormalizeJvmOpts(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		args     []string
+		expected jvmOptsNormalization
+	}{
+		{
+			name: "keeps args without duplicates",
+			args: []string{"-Xmx1g", "-Dkey=value", "--add-opens", "java.base/java.lang=ALL-UNNAMED"},
+			expected: jvmOptsNormalization{
+				jvmOpts: []string{"-Xmx1g", "-Dkey=value", "--add-opens", "java.base/java.lang=ALL-UNNAMED"},
+				dropped: map[int]string{},
+			},
+		},
+		{
+			name: "keeps the last of options that set the same thing",
+			args: []string{"-Xmx4M", "-Dkey=a", "-XX:+UseG1GC", "-Xmx1g", "-Dkey=b", "-XX:+UseG1GC"},
+			expected: jvmOptsNormalization{
+				jvmOpts: []string{"-Xmx1g", "-Dkey=b", "-XX:+UseG1GC"},
+				dropped: map[int]string{0: "overridden by -Xmx1g", 1: "overridden by -Dkey=b", 2: "given again later"},
+				warnings: []string{
+					"-Xmx4M is overridden by -Xmx1g",
+					"-Dkey=a is overridden by -Dkey=b",
+					"-XX:+UseG1GC is given more than once",
+				},
+			},
+		},
+		{
+			name: "does not dedupe args it cannot parse",
+			args: []string{"--add-opens", "a", "--add-opens", "b", "-XX:CompileCommand=quiet", "-XX:CompileCommand=quiet"},
+			expected: jvmOptsNormalization{
+				jvmOpts:  []string{"--add-opens", "a", "--add-opens", "b", "-XX:CompileCommand=quiet"},
+				dropped:  map[int]string{4: "given again later"},
+				warnings: []string{"-XX:CompileCommand=quiet is given more than once"},
+			},
+		},
+		{
+			name: "keeps conflicting garbage collectors",
+			args: []string{"-XX:+UseG1GC", "-XX:+UseParallelGC", "-XX:-UseSerialGC"},
+			expected: jvmOptsNormalization{
+				jvmOpts:  []string{"-XX:+UseG1GC", "-XX:+UseParallelGC", "-XX:-UseSerialGC"},
+				dropped:  map[int]string{},
+				warnings: []string{"conflicting garbage collectors are selected: -XX:+UseG1GC, -XX:+UseParallelGC"},
+				fatal:    []string{"conflicting garbage collectors are selected: -XX:+UseG1GC, -XX:+UseParallelGC"},
+			},
+		},
+		{
+			name: "does not select a garbage collector that is disabled later",
+			args: []string{"-XX:+UseG1GC", "-XX:+UseParallelGC", "-XX:-UseG1GC"},
+			expected: jvmOptsNormalization{
+				jvmOpts:  []string{"-XX:+UseParallelGC", "-XX:-UseG1GC"},
+				dropped:  map[int]string{0: "overridden by -XX:-UseG1GC"},
+				warnings: []string{"-XX:+UseG1GC is overridden by -XX:-UseG1GC"},
+			},
+		},
+		{
+			name: "finds an initial heap size larger than the maximum",
+			args: []string{"-Xms2g", "-Xmx1024m"},
+			expected: jvmOptsNormalization{
+				jvmOpts:  []string{"-Xms2g", "-Xmx1024m"},
+				dropped:  map[int]string{},
+				warnings: []string{"-Xms2g is larger than -Xmx1024m"},
+				fatal:    []string{"-Xms2g is larger than -Xmx1024m"},
+			},
+		},
+		{
+			name: "finds an experimental garbage collector that is not unlocked",
+			args: []string{"-XX:+UseEpsilonGC", "-XX:+UnlockExperimentalVMOptions", "-XX:-UnlockExperimentalVMOptions"},
+			expected: jvmOptsNormalization{
+				jvmOpts: []string{"-XX:+UseEpsilonGC", "-XX:-UnlockExperimentalVMOptions"},
+				dropped: map[int]string{1: "overridden by -XX:-UnlockExperimentalVMOptions"},
+				warnings: []string{
+					"-XX:+UnlockExperimentalVMOptions is overridden by -XX:-UnlockExperimentalVMOptions",
+					"-XX:+UseEpsilonGC requires -XX:+UnlockExperimentalVMOptions",
+				},
+				fatal: []string{"-XX:+UseEpsilonGC requires -XX:+UnlockExperimentalVMOptions"},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, normalizeJvmOpts(tc.args))
+		})
+	}
+}
+
+func TestParseJvmSize(t *testing.T) {
+	for size, expected := range map[string]uint64{"1024": 1024, "512k": 512 << 10, "1G": 1 << 30, "2t": 2 << 40} {
+		parsed, ok := parseJvmSize(size)
+		assert.True(t, ok, size)
+		assert.Equal(t, expected, parsed, size)
+	}
+	for _, size := range []string{"", "1gb", "-1g", "1.5g"} {
+		_, ok := parseJvmSize(size)
+		assert.False(t, ok, size)
+	}
+}
+
+func TestCreateJvmOpts(t *testing.T) {
+	t.Setenv("CONTAINER", "")
+	staticConfig := StaticLauncherConfig{
+		TypedConfig: TypedConfig{Type: "java"},
+		JavaConfig:  JavaConfig{JvmOpts: []string{"-Xmx4M", "-XX:+UseG1GC", "-Dkey=value"}},
+	}
+	customConfig := CustomLauncherConfig{
+		JvmOpts:                 []string{"-Xmx1g", "-XX:+UseParallelGC"},
+		DisableContainerSupport: true,
+	}
+
+	var logs bytes.Buffer
+	jvmArgs := createJvmOpts(&staticConfig, &customConfig, &logs)
+	assert.Equal(t, []string{"-XX:+UseG1GC", "-Dkey=value", "-Xmx1g", "-XX:+UseParallelGC"}, jvmArgs.jvmOpts())
+	assert.Equal(t, []ExplainedArg{
+		{Arg: "-XX:+UseG1GC", Origin: "static jvmOpts"},
+		{Arg: "-Dkey=value", Origin: "static jvmOpts"},
+		{Arg: "-Xmx1g", Origin: "custom jvmOpts"},
+		{Arg: "-XX:+UseParallelGC", Origin: "custom jvmOpts"},
+	}, jvmArgs.effective)
+	assert.Equal(t, []ExplainedArg{
+		{Arg: "-Xmx4M", Origin: "static jvmOpts", Reason: "overridden by -Xmx1g"},
+	}, jvmArgs.dropped)
+	assert.Equal(t, []string{
+		"conflicting garbage collectors are selected: -XX:+UseG1GC, -XX:+UseParallelGC",
+	}, jvmArgs.fatal)
+	assert.Equal(t, "Container support disabled in launcher-custom.yml\n"+
+		"Warning: -Xmx4M is overridden by -Xmx1g\n"+
+		"Warning: conflicting garbage collectors are selected: -XX:+UseG1GC, -XX:+UseParallelGC\n", logs.String())
+}
@@ -15,13 +15,18 @@
 package launchlib
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"path"
+	"path/filepath"
 	"regexp"
 	"runtime"
+	"sort"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -33,21 +38,40 @@ const (
 	// ExecPathBlackListRegex matches characters disallowed in paths we allow to be passed to exec()
 	ExecPathBlackListRegex = `[^\w.\/_\-]`
 	BytesInMebibyte        = 1048576
+	// CgexecPath is the executable that commands are run through when cgroups are configured
+	CgexecPath = "/bin/cgexec"
+
+	defaultInitialRAMPercentageArg = "-XX:InitialRAMPercentage=75.0"
+	defaultMaxRAMPercentageArg     = "-XX:MaxRAMPercentage=75.0"
+	ramPercentageDroppedReason     = "dropped in a container in favour of the -XX:MaxRAMPercentage or " +
+		"-XX:InitialRAMPercentage given"
 )
 
 type ServiceCmds struct {
 	Primary      *exec.Cmd
 	SubProcesses map[string]*exec.Cmd
+	// InitTasks are run to completion in order before any other command is started.
+	InitTasks []InitTaskCmd
 }
 
 func CompileCmdsFromConfig(
+	staticConfig *PrimaryStaticLauncherConfig, customConfig *PrimaryCustomLauncherConfig, loggers ServiceLoggers) (
+	serviceCmds *ServiceCmds, err error) {
+	return CompileCmdsFromConfigInDir(getWorkingDir(), staticConfig, customConfig, loggers)
+}
+
+// CompileCmdsFromConfigInDir compiles the commands of the service as CompileCmdsFromConfig does, but for a service
+// whose root is the given working directory rather than the working directory of the launcher. The commands are not set
+// to run in the working directory.
+func CompileCmdsFromConfigInDir(workingDir string,
 	staticConfig *PrimaryStaticLauncherConfig, customConfig *PrimaryCustomLauncherConfig, loggers ServiceLoggers) (
 	serviceCmds *ServiceCmds, err error) {
 	serviceCmds = &ServiceCmds{
 		SubProcesses: make(map[string]*exec.Cmd),
 	}
 
-	serviceCmds.Primary, err = compileCmdFromConfig(&staticConfig.StaticLauncherConfig, &customConfig.CustomLauncherConfig, &customConfig.CgroupsV1, loggers.PrimaryLogger)
+	serviceCmds.Primary, err = compileCmdFromConfig(workingDir, &staticConfig.StaticLauncherConfig,
+		&customConfig.CustomLauncherConfig, &customConfig.CgroupsV1, loggers.PrimaryLogger)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to compile command for primary command")
 	}
@@ -57,15 +81,59 @@ func CompileCmdsFromConfig(
 			return nil, errors.Errorf("no custom launcher config exists for subProcess config '%s'", name)
 		}
 
-		serviceCmds.SubProcesses[name], err = compileCmdFromConfig(&subProcStatic, &subProcCustom, &customConfig.CgroupsV1, loggers.SubProcessLogger(name))
+		serviceCmds.SubProcesses[name], err = compileCmdFromConfig(workingDir, &subProcStatic, &subProcCustom,
+			&customConfig.CgroupsV1, loggers.SubProcessLogger(name))
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to compile command for subProcess %s", name)
 		}
 	}
+	for _, task := range staticConfig.InitTasks {
+		// Init tasks have no custom configuration, though they run in the cgroups of the service.
+		taskCustom := CustomLauncherConfig{TypedConfig: task.TypedConfig}
+		cmd, err := compileCmdFromConfig(workingDir, &task.StaticLauncherConfig, &taskCustom, &customConfig.CgroupsV1,
+			loggers.SubProcessLogger(task.Name))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to compile command for initTask %s", task.Name)
+		}
+		timeout := task.Timeout
+		if timeout == 0 {
+			timeout = DefaultInitTaskTimeout
+		}
+		serviceCmds.InitTasks = append(serviceCmds.InitTasks, InitTaskCmd{Name: task.Name, Cmd: cmd, Timeout: timeout})
+	}
 	return serviceCmds, nil
 }
 
-func compileCmdFromConfig(
+// CompileEntrypointCmdFromConfig compiles the command of the named entrypoint of the service, followed by the given
+// extra args.
+func CompileEntrypointCmdFromConfig(name string, extraArgs []string, staticConfig *PrimaryStaticLauncherConfig,
+	customConfig *PrimaryCustomLauncherConfig, createLogger CreateLogger) (*exec.Cmd, error) {
+	return CompileEntrypointCmdFromConfigInDir(getWorkingDir(), name, extraArgs, staticConfig, customConfig,
+		createLogger)
+}
+
+// CompileEntrypointCmdFromConfigInDir compiles the command of the named entrypoint of the service as
+// CompileEntrypointCmdFromConfig does, but for a service whose root is the given working directory. The entrypoint is
+// compiled as the primary command is, with the custom configuration of the primary process, though none of the
+// subProcesses or initTasks of the service are compiled with it.
+func CompileEntrypointCmdFromConfigInDir(workingDir string, name string, extraArgs []string,
+	staticConfig *PrimaryStaticLauncherConfig, customConfig *PrimaryCustomLauncherConfig, createLogger CreateLogger) (
+	*exec.Cmd, error) {
+	entrypointStatic, err := staticConfig.EntrypointStaticConfig(name)
+	if err != nil {
+		return nil, err
+	}
+	entrypointStatic.Args = append(entrypointStatic.Args, extraArgs...)
+
+	cmd, err := compileCmdFromConfig(workingDir, &entrypointStatic, &customConfig.CustomLauncherConfig,
+		&customConfig.CgroupsV1, createLogger)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to compile command for entrypoint %s", name)
+	}
+	return cmd, nil
+}
+
+func compileCmdFromConfig(workingDir string,
 	staticConfig *StaticLauncherConfig, customConfig *CustomLauncherConfig, cgroupsV1 *map[string]string, createLogger CreateLogger) (cmd *exec.Cmd, err error) {
 	logger, err := createLogger()
 	if err != nil {
@@ -79,7 +147,6 @@ func compileCmdFromConfig(
 	_, _ = fmt.Fprintf(logger, "Launching with static configuration %v and custom configuration %v\n",
 		*staticConfig, *customConfig)
 
-	workingDir := getWorkingDir()
 	_, _ = fmt.Fprintf(logger, "Working directory: %s\n", workingDir)
 
 	var args []string
@@ -97,22 +164,21 @@ func compileCmdFromConfig(
 			staticConfig.JavaConfig.Classpath))
 		_, _ = fmt.Fprintf(logger, "Classpath: %s\n", classpath)
 
-		var combinedJvmOpts []string
-		combinedJvmOpts = append(combinedJvmOpts, staticConfig.JavaConfig.JvmOpts...)
-		combinedJvmOpts = append(combinedJvmOpts, customConfig.JvmOpts...)
-
-		jvmOpts := createJvmOpts(combinedJvmOpts, customConfig, logger)
+		jvmArgs := createJvmOpts(staticConfig, customConfig, logger)
+		if staticConfig.JavaConfig.StrictJvmOpts && len(jvmArgs.fatal) > 0 {
+			return nil, errors.Errorf("jvmOpts would fail to start the JVM: %s", strings.Join(jvmArgs.fatal, "; "))
+		}
 
-		executable, executableErr = verifyPathIsSafeForExec(path.Join(javaHome, "/bin/java"))
+		executable, executableErr = verifyPathIsSafeForExec(path.Join(javaHome, "/bin/java"), workingDir)
 		if executableErr != nil {
 			return nil, executableErr
 		}
 		args = append(args, executable) // 0th argument is the command itself
-		args = append(args, jvmOpts...)
+		args = append(args, jvmArgs.jvmOpts()...)
 		args = append(args, "-classpath", classpath)
 		args = append(args, staticConfig.JavaConfig.MainClass)
 	} else if staticConfig.Type == "executable" {
-		executable, executableErr = verifyPathIsSafeForExec(staticConfig.Executable)
+		executable, executableErr = verifyPathIsSafeForExec(staticConfig.Executable, workingDir)
 		if executableErr != nil {
 			return nil, executableErr
 		}
@@ -125,11 +191,17 @@ func compileCmdFromConfig(
 	args = append(args, staticConfig.Args...)
 	if len(*cgroupsV1) > 0 {
 		var cgexecArgs []string
-		executable = "/bin/cgexec"
+		executable = CgexecPath
 
 		cgexecArgs = append(cgexecArgs, executable)
-		for controller, cgroup := range *cgroupsV1 {
-			cgexecArgs = append(cgexecArgs, "-g", fmt.Sprintf("%s:%s", controller, cgroup))
+		// Controllers are sorted so that the same configuration always compiles to the same command.
+		controllers := make([]string, 0, len(*cgroupsV1))
+		for controller := range *cgroupsV1 {
+			controllers = append(controllers, controller)
+		}
+		sort.Strings(controllers)
+		for _, controller := range controllers {
+			cgexecArgs = append(cgexecArgs, "-g", fmt.Sprintf("%s:%s", controller, (*cgroupsV1)[controller]))
 		}
 		cgexecArgs = append(cgexecArgs, args...)
 		args = cgexecArgs
@@ -137,12 +209,44 @@ func compileCmdFromConfig(
 
 	_, _ = fmt.Fprintf(logger, "Argument list to executable binary: %v\n\n", args)
 
-	env := replaceEnvironmentVariables(merge(staticConfig.Env, customConfig.Env))
+	return createCmd(executable, args, ConfiguredEnv(staticConfig, customConfig, workingDir))
+}
+
+// ConfiguredEnv returns the environment variables configured for a process by the given static and custom
+// configurations of a service whose root is the given working directory, which the process is run with in addition
+// to the environment of the launcher.
+func ConfiguredEnv(staticConfig *StaticLauncherConfig, customConfig *CustomLauncherConfig,
+	workingDir string) map[string]string {
+	return replaceEnvironmentVariables(merge(staticConfig.Env, customConfig.Env), workingDir)
+}
 
-	return createCmd(executable, args, env)
+// CommandHash returns a hex-encoded SHA-256 hash of the given arguments and configured environment variables of a
+// compiled command, which changes whenever the configuration of the command changes in a way that affects how it is
+// run.
+func CommandHash(args []string, env map[string]string) string {
+	hash := sha256.New()
+	for _, arg := range args {
+		// Arguments are length-prefixed so that different lists of arguments cannot hash the same.
+		_, _ = fmt.Fprintf(hash, "%d:%s", len(arg), arg)
+	}
+	keys := make([]string, 0, len(env))
+	for key := range env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		_, _ = fmt.Fprintf(hash, "%d:%s%d:%s", len(key), key, len(env[key]), env[key])
+	}
+	return hex.EncodeToString(hash.Sum(nil))
 }
 
 func MkDirs(dirs []string, stdout io.Writer) error {
+	return MkDirsInDir("", dirs, stdout)
+}
+
+// MkDirsInDir creates the given directories relative to the given working directory, or to the working directory of
+// the launcher if it is empty.
+func MkDirsInDir(workingDir string, dirs []string, stdout io.Writer) error {
 	isDirMatcher := regexp.MustCompile(`^[A-Za-z0-9]+(/[A-Za-z0-9]+)*$`).MatchString
 	for _, dir := range dirs {
 		if !isDirMatcher(dir) {
@@ -150,20 +254,26 @@ func MkDirs(dirs []string, stdout io.Writer) error {
 		}
 
 		_, _ = fmt.Fprintf(stdout, "Creating directory: %s\n", dir)
-		if err := os.MkdirAll(dir, 0700); err != nil {
+		if err := os.MkdirAll(filepath.Join(workingDir, dir), 0700); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-// Returns true iff the given path is safe to be passed to exec(): must not contain funky characters and be a valid file
-func verifyPathIsSafeForExec(execPath string) (string, error) {
+// Returns true iff the given path is safe to be passed to exec(): must not contain funky characters and be a valid
+// file, relative to the given working directory if it is not absolute
+func verifyPathIsSafeForExec(execPath string, workingDir string) (string, error) {
 	if unsafe, err := regexp.MatchString(ExecPathBlackListRegex, execPath); err != nil {
 		return "", err
 	} else if unsafe {
 		return "", fmt.Errorf("Unsafe execution path: %q ", execPath)
-	} else if _, statErr := os.Stat(execPath); statErr != nil {
+	}
+	statPath := execPath
+	if !filepath.IsAbs(statPath) {
+		statPath = filepath.Join(workingDir, statPath)
+	}
+	if _, statErr := os.Stat(statPath); statErr != nil {
 		return "", statErr
 	}
 
@@ -240,8 +350,8 @@ func createCmd(executable string, args []string, customEnv map[string]string) (*
 
 // Performs replacement of all replaceable values in env, returning a new
 // map, with the same keys as env, but possibly changed values.
-func replaceEnvironmentVariables(env map[string]string) map[string]string {
-	replacer := createReplacer()
+func replaceEnvironmentVariables(env map[string]string, workingDir string) map[string]string {
+	replacer := createReplacer(workingDir)
 
 	returnMap := make(map[string]string)
 	for key, value := range env {
@@ -268,9 +378,9 @@ func merge(origMap, overrideMap map[string]string) map[string]string {
 	return returnMap
 }
 
-func createReplacer() *strings.Replacer {
+func createReplacer(workingDir string) *strings.Replacer {
 	return strings.NewReplacer(
-		delim("CWD"), getWorkingDir(),
+		delim("CWD"), workingDir,
 	)
 }
 
@@ -278,41 +388,149 @@ func delim(str string) string {
 	return fmt.Sprintf("%s%s%s", TemplateDelimsOpen, str, TemplateDelimsClose)
 }
 
-func createJvmOpts(combinedJvmOpts []string, customConfig *CustomLauncherConfig, logger io.WriteCloser) []string {
-	if isEnvVarSet("CONTAINER") && !customConfig.DisableContainerSupport && !hasMaxRAMOverride(combinedJvmOpts) {
+// jvmArgs are the jvmOpts of a java process as built by createJvmOpts: the effective args along with where each comes
+// from, the configured args that are dropped along with why, how the heap is sized and the problems found with them.
+type jvmArgs struct {
+	effective []ExplainedArg
+	dropped   []ExplainedArg
+	sizing    HeapSizing
+	warnings  []string
+	// fatal are the problems the JVM refuses to start with, which fail the launch in strict mode.
+	fatal []string
+}
+
+// jvmOpts returns the effective jvmOpts.
+func (a jvmArgs) jvmOpts() []string {
+	jvmOpts := make([]string, len(a.effective))
+	for i, arg := range a.effective {
+		jvmOpts[i] = arg.Arg
+	}
+	return jvmOpts
+}
+
+// createJvmOpts builds the jvmOpts of a java process with the given static and custom configurations: the static and
+// then the custom jvmOpts, with their heap size args replaced if the heap is sized for a container, deduped to those
+// that are effective. Each arg records where it comes from and, if it is dropped, why. Logs how the heap is sized if
+// running in a container, and warns of any problems found with the jvmOpts.
+func createJvmOpts(staticConfig *StaticLauncherConfig, customConfig *CustomLauncherConfig, logger io.Writer) jvmArgs {
+	var configured []ExplainedArg
+	for _, arg := range staticConfig.JavaConfig.JvmOpts {
+		configured = append(configured, ExplainedArg{Arg: arg, Origin: argOriginStaticJvmOpts})
+	}
+	for _, arg := range customConfig.JvmOpts {
+		configured = append(configured, ExplainedArg{Arg: arg, Origin: argOriginCustomJvmOpts})
+	}
+	combinedJvmOpts := make([]string, len(configured))
+	for i, arg := range configured {
+		combinedJvmOpts[i] = arg.Arg
+	}
+
+	sizedJvmOpts, sizing := sizeHeap(combinedJvmOpts, customConfig)
+	result := jvmArgs{sizing: sizing}
+	if sizing.ContainerSupport {
 		_, _ = fmt.Fprintln(logger, "Container support enabled")
-		jvmOptsWithUpdatedHeapSizeArgs, err := filterHeapSizeArgsV2(combinedJvmOpts)
-		if err != nil {
-			// When we fail to get the memory limit from the cgroups files, fallback to using percentage-based heap
-			// sizing. While this method doesn't take into account the per-processor memory offset, it is supported
-			// by all platforms using Java.
-			// Also, when the memory limit is unusually high (defined to be over 1TB), we revert to the
-			// percentage-based heap sizing. This is to handle the edge case where the cgroups memory limit is set
-			// to be an arbitrary large value.
-			combinedJvmOpts = filterHeapSizeArgs(combinedJvmOpts)
-		} else {
-			combinedJvmOpts = jvmOptsWithUpdatedHeapSizeArgs
+	} else if isEnvVarSet("CONTAINER") {
+		_, _ = fmt.Fprintln(logger, sizing.Reason)
+	}
+
+	// The heap size args are filtered out of the configured args in order, and those added are appended after them.
+	kept := sizedJvmOpts[:len(sizedJvmOpts)-len(sizing.Added)]
+	var sized []ExplainedArg
+	for _, arg := range configured {
+		if len(kept) > 0 && kept[0] == arg.Arg {
+			sized = append(sized, arg)
+			kept = kept[1:]
+			continue
 		}
-		return combinedJvmOpts
+		arg.Reason = sizing.DroppedReason
+		result.dropped = append(result.dropped, arg)
+	}
+	for _, arg := range sizing.Added {
+		sized = append(sized, ExplainedArg{Arg: arg, Origin: argOriginContainerSupport})
 	}
 
-	if isEnvVarSet("CONTAINER") {
-		if customConfig.DisableContainerSupport {
-			_, _ = fmt.Fprintln(logger, "Container support disabled in launcher-custom.yml")
-		} else if hasMaxRAMOverride(combinedJvmOpts) {
-			_, _ = fmt.Fprintln(logger, "Container support disabled: -XX:MaxRAM override present")
+	normalization := normalizeJvmOpts(sizedJvmOpts)
+	for i, arg := range sized {
+		if reason, ok := normalization.dropped[i]; ok {
+			arg.Reason = reason
+			result.dropped = append(result.dropped, arg)
+			continue
 		}
+		result.effective = append(result.effective, arg)
+	}
+	result.warnings = normalization.warnings
+	result.fatal = normalization.fatal
+	for _, warning := range result.warnings {
+		_, _ = fmt.Fprintf(logger, "Warning: %s\n", warning)
+	}
+	return result
+}
+
+// HeapSizing describes how the heap size args of a java process are chosen from its combined jvmOpts.
+type HeapSizing struct {
+	// ContainerSupport is whether the heap is sized for running in a container, in which case the -Xmx and -Xms args
+	// given in the jvmOpts are dropped.
+	ContainerSupport bool   `json:"containerSupport"`
+	Reason           string `json:"reason"`
+	// MemoryLimitBytes and Processors are those the heap size is computed from, if it is computed.
+	MemoryLimitBytes uint64 `json:"memoryLimitBytes,omitempty"`
+	Processors       int    `json:"processors,omitempty"`
+	HeapSizeBytes    uint64 `json:"heapSizeBytes,omitempty"`
+	// Dropped are the args given in the jvmOpts that are dropped, for DroppedReason, and Added those added in their
+	// place.
+	Dropped       []string `json:"dropped,omitempty"`
+	DroppedReason string   `json:"droppedReason,omitempty"`
+	Added         []string `json:"added,omitempty"`
+}
+
+// ResolveHeapSizing returns how the heap of a process with the given static and custom configurations is sized, or
+// nil if it is not a java process.
+func ResolveHeapSizing(staticConfig *StaticLauncherConfig, customConfig *CustomLauncherConfig) *HeapSizing {
+	if staticConfig.Type != "java" {
+		return nil
+	}
+	sizing := createJvmOpts(staticConfig, customConfig, ioutil.Discard).sizing
+	return &sizing
+}
+
+// sizeHeap returns the given combined jvmOpts with their heap size args replaced if running in a container with
+// container support enabled, along with how the heap was sized.
+func sizeHeap(combinedJvmOpts []string, customConfig *CustomLauncherConfig) ([]string, HeapSizing) {
+	if !isEnvVarSet("CONTAINER") {
+		return combinedJvmOpts, HeapSizing{Reason: "Container support disabled: CONTAINER is not set"}
+	}
+	if customConfig.DisableContainerSupport {
+		return combinedJvmOpts, HeapSizing{Reason: "Container support disabled in launcher-custom.yml"}
+	}
+	if hasMaxRAMOverride(combinedJvmOpts) {
+		return combinedJvmOpts, HeapSizing{Reason: "Container support disabled: -XX:MaxRAM override present"}
 	}
 
-	return combinedJvmOpts
+	jvmOptsWithUpdatedHeapSizeArgs, sizing, err := filterHeapSizeArgsV2(combinedJvmOpts)
+	if err != nil {
+		// When we fail to get the memory limit from the cgroups files, fallback to using percentage-based heap
+		// sizing. While this method doesn't take into account the per-processor memory offset, it is supported
+		// by all platforms using Java.
+		// Also, when the memory limit is unusually high (defined to be over 1TB), we revert to the
+		// percentage-based heap sizing. This is to handle the edge case where the cgroups memory limit is set
+		// to be an arbitrary large value.
+		jvmOptsWithUpdatedHeapSizeArgs, sizing = filterHeapSizeArgs(combinedJvmOpts)
+		sizing.Reason = fmt.Sprintf("Heap sized as a percentage of the container memory limit: %v", err)
+	}
+	return jvmOptsWithUpdatedHeapSizeArgs, sizing
 }
 
-func filterHeapSizeArgs(args []string) []string {
+// filterHeapSizeArgs returns the given args without their heap size args, with the default RAM percentage args added
+// unless a RAM percentage is given, along with which args were dropped and added.
+func filterHeapSizeArgs(args []string) ([]string, HeapSizing) {
 	var filtered []string
 	var hasMaxRAMPercentage, hasInitialRAMPercentage bool
+	sizing := HeapSizing{ContainerSupport: true}
 	for _, arg := range args {
 		if !isHeapSizeArg(arg) {
 			filtered = append(filtered, arg)
+		} else {
+			sizing.Dropped = append(sizing.Dropped, arg)
 		}
 
 		if isMaxRAMPercentage(arg) {
@@ -323,18 +541,27 @@ func filterHeapSizeArgs(args []string) []string {
 	}
 
 	if !hasInitialRAMPercentage && !hasMaxRAMPercentage {
-		filtered = append(filtered, "-XX:InitialRAMPercentage=75.0")
-		filtered = append(filtered, "-XX:MaxRAMPercentage=75.0")
+		sizing.DroppedReason = "replaced in a container by -XX:InitialRAMPercentage and -XX:MaxRAMPercentage, " +
+			"since the cgroup memory limit could not be used"
+		sizing.Added = []string{defaultInitialRAMPercentageArg, defaultMaxRAMPercentageArg}
+		filtered = append(filtered, sizing.Added...)
+	} else {
+		sizing.DroppedReason = ramPercentageDroppedReason
 	}
-	return filtered
+	return filtered, sizing
 }
 
-func filterHeapSizeArgsV2(args []string) ([]string, error) {
+// filterHeapSizeArgsV2 returns the given args without their heap size args, with -Xms and -Xmx args computed from the
+// cgroup memory limit added unless a RAM percentage is given, along with how the heap was sized.
+func filterHeapSizeArgsV2(args []string) ([]string, HeapSizing, error) {
 	var filtered []string
 	var hasMaxRAMPercentage, hasInitialRAMPercentage bool
+	sizing := HeapSizing{ContainerSupport: true}
 	for _, arg := range args {
 		if !isHeapSizeArg(arg) {
 			filtered = append(filtered, arg)
+		} else {
+			sizing.Dropped = append(sizing.Dropped, arg)
 		}
 
 		if isMaxRAMPercentage(arg) {
@@ -347,16 +574,25 @@ func filterHeapSizeArgsV2(args []string) ([]string, error) {
 	if !hasInitialRAMPercentage && !hasMaxRAMPercentage {
 		cgroupMemoryLimitInBytes, err := DefaultMemoryLimit.MemoryLimitInBytes()
 		if err != nil {
-			return filtered, errors.Wrap(err, "failed to get cgroup memory limit")
+			return filtered, HeapSizing{}, errors.Wrap(err, "failed to get cgroup memory limit")
 		}
 		jvmHeapSizeInBytes, err := ComputeJVMHeapSizeInBytes(runtime.NumCPU(), cgroupMemoryLimitInBytes)
 		if err != nil {
-			return filtered, errors.New("cgroups memory limit is unusually high. Not setting JVM heap size options")
+			return filtered, HeapSizing{},
+				errors.New("cgroups memory limit is unusually high. Not setting JVM heap size options")
 		}
-		filtered = append(filtered, fmt.Sprintf("-Xms%d", jvmHeapSizeInBytes))
-		filtered = append(filtered, fmt.Sprintf("-Xmx%d", jvmHeapSizeInBytes))
+		sizing.Reason = "Heap sized from the cgroup memory limit and the number of processors"
+		sizing.DroppedReason = "replaced in a container by -Xms and -Xmx computed from the cgroup memory limit"
+		sizing.MemoryLimitBytes = cgroupMemoryLimitInBytes
+		sizing.Processors = runtime.NumCPU()
+		sizing.HeapSizeBytes = jvmHeapSizeInBytes
+		sizing.Added = []string{fmt.Sprintf("-Xms%d", jvmHeapSizeInBytes), fmt.Sprintf("-Xmx%d", jvmHeapSizeInBytes)}
+		filtered = append(filtered, sizing.Added...)
+	} else {
+		sizing.Reason = "Heap sized by the -XX:MaxRAMPercentage or -XX:InitialRAMPercentage given"
+		sizing.DroppedReason = ramPercentageDroppedReason
 	}
-	return filtered, nil
+	return filtered, sizing, nil
 }
 
 func hasMaxRAMOverride(args []string) bool {
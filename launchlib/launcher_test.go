@@ -67,7 +67,7 @@ func TestSetCustomEnvironment(t *testing.T) {
 		"SOME_VAR":  "CUSTOM_VAR",
 	}
 
-	env := replaceEnvironmentVariables(merge(originalEnv, customEnv))
+	env := replaceEnvironmentVariables(merge(originalEnv, customEnv), getWorkingDir())
 	cwd := getWorkingDir()
 
 	if got, ok := env["SOME_PATH"]; ok {
@@ -107,7 +107,7 @@ func TestUnknownVariablesAreNotExpanded(t *testing.T) {
 		"SOME_VAR": "{{FOO}}",
 	}
 
-	env := replaceEnvironmentVariables(merge(originalEnv, customEnv))
+	env := replaceEnvironmentVariables(merge(originalEnv, customEnv), getWorkingDir())
 	if got, ok := env["SOME_VAR"]; ok {
 		assert.Equal(t, "{{FOO}}", got, "SOME_VAR environment variable incorrect")
 	} else {
@@ -121,7 +121,7 @@ func TestKeysAreNotExpanded(t *testing.T) {
 		"{{CWD}}": "Value",
 	}
 
-	env := replaceEnvironmentVariables(merge(originalEnv, customEnv))
+	env := replaceEnvironmentVariables(merge(originalEnv, customEnv), getWorkingDir())
 	if got, ok := env["{{CWD}}"]; ok {
 		assert.Equal(t, "Value", got, "%%CWD%% environment variable incorrect")
 	} else {
@@ -148,3 +148,47 @@ func TestMkdirChecksDirectorySyntax(t *testing.T) {
 		assert.EqualError(t, err, "Cannot create directory with non [A-Za-z0-9] characters: "+dir)
 	}
 }
+
+func TestCommandHash(t *testing.T) {
+	args := []string{"java", "-Xmx1g", "Main"}
+	env := map[string]string{"FOO": "foo", "BAR": "bar"}
+	hash := CommandHash(args, env)
+
+	assert.Equal(t, hash, CommandHash([]string{"java", "-Xmx1g", "Main"}, map[string]string{"BAR": "bar", "FOO": "foo"}))
+	assert.NotEqual(t, hash, CommandHash([]string{"java", "-Xmx2g", "Main"}, env))
+	assert.NotEqual(t, hash, CommandHash(args, map[string]string{"FOO": "foo", "BAR": "baz"}))
+	assert.NotEqual(t, hash, CommandHash(args, nil))
+	assert.NotEqual(t, CommandHash([]string{"ab", "c"}, nil), CommandHash([]string{"a", "bc"}, nil))
+}
+
+func TestFilterHeapSizeArgs(t *testing.T) {
+	filtered, sizing := filterHeapSizeArgs([]string{"-Xmx1g", "-Dkey=value", "-Xms1g"})
+	assert.Equal(t, []string{"-Dkey=value", "-XX:InitialRAMPercentage=75.0", "-XX:MaxRAMPercentage=75.0"}, filtered)
+	assert.Equal(t, HeapSizing{
+		ContainerSupport: true,
+		Dropped:          []string{"-Xmx1g", "-Xms1g"},
+		DroppedReason: "replaced in a container by -XX:InitialRAMPercentage and -XX:MaxRAMPercentage, since the " +
+			"cgroup memory limit could not be used",
+		Added: []string{"-XX:InitialRAMPercentage=75.0", "-XX:MaxRAMPercentage=75.0"},
+	}, sizing)
+
+	filtered, sizing = filterHeapSizeArgs([]string{"-Xmx1g", "-XX:MaxRAMPercentage=50.0"})
+	assert.Equal(t, []string{"-XX:MaxRAMPercentage=50.0"}, filtered)
+	assert.Equal(t, HeapSizing{
+		ContainerSupport: true,
+		Dropped:          []string{"-Xmx1g"},
+		DroppedReason:    ramPercentageDroppedReason,
+	}, sizing)
+}
+
+func TestFilterHeapSizeArgsV2_KeepsRAMPercentage(t *testing.T) {
+	filtered, sizing, err := filterHeapSizeArgsV2([]string{"-Xms1g", "-XX:InitialRAMPercentage=50.0", "-Dkey=value"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"-XX:InitialRAMPercentage=50.0", "-Dkey=value"}, filtered)
+	assert.Equal(t, HeapSizing{
+		ContainerSupport: true,
+		Reason:           "Heap sized by the -XX:MaxRAMPercentage or -XX:InitialRAMPercentage given",
+		Dropped:          []string{"-Xms1g"},
+		DroppedReason:    ramPercentageDroppedReason,
+	}, sizing)
+}
@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
@@ -31,6 +32,24 @@ const (
 type ProcessMonitor struct {
 	PrimaryPID     int
 	SubProcessPIDs []int
+	// Names are the names of the processes by pid, as recorded in the event log.
+	Names map[int]string
+	// EventLog is nil if the lifecycle events of the service are not recorded.
+	EventLog *EventLog
+	// PreStop are the preStop actions of the processes by name, which are run before the processes are first sent a
+	// stop signal.
+	PreStop     map[string]*PreStopConfig
+	preStopOnce sync.Once
+	// Ready is how the primary process is confirmed to have started before systemd is notified that the service is
+	// ready, or nil if systemd is not notified.
+	Ready *ReadyCheck
+}
+
+// ReadyCheck is how the primary process is confirmed to have started: it must keep running for ConfirmationPeriod
+// and, if Readiness is set, pass its readiness check within its timeout.
+type ReadyCheck struct {
+	ConfirmationPeriod time.Duration
+	Readiness          *ReadinessConfig
 }
 
 func (m *ProcessMonitor) Run() error {
@@ -39,6 +58,10 @@ func (m *ProcessMonitor) Run() error {
 	}
 
 	m.ForwardSignals()
+	if err := m.NotifyWatchdog(); err != nil {
+		fmt.Println("error notifying systemd watchdog", err)
+	}
+	m.NotifyReady()
 	return m.TermProcessGroupOnDeath()
 }
 
@@ -50,6 +73,13 @@ func (m *ProcessMonitor) ForwardSignals() {
 		for {
 			select {
 			case sign := <-signals:
+				if sign == syscall.SIGTERM || sign == syscall.SIGINT {
+					if _, err := SdNotify(SdNotifyStopping); err != nil {
+						fmt.Println("error notifying systemd of stopping", err)
+					}
+					m.runPreStopActions()
+					m.recordSignalSent(sign, append([]int{m.PrimaryPID}, m.SubProcessPIDs...))
+				}
 				// Errors are already printed and there is no where else relevant to return them to.
 				_ = SignalPid(m.PrimaryPID, sign)
 				_ = m.SignalSubProcesses(sign)
@@ -58,6 +88,75 @@ func (m *ProcessMonitor) ForwardSignals() {
 	}()
 }
 
+// NotifyWatchdog notifies systemd that the service is alive for as long as the primary process is alive, if the
+// systemd watchdog is enabled for the primary process.
+func (m *ProcessMonitor) NotifyWatchdog() error {
+	interval, err := SdWatchdogInterval(m.PrimaryPID)
+	if err != nil || interval == 0 {
+		return err
+	}
+
+	go func() {
+		tick := time.NewTicker(interval)
+		defer tick.Stop()
+		for range tick.C {
+			if !IsPidAlive(m.PrimaryPID) {
+				return
+			}
+			if _, err := SdNotify(SdNotifyWatchdog); err != nil {
+				fmt.Println("error notifying systemd watchdog", err)
+			}
+		}
+	}()
+	return nil
+}
+
+// NotifyReady notifies systemd that the service is ready once the primary process has passed its ReadyCheck, if set.
+// systemd is not notified if the primary process dies or does not become ready in time, so that systemd fails the start
+// of the service once its own start timeout passes.
+func (m *ProcessMonitor) NotifyReady() {
+	if m.Ready == nil {
+		return
+	}
+
+	go func() {
+		if err := m.waitUntilReady(); err != nil {
+			fmt.Println("not notifying systemd that the service is ready:", err)
+			return
+		}
+		if _, err := SdNotify(SdNotifyReady); err != nil {
+			fmt.Println("error notifying systemd that the service is ready", err)
+		}
+	}()
+}
+
+// waitUntilReady waits for the primary process to pass its ReadyCheck.
+func (m *ProcessMonitor) waitUntilReady() error {
+	time.Sleep(m.Ready.ConfirmationPeriod)
+	if !IsPidAlive(m.PrimaryPID) {
+		return errors.Errorf("primary process exited within %s of starting", m.Ready.ConfirmationPeriod)
+	}
+	readiness := m.Ready.Readiness
+	if readiness == nil {
+		return nil
+	}
+
+	deadline := time.Now().Add(readiness.Timeout - m.Ready.ConfirmationPeriod)
+	tick := time.NewTicker(ReadinessPollInterval)
+	defer tick.Stop()
+	for !IsReady(readiness) {
+		if !IsPidAlive(m.PrimaryPID) {
+			return errors.New("primary process exited before it became ready")
+		}
+		if time.Now().After(deadline) {
+			return errors.Errorf("primary process did not become ready within %s waiting for %s", readiness.Timeout,
+				DescribeReadiness(readiness))
+		}
+		<-tick.C
+	}
+	return nil
+}
+
 func (m *ProcessMonitor) TermProcessGroupOnDeath() error {
 	tick := time.NewTicker(CheckPeriod)
 	alive := true
@@ -72,13 +171,56 @@ func (m *ProcessMonitor) TermProcessGroupOnDeath() error {
 		}
 	}
 
+	m.recordEvent(Event{
+		Type:    EventExitObserved,
+		Process: m.Names[m.PrimaryPID],
+		Pid:     m.PrimaryPID,
+		Message: "primary process is no longer running",
+	})
+	m.runPreStopActions()
 	return m.KillSubProcesses()
 }
 
 func (m *ProcessMonitor) KillSubProcesses() error {
+	m.recordSignalSent(syscall.SIGTERM, m.SubProcessPIDs)
 	return m.SignalSubProcesses(syscall.SIGTERM)
 }
 
+// runPreStopActions runs the preStop actions of the processes that are alive, unless they have already been run.
+func (m *ProcessMonitor) runPreStopActions() {
+	m.preStopOnce.Do(func() {
+		actions := make(map[string]*PreStopConfig)
+		for pid, name := range m.Names {
+			if action, ok := m.PreStop[name]; ok && IsPidAlive(pid) {
+				actions[name] = action
+			}
+		}
+		RunPreStopActions(actions, "", os.Stdout)
+	})
+}
+
+// recordSignalSent records in the event log that the given signal is sent to those of the given processes that are
+// alive.
+func (m *ProcessMonitor) recordSignalSent(sign os.Signal, pids []int) {
+	sig, ok := sign.(syscall.Signal)
+	if !ok {
+		return
+	}
+	for _, pid := range pids {
+		if IsPidAlive(pid) {
+			m.recordEvent(Event{Type: EventSignalSent, Process: m.Names[pid], Pid: pid, Signal: int(sig)})
+		}
+	}
+}
+
+func (m *ProcessMonitor) recordEvent(event Event) {
+	if m.EventLog == nil {
+		return
+	}
+	// Failing to record an event is not written to the output of the service, which the monitor shares.
+	_ = m.EventLog.Append(event)
+}
+
 func (m *ProcessMonitor) SignalSubProcesses(sign os.Signal) error {
 	// Service process has died, terminating sub-processes
 	var errPids []int
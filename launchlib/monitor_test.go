@@ -0,0 +1,93 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessMonitor_NotifyReady(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	require.NoError(t, err)
+	defer func() {
+		_ = conn.Close()
+	}()
+	t.Setenv(NotifySocketEnvVar, socket)
+
+	readyFile := filepath.Join(t.TempDir(), "ready")
+	monitor := &ProcessMonitor{
+		PrimaryPID: os.Getpid(),
+		Ready: &ReadyCheck{
+			ConfirmationPeriod: 100 * time.Millisecond,
+			Readiness:          &ReadinessConfig{File: readyFile, Timeout: 10 * time.Second},
+		},
+	}
+	monitor.NotifyReady()
+
+	// systemd is not notified before the readiness check passes.
+	buf := make([]byte, 1024)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	_, err = conn.Read(buf)
+	require.Error(t, err)
+
+	require.NoError(t, os.WriteFile(readyFile, nil, 0644))
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "READY=1", string(buf[:n]))
+}
+
+func TestProcessMonitor_WaitUntilReady(t *testing.T) {
+	readyFile := filepath.Join(t.TempDir(), "ready")
+	for _, tc := range []struct {
+		name    string
+		pid     int
+		ready   bool
+		wantErr string
+	}{
+		{name: "confirmed and ready", pid: os.Getpid(), ready: true},
+		{name: "primary exited", pid: 99999999, wantErr: "primary process exited within 10ms of starting"},
+		{name: "not ready in time", pid: os.Getpid(),
+			wantErr: "primary process did not become ready within 600ms waiting for file '" + readyFile + "' to exist"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.NoError(t, os.RemoveAll(readyFile))
+			if tc.ready {
+				require.NoError(t, os.WriteFile(readyFile, nil, 0644))
+			}
+			monitor := &ProcessMonitor{
+				PrimaryPID: tc.pid,
+				Ready: &ReadyCheck{
+					ConfirmationPeriod: 10 * time.Millisecond,
+					Readiness:          &ReadinessConfig{File: readyFile, Timeout: 600 * time.Millisecond},
+				},
+			}
+			err := monitor.waitUntilReady()
+			if tc.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.wantErr)
+			}
+		})
+	}
+}
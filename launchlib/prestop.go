@@ -0,0 +1,142 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// preStopCommandWaitDelay is how long a preStop command that has been killed after its timeout is waited for.
+const preStopCommandWaitDelay = time.Second
+
+// RunPreStopActions runs the given preStop actions of processes by name concurrently, running commands in the given
+// directory with their output written to the given writer, and waits for all of them to finish or time out. A failed
+// action is written to the writer rather than returned, since it must not prevent its process from being stopped.
+func RunPreStopActions(actions map[string]*PreStopConfig, dir string, stdout io.Writer) {
+	names := make([]string, 0, len(actions))
+	for name, action := range actions {
+		if action != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	stdout = &lockedWriter{w: stdout}
+	var wg sync.WaitGroup
+	for _, name := range names {
+		wg.Add(1)
+		go func(name string, action *PreStopConfig) {
+			defer wg.Done()
+			_, _ = fmt.Fprintf(stdout, "Running preStop action of '%s': %s\n", name, action.describe())
+			if err := RunPreStop(action, dir, stdout); err != nil {
+				_, _ = fmt.Fprintf(stdout, "preStop action of '%s' failed, stopping it regardless: %v\n", name, err)
+			}
+		}(name, actions[name])
+	}
+	wg.Wait()
+}
+
+// RunPreStop runs the given preStop action, abandoning it once its timeout has elapsed. Commands are run in the given
+// directory, with their output written to the given writer.
+func RunPreStop(action *PreStopConfig, dir string, stdout io.Writer) error {
+	ctx := context.Background()
+	if action.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, action.Timeout)
+		defer cancel()
+	}
+
+	switch {
+	case action.HTTPURL != "":
+		method := action.HTTPMethod
+		if method == "" {
+			method = http.MethodGet
+		}
+		req, err := http.NewRequestWithContext(ctx, method, action.HTTPURL, nil)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create request to '%s'", action.HTTPURL)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return errors.Wrapf(err, "request to '%s' failed", action.HTTPURL)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return errors.Errorf("request to '%s' responded with status %d", action.HTTPURL, resp.StatusCode)
+		}
+		return nil
+	case len(action.Command) > 0:
+		cmd := exec.CommandContext(ctx, action.Command[0], action.Command[1:]...)
+		cmd.Dir = dir
+		cmd.Stdout = stdout
+		cmd.Stderr = stdout
+		cmd.WaitDelay = preStopCommandWaitDelay
+		if err := cmd.Run(); err != nil {
+			if ctx.Err() != nil {
+				return errors.Wrapf(ctx.Err(), "command '%s' did not finish within %s", action.Command[0],
+					action.Timeout)
+			}
+			return errors.Wrapf(err, "command '%s' failed", action.Command[0])
+		}
+		return nil
+	case action.Sleep > 0:
+		timer := time.NewTimer(action.Sleep)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			return nil
+		case <-ctx.Done():
+			return errors.Wrapf(ctx.Err(), "sleep of %s did not finish within %s", action.Sleep, action.Timeout)
+		}
+	}
+	return nil
+}
+
+// lockedWriter is a writer that can be written to concurrently.
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
+
+// describe returns a description of the preStop action.
+func (action *PreStopConfig) describe() string {
+	switch {
+	case action.HTTPURL != "":
+		method := action.HTTPMethod
+		if method == "" {
+			method = http.MethodGet
+		}
+		return fmt.Sprintf("%s %s", method, action.HTTPURL)
+	case len(action.Command) > 0:
+		return fmt.Sprintf("command %v", action.Command)
+	default:
+		return fmt.Sprintf("sleep %s", action.Sleep)
+	}
+}
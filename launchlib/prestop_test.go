@@ -0,0 +1,96 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunPreStop_HTTP(t *testing.T) {
+	var methods []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		if r.URL.Path != "/drain" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	assert.NoError(t, RunPreStop(&PreStopConfig{HTTPURL: server.URL + "/drain", HTTPMethod: http.MethodPost,
+		Timeout: time.Second}, "", &bytes.Buffer{}))
+	err := RunPreStop(&PreStopConfig{HTTPURL: server.URL + "/missing", Timeout: time.Second}, "", &bytes.Buffer{})
+	assert.EqualError(t, err, "request to '"+server.URL+"/missing' responded with status 404")
+	assert.Equal(t, []string{http.MethodPost, http.MethodGet}, methods)
+}
+
+func TestRunPreStop_HTTPTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	start := time.Now()
+	err := RunPreStop(&PreStopConfig{HTTPURL: server.URL, Timeout: 100 * time.Millisecond}, "", &bytes.Buffer{})
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestRunPreStop_Command(t *testing.T) {
+	dir := t.TempDir()
+	var output bytes.Buffer
+	require.NoError(t, RunPreStop(&PreStopConfig{Command: []string{"pwd"}, Timeout: time.Second}, dir, &output))
+	assert.Equal(t, dir+"\n", output.String())
+
+	err := RunPreStop(&PreStopConfig{Command: []string{"false"}, Timeout: time.Second}, dir, &output)
+	assert.EqualError(t, err, "command 'false' failed: exit status 1")
+
+	err = RunPreStop(&PreStopConfig{Command: []string{"sleep", "10"}, Timeout: 100 * time.Millisecond}, dir, &output)
+	assert.EqualError(t, err, "command 'sleep' did not finish within 100ms: context deadline exceeded")
+}
+
+func TestRunPreStop_Sleep(t *testing.T) {
+	start := time.Now()
+	assert.NoError(t, RunPreStop(&PreStopConfig{Sleep: 50 * time.Millisecond, Timeout: time.Second}, "",
+		&bytes.Buffer{}))
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+	err := RunPreStop(&PreStopConfig{Sleep: time.Second, Timeout: 50 * time.Millisecond}, "", &bytes.Buffer{})
+	assert.EqualError(t, err, "sleep of 1s did not finish within 50ms: context deadline exceeded")
+}
+
+func TestRunPreStopActions_LogsFailures(t *testing.T) {
+	var output bytes.Buffer
+	start := time.Now()
+	RunPreStopActions(map[string]*PreStopConfig{
+		"primary": {Sleep: 100 * time.Millisecond, Timeout: time.Second},
+		"sidecar": {Command: []string{"false"}, Timeout: time.Second},
+		"other":   nil,
+	}, "", &output)
+	assert.Less(t, time.Since(start), time.Second, "actions should run concurrently")
+	assert.Contains(t, output.String(), "Running preStop action of 'primary': sleep 100ms\n")
+	assert.Contains(t, output.String(), "Running preStop action of 'sidecar': command [false]\n")
+	assert.Contains(t, output.String(), "preStop action of 'sidecar' failed, stopping it regardless: command 'false' "+
+		"failed: exit status 1\n")
+	assert.NotContains(t, output.String(), "'other'")
+}
@@ -0,0 +1,83 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// ReadinessPollInterval is how often a readiness check is run while waiting for it to pass.
+	ReadinessPollInterval = 500 * time.Millisecond
+	readinessCheckTimeout = time.Second
+)
+
+// IsReady returns whether the readiness check configured by the given readiness configuration passes: the file exists,
+// the TCP port on localhost accepts connections, or a GET of the HTTP URL responds with a 2xx status.
+func IsReady(readiness *ReadinessConfig) bool {
+	switch {
+	case readiness.File != "":
+		_, err := os.Stat(readiness.File)
+		return err == nil
+	case readiness.TCPPort != 0:
+		conn, err := net.DialTimeout("tcp", net.JoinHostPort("localhost", strconv.Itoa(readiness.TCPPort)),
+			readinessCheckTimeout)
+		if err != nil {
+			return false
+		}
+		_ = conn.Close()
+		return true
+	case readiness.HTTPURL != "":
+		client := http.Client{Timeout: readinessCheckTimeout}
+		resp, err := client.Get(readiness.HTTPURL)
+		if err != nil {
+			return false
+		}
+		_ = resp.Body.Close()
+		return resp.StatusCode >= 200 && resp.StatusCode < 300
+	}
+	return true
+}
+
+// RemoveReadinessFile removes the file of the readiness check configured by the given readiness configuration, if any,
+// so that a file left behind by a previous run of the process cannot make the process appear ready before it is.
+func RemoveReadinessFile(readiness *ReadinessConfig) error {
+	if readiness == nil || readiness.File == "" {
+		return nil
+	}
+	if err := os.Remove(readiness.File); err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "failed to remove readiness file '%s'", readiness.File)
+	}
+	return nil
+}
+
+// DescribeReadiness returns a description of the readiness check configured by the given readiness configuration.
+func DescribeReadiness(readiness *ReadinessConfig) string {
+	switch {
+	case readiness.File != "":
+		return fmt.Sprintf("file '%s' to exist", readiness.File)
+	case readiness.TCPPort != 0:
+		return fmt.Sprintf("port %d to accept connections", readiness.TCPPort)
+	default:
+		return fmt.Sprintf("'%s' to respond successfully", readiness.HTTPURL)
+	}
+}
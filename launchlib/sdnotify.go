@@ -0,0 +1,91 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// The environment variables systemd sets for a service to notify it of its state, as per sd_notify(3) and
+// sd_watchdog_enabled(3).
+const (
+	NotifySocketEnvVar = "NOTIFY_SOCKET"
+	WatchdogUsecEnvVar = "WATCHDOG_USEC"
+	WatchdogPidEnvVar  = "WATCHDOG_PID"
+)
+
+// The states a service notifies systemd of.
+const (
+	SdNotifyReady    = "READY=1"
+	SdNotifyStopping = "STOPPING=1"
+	SdNotifyWatchdog = "WATCHDOG=1"
+)
+
+// SdNotifyMainPid returns the state that notifies systemd that the main process of the service is the given pid.
+func SdNotifyMainPid(pid int) string {
+	return "MAINPID=" + strconv.Itoa(pid)
+}
+
+// SdNotify sends the given states to systemd over the datagram socket given by $NOTIFY_SOCKET, as per sd_notify(3).
+// Returns false without sending anything if $NOTIFY_SOCKET is not set, i.e. if the service was not started by systemd
+// with Type=notify.
+func SdNotify(states ...string) (bool, error) {
+	socket := os.Getenv(NotifySocketEnvVar)
+	if socket == "" {
+		return false, nil
+	}
+	// A socket in the abstract namespace is given with a leading '@', which the net package also uses to denote one.
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to connect to notify socket '%s'", socket)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	if _, err := conn.Write([]byte(strings.Join(states, "\n"))); err != nil {
+		return false, errors.Wrapf(err, "failed to write to notify socket '%s'", socket)
+	}
+	return true, nil
+}
+
+// SdWatchdogInterval returns how often the process with the given pid must notify systemd that it is alive, which is
+// half of $WATCHDOG_USEC as recommended by sd_watchdog_enabled(3). Returns 0 if the watchdog is not enabled, or if
+// $WATCHDOG_PID is set to another pid.
+func SdWatchdogInterval(pid int) (time.Duration, error) {
+	usecStr := os.Getenv(WatchdogUsecEnvVar)
+	if usecStr == "" {
+		return 0, nil
+	}
+	usec, err := strconv.ParseInt(usecStr, 10, 64)
+	if err != nil || usec <= 0 {
+		return 0, errors.Errorf("invalid %s '%s'", WatchdogUsecEnvVar, usecStr)
+	}
+	if pidStr := os.Getenv(WatchdogPidEnvVar); pidStr != "" {
+		watchdogPid, err := strconv.Atoi(pidStr)
+		if err != nil {
+			return 0, errors.Errorf("invalid %s '%s'", WatchdogPidEnvVar, pidStr)
+		}
+		if watchdogPid != pid {
+			return 0, nil
+		}
+	}
+	return time.Duration(usec) * time.Microsecond / 2, nil
+}
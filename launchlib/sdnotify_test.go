@@ -0,0 +1,94 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"net"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSdNotify(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	require.NoError(t, err)
+	defer func() {
+		_ = conn.Close()
+	}()
+	t.Setenv(NotifySocketEnvVar, socket)
+
+	sent, err := SdNotify(SdNotifyMainPid(1234), SdNotifyReady)
+	require.NoError(t, err)
+	assert.True(t, sent)
+
+	buf := make([]byte, 1024)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "MAINPID=1234\nREADY=1", string(buf[:n]))
+}
+
+func TestSdNotify_NoSocket(t *testing.T) {
+	t.Setenv(NotifySocketEnvVar, "")
+
+	sent, err := SdNotify(SdNotifyReady)
+	assert.NoError(t, err)
+	assert.False(t, sent)
+}
+
+func TestSdNotify_MissingSocket(t *testing.T) {
+	t.Setenv(NotifySocketEnvVar, filepath.Join(t.TempDir(), "notify.sock"))
+
+	sent, err := SdNotify(SdNotifyReady)
+	assert.Error(t, err)
+	assert.False(t, sent)
+}
+
+func TestSdWatchdogInterval(t *testing.T) {
+	const pid = 1234
+	for _, tc := range []struct {
+		name      string
+		usec      string
+		pid       string
+		want      time.Duration
+		expectErr bool
+	}{
+		{name: "not enabled"},
+		{name: "enabled", usec: "10000000", want: 5 * time.Second},
+		{name: "enabled for pid", usec: "10000000", pid: strconv.Itoa(pid), want: 5 * time.Second},
+		{name: "enabled for other pid", usec: "10000000", pid: "1"},
+		{name: "invalid timeout", usec: "ten", expectErr: true},
+		{name: "non-positive timeout", usec: "0", expectErr: true},
+		{name: "invalid pid", usec: "10000000", pid: "one", expectErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv(WatchdogUsecEnvVar, tc.usec)
+			t.Setenv(WatchdogPidEnvVar, tc.pid)
+
+			got, err := SdWatchdogInterval(pid)
+			if tc.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.want, got)
+			}
+		})
+	}
+}
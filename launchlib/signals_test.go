@@ -0,0 +1,51 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"strconv"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSignal(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		signal    string
+		want      syscall.Signal
+		expectErr bool
+	}{
+		{name: "with prefix", signal: "SIGHUP", want: syscall.SIGHUP},
+		{name: "without prefix", signal: "TERM", want: syscall.SIGTERM},
+		{name: "lower case", signal: "sigusr2", want: syscall.SIGUSR2},
+		{name: "number", signal: "3", want: syscall.SIGQUIT},
+		{name: "unknown name", signal: "SIGFOO", expectErr: true},
+		{name: "non-positive number", signal: "0", expectErr: true},
+		{name: "highest number", signal: strconv.Itoa(maxSignal), want: syscall.Signal(maxSignal)},
+		{name: "number above highest", signal: strconv.Itoa(maxSignal + 1), expectErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseSignal(tc.signal)
+			if tc.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.want, got)
+			}
+		})
+	}
+}